@@ -0,0 +1,109 @@
+// Command fakeproviders runs local fakes of the Digiflazz, Duitku and H2H APIs.
+//
+// Point the app at them with DIGI_BASE_URL=http://localhost:9001,
+// DUITKU_BASE_URL=http://localhost:9002 and H2H_BASE_URL=http://localhost:9003.
+// Outcomes can be preloaded with -script or changed at runtime through
+// POST /_fake/script on each fake.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/wafi04/backendvazzz/pkg/config"
+	"github.com/wafi04/backendvazzz/pkg/fakeprovider"
+	"github.com/wafi04/backendvazzz/pkg/lib"
+	"github.com/wafi04/backendvazzz/pkg/supplier"
+)
+
+type scriptFile struct {
+	Digiflazz []fakeprovider.OutcomeScript `json:"digiflazz"`
+	Duitku    []fakeprovider.PaymentScript `json:"duitku"`
+	H2H       []fakeprovider.OutcomeScript `json:"h2h"`
+}
+
+func main() {
+	config.LoadEnv()
+
+	digiflazzAddr := flag.String("digiflazz-addr", ":9001", "listen address of the fake Digiflazz")
+	duitkuAddr := flag.String("duitku-addr", ":9002", "listen address of the fake Duitku")
+	h2hAddr := flag.String("h2h-addr", ":9003", "listen address of the fake H2H supplier")
+	callbackBase := flag.String("callback-base", config.GetEnv("CALLBACK_BASE_URL", "http://localhost:8080"), "base URL of the app receiving callbacks")
+	scriptPath := flag.String("script", "", "JSON file with initial outcomes")
+	flag.Parse()
+
+	base := strings.TrimRight(*callbackBase, "/")
+
+	digiflazz := fakeprovider.NewDigiflazz(lib.DigiConfig{
+		DigiUsername:  config.GetEnv("DIGI_USERNAME", ""),
+		DigiKey:       config.GetEnv("DIGI_API_KEY", ""),
+		WebhookSecret: config.GetEnv("DIGI_WEBHOOK_SECRET", ""),
+		CallbackUrl:   base + "/api/transactions/callback/digiflazz",
+	})
+	duitku := fakeprovider.NewDuitku(lib.DuitkuConfig{
+		MerchantCode: config.GetEnv("DUITKU_MERCHANT_CODE", ""),
+		Key:          config.GetEnv("DUITKU_API_KEY", ""),
+		CallbackUrl:  base + "/api/transactions/callback/duitku",
+	})
+
+	h2hName := config.GetEnv("H2H_NAME", "h2h")
+	h2h := fakeprovider.NewH2H(supplier.H2HConfig{
+		Name:           h2hName,
+		MemberID:       config.GetEnv("H2H_MEMBER_ID", ""),
+		Pin:            config.GetEnv("H2H_PIN", ""),
+		Password:       config.GetEnv("H2H_PASSWORD", ""),
+		CallbackSecret: config.GetEnv("H2H_CALLBACK_SECRET", ""),
+	}, base+"/api/transactions/callback/"+h2hName)
+
+	if *scriptPath != "" {
+		if err := loadScript(*scriptPath, digiflazz, duitku, h2h); err != nil {
+			log.Fatalf("Failed to load script: %v", err)
+		}
+	}
+
+	go func() {
+		log.Printf("Fake Digiflazz listening on %s", *digiflazzAddr)
+		log.Fatal(http.ListenAndServe(*digiflazzAddr, digiflazz.Handler()))
+	}()
+
+	go func() {
+		log.Printf("Fake H2H listening on %s", *h2hAddr)
+		log.Fatal(http.ListenAndServe(*h2hAddr, h2h.Handler()))
+	}()
+
+	log.Printf("Fake Duitku listening on %s", *duitkuAddr)
+	log.Fatal(http.ListenAndServe(*duitkuAddr, duitku.Handler()))
+}
+
+func loadScript(path string, digiflazz *fakeprovider.Digiflazz, duitku *fakeprovider.Duitku, h2h *fakeprovider.H2H) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var script scriptFile
+	if err := json.Unmarshal(data, &script); err != nil {
+		return err
+	}
+
+	for _, s := range script.Digiflazz {
+		if err := digiflazz.ApplyScript(s); err != nil {
+			return err
+		}
+	}
+	for _, s := range script.Duitku {
+		if err := duitku.ApplyScript(s); err != nil {
+			return err
+		}
+	}
+	for _, s := range script.H2H {
+		if err := h2h.ApplyScript(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
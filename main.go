@@ -12,20 +12,15 @@ import (
 )
 
 func main() {
-	config.LoadEnv()
-	// Load environment variables
-	dbURL := config.GetEnv("DATABASE_URL", "")
-	if dbURL == "" {
-		log.Fatal("DATABASE_URL environment variable is required")
-	}
-
-	jwtSecret := config.GetEnv("JWT_SECRET", "")
-	if jwtSecret == "" {
-		log.Fatal("JWT_SECRET environment variable is required")
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
 	}
+	log.Printf("Running in %s mode", cfg.Mode)
 
 	// Connect to database
-	db, err := sql.Open("postgres", dbURL)
+	db, err := sql.Open("postgres", cfg.DatabaseURL)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
@@ -42,11 +37,11 @@ func main() {
 	r := gin.Default()
 
 	// CORS configuration
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"http://localhost:3000", "https://your-frontend.com"}
-	config.AllowCredentials = true
-	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
-	r.Use(cors.New(config))
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowOrigins = cfg.CORSOrigins
+	corsConfig.AllowCredentials = true
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "Idempotency-Key"}
+	r.Use(cors.New(corsConfig))
 
 	api := r.Group("/api")
 
@@ -56,7 +51,7 @@ func main() {
 
 	server.SetupRoutesMethod(api, db)
 
-	server.GetProductFromDigiflazz(api, db)
+	server.GetProductFromDigiflazz(api, db, cfg)
 
 	server.SetupRoutesUser(api, db)
 
@@ -64,9 +59,20 @@ func main() {
 
 	server.SetupRoutesProducts(api, db)
 
-	server.SetUpTransactionRoutes(api, db)
-	server.SetupDepositTransaction(api, db)
+	server.SetUpTransactionRoutes(api, db, cfg)
+	server.SetupDepositTransaction(api, db, cfg)
 	server.SetupAnalyticsRoutes(api, db)
+	server.SetupProviderBalanceRoutes(api, db, cfg)
+	server.SetupPostpaidRoutes(api, db, cfg)
+	server.SetupCategoryMappingRoutes(api, db)
+	server.SetupMarkupRoutes(api, db)
+	server.SetupFlashSaleRoutes(api, db)
+
+	// Background workers
+	workers := server.SetupWorkers(db, cfg)
+	workers.Start()
+	defer workers.Stop()
+
 	// Health check endpoint
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -91,10 +97,5 @@ func main() {
 	})
 
 	// Start server
-	port := "8080"
-	if port == "" {
-		port = "8080"
-	}
-
-	log.Fatal(r.Run(":" + port))
+	log.Fatal(r.Run(":" + cfg.Port))
 }
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS transaction_status_history (
+    id          SERIAL PRIMARY KEY,
+    order_id    VARCHAR(100) NOT NULL,
+    from_status VARCHAR(20),
+    to_status   VARCHAR(20) NOT NULL,
+    actor       VARCHAR(100) NOT NULL,
+    source      VARCHAR(20) NOT NULL,
+    payload     TEXT,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_status_history_order_id
+    ON transaction_status_history (order_id, created_at);
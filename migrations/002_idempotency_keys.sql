@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id              SERIAL PRIMARY KEY,
+    scope           VARCHAR(50) NOT NULL,
+    username        VARCHAR(100) NOT NULL DEFAULT '',
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash    VARCHAR(64) NOT NULL,
+    status_code     INTEGER,
+    response_body   TEXT,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (scope, username, idempotency_key)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE payment_methods ADD COLUMN IF NOT EXISTS expiry_period INTEGER;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS voucher_code VARCHAR(100);
@@ -0,0 +1,3 @@
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS settled_by VARCHAR(20);
+
+ALTER TABLE deposits ADD COLUMN IF NOT EXISTS settled_by VARCHAR(20);
@@ -0,0 +1,3 @@
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS status_check_attempts INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS next_status_check_at TIMESTAMP;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS callback_rejections (
+    id         SERIAL PRIMARY KEY,
+    provider   VARCHAR(20) NOT NULL,
+    order_id   VARCHAR(100),
+    reason     TEXT NOT NULL,
+    payload    TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_callback_rejections_created_at
+    ON callback_rejections (created_at);
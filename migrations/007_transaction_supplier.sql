@@ -0,0 +1,5 @@
+-- Supplier yang memproses order, dipakai untuk status check dan validasi callback
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS supplier VARCHAR(50);
+
+UPDATE transactions SET supplier = 'digiflazz'
+WHERE supplier IS NULL AND status NOT IN ('PENDING', 'EXPIRED', 'CANCELED');
@@ -0,0 +1,35 @@
+-- SKU alternatif per produk, dicoba berurutan jika supplier utama gagal
+CREATE TABLE IF NOT EXISTS service_supplier_routes (
+    id                  SERIAL PRIMARY KEY,
+    service_provider_id VARCHAR(100) NOT NULL,
+    priority            INTEGER NOT NULL DEFAULT 1,
+    supplier            VARCHAR(50) NOT NULL,
+    sku                 VARCHAR(100) NOT NULL,
+    price               INTEGER NOT NULL DEFAULT 0,
+    in_stock            BOOLEAN NOT NULL DEFAULT TRUE,
+    status              VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at          TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (service_provider_id, supplier, sku)
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_supplier_routes_service
+    ON service_supplier_routes (service_provider_id, priority);
+
+-- Setiap percobaan order ke supplier; supplier_ref adalah ref_id yang dikirim ke supplier
+CREATE TABLE IF NOT EXISTS fulfillment_attempts (
+    id           SERIAL PRIMARY KEY,
+    order_id     VARCHAR(100) NOT NULL,
+    attempt      INTEGER NOT NULL,
+    supplier     VARCHAR(50) NOT NULL,
+    sku          VARCHAR(100) NOT NULL,
+    supplier_ref VARCHAR(120) NOT NULL UNIQUE,
+    status       VARCHAR(20) NOT NULL,
+    message      TEXT,
+    price        INTEGER NOT NULL DEFAULT 0,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (order_id, attempt)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fulfillment_attempts_order ON fulfillment_attempts (order_id);
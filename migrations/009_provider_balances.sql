@@ -0,0 +1,18 @@
+-- Saldo deposit terakhir di tiap supplier, dari response transaksi dan cek saldo berkala
+CREATE TABLE IF NOT EXISTS provider_balances (
+    supplier   VARCHAR(50) PRIMARY KEY,
+    balance    BIGINT NOT NULL,
+    source     VARCHAR(20) NOT NULL,
+    alerting   BOOLEAN NOT NULL DEFAULT FALSE,
+    checked_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS provider_balance_alerts (
+    id         SERIAL PRIMARY KEY,
+    supplier   VARCHAR(50) NOT NULL,
+    balance    BIGINT NOT NULL,
+    threshold  BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_provider_balance_alerts_created ON provider_balance_alerts (created_at DESC);
@@ -0,0 +1,19 @@
+-- Hasil inquiry tagihan pascabayar. quote_id dipakai sebagai order_id dan ref_id ke supplier
+CREATE TABLE IF NOT EXISTS bill_quotes (
+    id             SERIAL PRIMARY KEY,
+    quote_id       VARCHAR(100) NOT NULL UNIQUE,
+    supplier       VARCHAR(50) NOT NULL,
+    product_code   VARCHAR(100) NOT NULL,
+    customer_no    VARCHAR(100) NOT NULL,
+    customer_name  VARCHAR(255),
+    bill_amount    INTEGER NOT NULL,
+    purchase_price INTEGER NOT NULL,
+    admin_fee      INTEGER NOT NULL DEFAULT 0,
+    detail         JSONB,
+    status         VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+    expires_at     TIMESTAMP NOT NULL,
+    used_at        TIMESTAMP,
+    created_at     TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bill_quotes_active ON bill_quotes (expires_at) WHERE status = 'ACTIVE';
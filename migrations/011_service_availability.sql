@@ -0,0 +1,6 @@
+-- Jam cut-off harian dan stok dari price list supplier (format HH:MM, WIB)
+ALTER TABLE services ADD COLUMN IF NOT EXISTS start_cut_off VARCHAR(5) NOT NULL DEFAULT '00:00';
+ALTER TABLE services ADD COLUMN IF NOT EXISTS end_cut_off VARCHAR(5) NOT NULL DEFAULT '00:00';
+ALTER TABLE services ADD COLUMN IF NOT EXISTS stock INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE services ADD COLUMN IF NOT EXISTS unlimited_stock BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE services ADD COLUMN IF NOT EXISTS buyer_product_status BOOLEAN NOT NULL DEFAULT TRUE;
@@ -0,0 +1,26 @@
+-- Riwayat sinkronisasi produk dari price list supplier
+CREATE TABLE IF NOT EXISTS sync_jobs (
+    id          SERIAL PRIMARY KEY,
+    trigger     VARCHAR(20) NOT NULL,
+    status      VARCHAR(20) NOT NULL DEFAULT 'RUNNING',
+    fetched     INTEGER NOT NULL DEFAULT 0,
+    created     INTEGER NOT NULL DEFAULT 0,
+    updated     INTEGER NOT NULL DEFAULT 0,
+    failed      INTEGER NOT NULL DEFAULT 0,
+    error       TEXT,
+    started_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_jobs_started ON sync_jobs (started_at DESC);
+
+CREATE TABLE IF NOT EXISTS sync_job_errors (
+    id           SERIAL PRIMARY KEY,
+    job_id       INTEGER NOT NULL REFERENCES sync_jobs(id) ON DELETE CASCADE,
+    sku          VARCHAR(100),
+    product_name VARCHAR(255),
+    message      TEXT NOT NULL,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_job_errors_job ON sync_job_errors (job_id);
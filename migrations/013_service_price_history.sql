@@ -0,0 +1,30 @@
+-- Riwayat harga produk, ditulis setiap sync saat harga beli atau harga jual berubah
+CREATE TABLE IF NOT EXISTS service_price_history (
+    id                 SERIAL PRIMARY KEY,
+    provider_id        VARCHAR(100) NOT NULL,
+    sync_job_id        INTEGER REFERENCES sync_jobs(id) ON DELETE SET NULL,
+    change_type        VARCHAR(20) NOT NULL,
+    old_price_purchase INTEGER,
+    new_price_purchase INTEGER NOT NULL,
+    old_price          INTEGER,
+    new_price          INTEGER NOT NULL,
+    old_price_reseller INTEGER,
+    new_price_reseller INTEGER NOT NULL,
+    old_price_platinum INTEGER,
+    new_price_platinum INTEGER NOT NULL,
+    created_at         TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_price_history_provider ON service_price_history (provider_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_service_price_history_job ON service_price_history (sync_job_id);
+
+-- Produk yang tidak lagi muncul di price list pada sebuah sync
+CREATE TABLE IF NOT EXISTS sync_job_disappeared (
+    id           SERIAL PRIMARY KEY,
+    job_id       INTEGER NOT NULL REFERENCES sync_jobs(id) ON DELETE CASCADE,
+    provider_id  VARCHAR(100) NOT NULL,
+    service_name VARCHAR(255),
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_job_disappeared_job ON sync_job_disappeared (job_id);
@@ -0,0 +1,6 @@
+-- Alasan produk dinonaktifkan otomatis, misal VANISHED saat hilang dari price list supplier
+ALTER TABLE services ADD COLUMN IF NOT EXISTS inactive_reason VARCHAR(50);
+ALTER TABLE services ADD COLUMN IF NOT EXISTS deactivated_at TIMESTAMP;
+
+ALTER TABLE sync_jobs ADD COLUMN IF NOT EXISTS deactivated INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sync_jobs ADD COLUMN IF NOT EXISTS reactivated INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,30 @@
+-- Aturan pemetaan produk supplier ke kategori/sub kategori, dicek berurutan dari priority terkecil
+CREATE TABLE IF NOT EXISTS category_mapping_rules (
+    id                SERIAL PRIMARY KEY,
+    priority          INTEGER NOT NULL DEFAULT 100,
+    match_field       VARCHAR(20) NOT NULL CHECK (match_field IN ('brand', 'category', 'type', 'sku_prefix', 'name_regex')),
+    pattern           VARCHAR(255) NOT NULL,
+    category_id       INTEGER NOT NULL,
+    sub_category_id   INTEGER,
+    auto_sub_category BOOLEAN NOT NULL DEFAULT FALSE,
+    status            VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at        TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_category_mapping_rules_priority ON category_mapping_rules (priority, id) WHERE status = 'active';
+
+-- Produk dari price list yang belum punya kategori, menunggu diselesaikan admin
+CREATE TABLE IF NOT EXISTS unmapped_products (
+    provider_id   VARCHAR(100) PRIMARY KEY,
+    product_name  VARCHAR(255) NOT NULL,
+    brand         VARCHAR(100),
+    category      VARCHAR(100),
+    type          VARCHAR(100),
+    seen_count    INTEGER NOT NULL DEFAULT 1,
+    first_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_seen_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    resolved_at   TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_unmapped_products_open ON unmapped_products (last_seen_at DESC) WHERE resolved_at IS NULL;
@@ -0,0 +1,27 @@
+-- Aturan markup harga jual, dicek berurutan dari priority terkecil. Kolom scope NULL berarti semua.
+CREATE TABLE IF NOT EXISTS markup_rules (
+    id              SERIAL PRIMARY KEY,
+    name            VARCHAR(100) NOT NULL,
+    priority        INTEGER NOT NULL DEFAULT 100,
+    category_id     INTEGER,
+    sub_category_id INTEGER,
+    brand           VARCHAR(100),
+    sku             VARCHAR(100),
+    min_price       INTEGER,
+    max_price       INTEGER,
+    mode            VARCHAR(10) NOT NULL CHECK (mode IN ('percent', 'fixed')),
+    profit          INTEGER NOT NULL,
+    profit_reseller INTEGER NOT NULL,
+    profit_platinum INTEGER NOT NULL,
+    min_margin      INTEGER NOT NULL DEFAULT 0,
+    rounding        INTEGER NOT NULL DEFAULT 0,
+    status          VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_markup_rules_priority ON markup_rules (priority, id) WHERE status = 'active';
+
+-- Brand dari price list dan aturan markup yang terakhir dipakai
+ALTER TABLE services ADD COLUMN IF NOT EXISTS brand VARCHAR(100);
+ALTER TABLE services ADD COLUMN IF NOT EXISTS markup_rule_id INTEGER;
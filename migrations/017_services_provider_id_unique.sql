@@ -0,0 +1,27 @@
+-- Sync memakai INSERT ... ON CONFLICT (provider_id), jadi SKU supplier harus unik.
+-- Duplikat tidak dihapus otomatis karena bisa saja dipakai oleh transaksi atau diubah admin;
+-- migrasi gagal dan menampilkan daftar duplikat supaya bisa digabung manual lebih dulu.
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(provider_id || ' (id ' || ids || ')', ', ' ORDER BY provider_id)
+    INTO duplicates
+    FROM (
+        SELECT provider_id, string_agg(id::text, ', ' ORDER BY id) AS ids
+        FROM services
+        GROUP BY provider_id
+        HAVING COUNT(*) > 1
+    ) d;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'services has duplicate provider_id, merge them before applying this migration: %', duplicates;
+    END IF;
+END $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS services_provider_id_key ON services (provider_id);
+
+-- Durasi tiap tahap sync dalam milidetik
+ALTER TABLE sync_jobs ADD COLUMN IF NOT EXISTS fetch_ms BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE sync_jobs ADD COLUMN IF NOT EXISTS upsert_ms BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE sync_jobs ADD COLUMN IF NOT EXISTS duration_ms BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,16 @@
+-- Jadwal sync otomatis, satu baris dipakai bersama oleh semua instance API
+CREATE TABLE IF NOT EXISTS sync_schedule (
+    id          INTEGER PRIMARY KEY CHECK (id = 1),
+    cron        VARCHAR(100) NOT NULL DEFAULT '*/10 * * * *',
+    enabled     BOOLEAN NOT NULL DEFAULT FALSE,
+    next_run_at TIMESTAMPTZ,
+    last_run_at TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO sync_schedule (id) VALUES (1) ON CONFLICT (id) DO NOTHING;
+
+-- Instance yang menjalankan job, untuk status sync lintas replika
+ALTER TABLE sync_jobs ADD COLUMN IF NOT EXISTS instance VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_sync_jobs_running ON sync_jobs (id) WHERE status = 'RUNNING';
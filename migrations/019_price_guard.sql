@@ -0,0 +1,22 @@
+-- Perubahan harga beli dari supplier yang melebihi batas wajar ditahan sampai disetujui admin
+CREATE TABLE IF NOT EXISTS pending_price_changes (
+    id                 SERIAL PRIMARY KEY,
+    provider_id        VARCHAR(100) NOT NULL,
+    sync_job_id        INTEGER REFERENCES sync_jobs(id) ON DELETE SET NULL,
+    old_price_purchase INTEGER NOT NULL,
+    new_price_purchase INTEGER NOT NULL,
+    change_percent     NUMERIC(10, 2) NOT NULL,
+    status             VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    reviewed_by        VARCHAR(100),
+    reviewed_at        TIMESTAMP,
+    created_at         TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Satu perubahan PENDING per produk, sync berikutnya memperbarui baris yang sama
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pending_price_changes_open
+    ON pending_price_changes (provider_id) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_pending_price_changes_status ON pending_price_changes (status, created_at DESC);
+
+ALTER TABLE sync_jobs ADD COLUMN IF NOT EXISTS held INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sync_jobs ADD COLUMN IF NOT EXISTS below_cost INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,42 @@
+-- Kampanye flash sale dengan jadwal mulai dan selesai
+CREATE TABLE IF NOT EXISTS flash_sales (
+    id         SERIAL PRIMARY KEY,
+    title      VARCHAR(255) NOT NULL,
+    banner     VARCHAR(500),
+    start_at   TIMESTAMPTZ NOT NULL,
+    end_at     TIMESTAMPTZ NOT NULL,
+    status     VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (end_at > start_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_flash_sales_window ON flash_sales (end_at, start_at) WHERE status = 'active';
+
+-- Harga sale per SKU; quota dan per_customer_limit NULL berarti tanpa batas
+CREATE TABLE IF NOT EXISTS flash_sale_items (
+    id                 SERIAL PRIMARY KEY,
+    flash_sale_id      INTEGER NOT NULL REFERENCES flash_sales(id) ON DELETE CASCADE,
+    provider_id        VARCHAR(100) NOT NULL,
+    sale_price         INTEGER NOT NULL CHECK (sale_price > 0),
+    quota              INTEGER CHECK (quota > 0),
+    per_customer_limit INTEGER CHECK (per_customer_limit > 0),
+    sold               INTEGER NOT NULL DEFAULT 0,
+    created_at         TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (flash_sale_id, provider_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_flash_sale_items_provider ON flash_sale_items (provider_id);
+
+-- Order yang memakai kuota flash sale; released saat order gagal/kedaluwarsa dan kuota dikembalikan
+CREATE TABLE IF NOT EXISTS flash_sale_orders (
+    id           SERIAL PRIMARY KEY,
+    item_id      INTEGER NOT NULL REFERENCES flash_sale_items(id) ON DELETE CASCADE,
+    order_id     VARCHAR(100) NOT NULL UNIQUE,
+    customer_key VARCHAR(255) NOT NULL,
+    released     BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_flash_sale_orders_customer ON flash_sale_orders (item_id, customer_key) WHERE NOT released;
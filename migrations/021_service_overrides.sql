@@ -0,0 +1,5 @@
+-- Field yang diubah manual oleh admin; sync tidak menimpa field di daftar ini
+ALTER TABLE services ADD COLUMN IF NOT EXISTS locked_fields TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_services_category ON services (category_id, sub_category_id);
+CREATE INDEX IF NOT EXISTS idx_services_brand ON services (brand);
@@ -0,0 +1,11 @@
+-- Tipe produk dari price list supplier untuk filter katalog, terisi pada sync berikutnya
+ALTER TABLE services ADD COLUMN IF NOT EXISTS product_type VARCHAR(100);
+
+-- Pencarian katalog memakai ILIKE '%...%' pada nama dan SKU
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_services_name_trgm ON services USING gin (service_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_services_provider_id_trgm ON services USING gin (provider_id gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_services_catalog ON services (category_id, sub_category_id, price) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_services_product_type ON services (product_type);
+CREATE INDEX IF NOT EXISTS idx_services_flash_sale ON services (provider_id) WHERE is_flash_sale = 'active';
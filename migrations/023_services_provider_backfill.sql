@@ -0,0 +1,3 @@
+-- Produk lama dibuat sebelum kolom provider diisi dan semuanya berasal dari Digiflazz.
+-- Tanpa backfill, produk ini tidak pernah ikut dicek saat hilang dari price list.
+UPDATE services SET provider = 'digiflazz' WHERE COALESCE(provider, '') = '';
@@ -0,0 +1,4 @@
+-- Logo dan kategori tidak pernah ditulis sync, kuncinya dibuang supaya tidak muncul di lockedFields
+UPDATE services
+SET locked_fields = array_remove(array_remove(locked_fields, 'product_logo'), 'category')
+WHERE locked_fields && ARRAY['product_logo', 'category'];
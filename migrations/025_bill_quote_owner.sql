@@ -0,0 +1,2 @@
+-- Pemilik hasil inquiry; NULL untuk inquiry dari guest
+ALTER TABLE bill_quotes ADD COLUMN IF NOT EXISTS username VARCHAR(255);
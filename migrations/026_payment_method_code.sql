@@ -0,0 +1,12 @@
+-- Kode metode disimpan agar masa kedaluwarsa dicari dengan kunci yang sama seperti saat checkout.
+-- Kolom method tetap menyimpan nama untuk tampilan.
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS method_code VARCHAR(100);
+ALTER TABLE deposits ADD COLUMN IF NOT EXISTS method_code VARCHAR(100);
+
+UPDATE payments p SET method_code = pm.code
+FROM payment_methods pm
+WHERE p.method_code IS NULL AND pm.name = p.method;
+
+UPDATE deposits d SET method_code = pm.code
+FROM payment_methods pm
+WHERE d.method_code IS NULL AND pm.name = d.method;
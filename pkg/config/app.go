@@ -0,0 +1,282 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/wafi04/backendvazzz/pkg/lib"
+	"github.com/wafi04/backendvazzz/pkg/supplier"
+)
+
+const (
+	ModeSandbox    = "sandbox"
+	ModeProduction = "production"
+)
+
+// AppConfig berisi seluruh konfigurasi aplikasi yang dibaca sekali saat startup
+type AppConfig struct {
+	Mode            string
+	Port            string
+	DatabaseURL     string
+	JWTSecret       string
+	CallbackBaseURL string
+	CORSOrigins     []string
+	Digiflazz       lib.DigiConfig
+	Duitku          lib.DuitkuConfig
+	// H2H hanya aktif jika H2H_BASE_URL diisi
+	H2H         supplier.H2HConfig
+	Sync        SyncConfig
+	Fulfillment FulfillmentConfig
+	Balance     ProviderBalanceConfig
+	Postpaid    PostpaidConfig
+	Payment     PaymentConfig
+	StatusCheck StatusCheckConfig
+}
+
+// SyncConfig mengatur perilaku sinkronisasi produk
+type SyncConfig struct {
+	// MaxVanishedPercent membatalkan penonaktifan jika produk aktif yang hilang melebihi persentase ini
+	MaxVanishedPercent int
+	// MaxPriceChangePercent menahan perubahan harga beli di atas persen ini untuk disetujui admin, 0 = mati
+	MaxPriceChangePercent int
+	// DisableBelowCost menonaktifkan SKU yang harga jualnya di bawah harga beli supplier
+	DisableBelowCost bool
+}
+
+// FulfillmentConfig mengatur urutan dan batas waktu percobaan ke supplier
+type FulfillmentConfig struct {
+	// CheapestFirst mengurutkan rute berdasarkan harga termurah, bukan prioritas
+	CheapestFirst         bool
+	AttemptTimeoutSeconds int
+}
+
+// ProviderBalanceConfig mengatur alert saldo supplier dan penolakan order saat saldo kurang
+type ProviderBalanceConfig struct {
+	AlertThreshold  int64
+	AlertWebhookURL string
+	Gating          bool
+}
+
+// PaymentConfig mengatur masa berlaku pembayaran Duitku
+type PaymentConfig struct {
+	// ExpiryMinutes dipakai untuk metode pembayaran tanpa expiry_period sendiri
+	ExpiryMinutes int
+	// ExpiryGraceMinutes ditambahkan sebelum order dan deposit dianggap kedaluwarsa,
+	// supaya callback yang sedikit terlambat masih diterima
+	ExpiryGraceMinutes int
+	// ReconcileAfterMinutes adalah umur pembayaran PENDING sebelum statusnya dicek ke Duitku
+	ReconcileAfterMinutes int
+}
+
+// StatusCheckConfig mengatur pengecekan status order yang belum dijawab supplier
+type StatusCheckConfig struct {
+	AfterMinutes int
+	// MaxAttempts adalah batas pengecekan sebelum order masuk MANUAL_REVIEW
+	MaxAttempts int
+}
+
+type PostpaidConfig struct {
+	// QuoteValidityMinutes adalah lama hasil inquiry boleh dibayar
+	QuoteValidityMinutes int
+}
+
+// Load reads the configuration from the environment (and .env file when present)
+// and validates it.
+func Load() (*AppConfig, error) {
+	LoadEnv()
+
+	mode := strings.ToLower(GetEnv("APP_MODE", ModeSandbox))
+	callbackBaseURL := strings.TrimRight(GetEnv("CALLBACK_BASE_URL", ""), "/")
+
+	disableBelowCost, err := strconv.ParseBool(GetEnv("SYNC_DISABLE_BELOW_COST", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("SYNC_DISABLE_BELOW_COST must be true or false: %w", err)
+	}
+	cheapestFirst, err := strconv.ParseBool(GetEnv("FULFILLMENT_CHEAPEST_FIRST", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("FULFILLMENT_CHEAPEST_FIRST must be true or false: %w", err)
+	}
+	balanceGating, err := strconv.ParseBool(GetEnv("PROVIDER_BALANCE_GATING", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("PROVIDER_BALANCE_GATING must be true or false: %w", err)
+	}
+
+	cfg := &AppConfig{
+		Mode:            mode,
+		Port:            GetEnv("PORT", "8080"),
+		DatabaseURL:     GetEnv("DATABASE_URL", ""),
+		JWTSecret:       GetEnv("JWT_SECRET", ""),
+		CallbackBaseURL: callbackBaseURL,
+		CORSOrigins:     splitList(GetEnv("CORS_ORIGINS", "http://localhost:3000")),
+		Digiflazz: lib.DigiConfig{
+			DigiKey:       GetEnv("DIGI_API_KEY", ""),
+			DigiUsername:  GetEnv("DIGI_USERNAME", ""),
+			BaseUrl:       GetEnv("DIGI_BASE_URL", ""),
+			CallbackUrl:   callbackBaseURL + "/api/transactions/callback/digiflazz",
+			WebhookSecret: GetEnv("DIGI_WEBHOOK_SECRET", ""),
+			Testing:       mode == ModeSandbox,
+		},
+		Duitku: lib.DuitkuConfig{
+			Key:          GetEnv("DUITKU_API_KEY", ""),
+			MerchantCode: GetEnv("DUITKU_MERCHANT_CODE", ""),
+			Sandbox:      mode == ModeSandbox,
+			BaseUrl:      GetEnv("DUITKU_BASE_URL", ""),
+			CallbackUrl:  callbackBaseURL + "/api/transactions/callback/duitku",
+			ReturnUrl:    GetEnv("DUITKU_RETURN_URL", ""),
+		},
+		H2H: supplier.H2HConfig{
+			Name:           GetEnv("H2H_NAME", "h2h"),
+			BaseUrl:        GetEnv("H2H_BASE_URL", ""),
+			MemberID:       GetEnv("H2H_MEMBER_ID", ""),
+			Pin:            GetEnv("H2H_PIN", ""),
+			Password:       GetEnv("H2H_PASSWORD", ""),
+			CallbackSecret: GetEnv("H2H_CALLBACK_SECRET", ""),
+		},
+		Sync: SyncConfig{
+			MaxVanishedPercent:    parseIntOrInvalid(GetEnv("SYNC_MAX_VANISHED_PERCENT", "20")),
+			MaxPriceChangePercent: parseIntOrInvalid(GetEnv("SYNC_MAX_PRICE_CHANGE_PERCENT", "50")),
+			DisableBelowCost:      disableBelowCost,
+		},
+		Fulfillment: FulfillmentConfig{
+			CheapestFirst:         cheapestFirst,
+			AttemptTimeoutSeconds: parseIntOrInvalid(GetEnv("FULFILLMENT_ATTEMPT_TIMEOUT_SECONDS", "20")),
+		},
+		Balance: ProviderBalanceConfig{
+			AlertThreshold:  int64(parseIntOrInvalid(GetEnv("PROVIDER_BALANCE_ALERT_THRESHOLD", "500000"))),
+			AlertWebhookURL: GetEnv("PROVIDER_BALANCE_ALERT_WEBHOOK_URL", ""),
+			Gating:          balanceGating,
+		},
+		Payment: PaymentConfig{
+			ExpiryMinutes:         parseIntOrInvalid(GetEnv("PAYMENT_EXPIRY_MINUTES", "60")),
+			ExpiryGraceMinutes:    parseIntOrInvalid(GetEnv("PAYMENT_EXPIRY_GRACE_MINUTES", "10")),
+			ReconcileAfterMinutes: parseIntOrInvalid(GetEnv("DUITKU_RECONCILE_AFTER_MINUTES", "10")),
+		},
+		StatusCheck: StatusCheckConfig{
+			AfterMinutes: parseIntOrInvalid(GetEnv("DIGIFLAZZ_STATUS_CHECK_AFTER_MINUTES", "5")),
+			MaxAttempts:  parseIntOrInvalid(GetEnv("DIGIFLAZZ_STATUS_CHECK_MAX_ATTEMPTS", "8")),
+		},
+		Postpaid: PostpaidConfig{
+			QuoteValidityMinutes: parseIntOrInvalid(GetEnv("POSTPAID_QUOTE_VALIDITY_MINUTES", "30")),
+		},
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	jwtSecret = cfg.JWTSecret
+	return cfg, nil
+}
+
+// Validate returns every missing or invalid setting at once
+func (c *AppConfig) Validate() error {
+	var errs []error
+
+	if c.Mode != ModeSandbox && c.Mode != ModeProduction {
+		errs = append(errs, fmt.Errorf("APP_MODE must be %q or %q, got %q", ModeSandbox, ModeProduction, c.Mode))
+	}
+
+	required := []struct{ key, value string }{
+		{"DATABASE_URL", c.DatabaseURL},
+		{"JWT_SECRET", c.JWTSecret},
+		{"CALLBACK_BASE_URL", c.CallbackBaseURL},
+		{"DIGI_API_KEY", c.Digiflazz.DigiKey},
+		{"DIGI_USERNAME", c.Digiflazz.DigiUsername},
+		{"DIGI_WEBHOOK_SECRET", c.Digiflazz.WebhookSecret},
+		{"DUITKU_API_KEY", c.Duitku.Key},
+		{"DUITKU_MERCHANT_CODE", c.Duitku.MerchantCode},
+	}
+	for _, r := range required {
+		if r.value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", r.key))
+		}
+	}
+
+	if c.H2HEnabled() {
+		for _, r := range []struct{ key, value string }{
+			{"H2H_MEMBER_ID", c.H2H.MemberID},
+			{"H2H_PIN", c.H2H.Pin},
+			{"H2H_PASSWORD", c.H2H.Password},
+			{"H2H_CALLBACK_SECRET", c.H2H.CallbackSecret},
+		} {
+			if r.value == "" {
+				errs = append(errs, fmt.Errorf("%s is required when H2H_BASE_URL is set", r.key))
+			}
+		}
+	}
+
+	if c.CallbackBaseURL != "" {
+		u, err := url.Parse(c.CallbackBaseURL)
+		if err != nil || u.Host == "" {
+			errs = append(errs, fmt.Errorf("CALLBACK_BASE_URL is not a valid URL: %q", c.CallbackBaseURL))
+		} else if c.IsProduction() && u.Scheme != "https" {
+			errs = append(errs, errors.New("CALLBACK_BASE_URL must use https in production"))
+		}
+	}
+
+	if c.Sync.MaxVanishedPercent < 0 || c.Sync.MaxVanishedPercent > 100 {
+		errs = append(errs, errors.New("SYNC_MAX_VANISHED_PERCENT must be a number between 0 and 100"))
+	}
+	if c.Sync.MaxPriceChangePercent < 0 {
+		errs = append(errs, errors.New("SYNC_MAX_PRICE_CHANGE_PERCENT must be a number, 0 disables the price guard"))
+	}
+	if c.Fulfillment.AttemptTimeoutSeconds <= 0 {
+		errs = append(errs, errors.New("FULFILLMENT_ATTEMPT_TIMEOUT_SECONDS must be a positive number"))
+	}
+	if c.Balance.AlertThreshold < 0 {
+		errs = append(errs, errors.New("PROVIDER_BALANCE_ALERT_THRESHOLD must be a number, 0 disables the alert"))
+	}
+	if c.Payment.ExpiryMinutes <= 0 {
+		errs = append(errs, errors.New("PAYMENT_EXPIRY_MINUTES must be a positive number"))
+	}
+	if c.Payment.ExpiryGraceMinutes < 0 {
+		errs = append(errs, errors.New("PAYMENT_EXPIRY_GRACE_MINUTES must be a number, 0 disables the grace window"))
+	}
+	if c.Payment.ReconcileAfterMinutes <= 0 {
+		errs = append(errs, errors.New("DUITKU_RECONCILE_AFTER_MINUTES must be a positive number"))
+	}
+	if c.StatusCheck.AfterMinutes <= 0 {
+		errs = append(errs, errors.New("DIGIFLAZZ_STATUS_CHECK_AFTER_MINUTES must be a positive number"))
+	}
+	if c.StatusCheck.MaxAttempts <= 0 {
+		errs = append(errs, errors.New("DIGIFLAZZ_STATUS_CHECK_MAX_ATTEMPTS must be a positive number"))
+	}
+	if c.Postpaid.QuoteValidityMinutes <= 0 {
+		errs = append(errs, errors.New("POSTPAID_QUOTE_VALIDITY_MINUTES must be a positive number"))
+	}
+
+	if len(c.CORSOrigins) == 0 {
+		errs = append(errs, errors.New("CORS_ORIGINS must contain at least one origin"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *AppConfig) H2HEnabled() bool {
+	return c.H2H.BaseUrl != ""
+}
+
+func (c *AppConfig) IsProduction() bool {
+	return c.Mode == ModeProduction
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseIntOrInvalid returns -1 for values that are not a number so Validate can report them
+func parseIntOrInvalid(value string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return -1
+	}
+	return n
+}
@@ -7,10 +7,16 @@ import (
 	"github.com/joho/godotenv"
 )
 
+// LoadEnv membaca file .env (atau CONFIG_FILE) jika ada.
+// Environment variable yang sudah di-set tidak ditimpa.
 func LoadEnv() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
+	file := GetEnv("CONFIG_FILE", ".env")
+	if err := godotenv.Load(file); err != nil {
+		if os.IsNotExist(err) {
+			log.Printf("No %s file found, using environment variables", file)
+			return
+		}
+		log.Printf("Error loading %s file: %v", file, err)
 	}
 }
 
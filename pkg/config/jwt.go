@@ -2,19 +2,26 @@ package config
 
 import (
 	"errors"
-	"fmt"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 )
 
+// jwtSecret diisi oleh Load setelah konfigurasi tervalidasi
+var jwtSecret string
+
+var errJWTSecretNotSet = errors.New("JWT secret is not configured")
+
 func ValidateToken(tokenString string) (jwt.MapClaims, error) {
+	if jwtSecret == "" {
+		return nil, errJWTSecretNotSet
+	}
+
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
-		return []byte(os.Getenv("JWT_SECRET")), nil
+		return []byte(jwtSecret), nil
 	})
 
 	if err != nil {
@@ -45,10 +52,9 @@ func GenerateJWT(userID, username, role string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// Sign token dengan secret key
-	secretKey := os.Getenv("JWT_SECRET")
-	if secretKey == "" {
-		return "", fmt.Errorf("JWT_SECRET environment variable not set")
+	if jwtSecret == "" {
+		return "", errJWTSecretNotSet
 	}
 
-	return token.SignedString([]byte(secretKey))
+	return token.SignedString([]byte(jwtSecret))
 }
@@ -0,0 +1,174 @@
+package fakeprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// OutcomeScript is the JSON form of Outcome used by the /_fake/script endpoint.
+// An empty SKU replaces the default outcome.
+type OutcomeScript struct {
+	SKU            string `json:"sku"`
+	Status         string `json:"status"`
+	Message        string `json:"message"`
+	SN             string `json:"sn"`
+	Delay          string `json:"delay"`
+	Malformed      bool   `json:"malformed"`
+	CallbackStatus string `json:"callback_status"`
+	CallbackDelay  string `json:"callback_delay"`
+}
+
+// PaymentScript is the JSON form of PaymentOutcome used by the /_fake/script endpoint.
+// An empty PaymentMethod replaces the default outcome.
+type PaymentScript struct {
+	PaymentMethod string `json:"payment_method"`
+	Result        string `json:"result"`
+	Delay         string `json:"delay"`
+	Malformed     bool   `json:"malformed"`
+	CallbackDelay string `json:"callback_delay"`
+	SkipCallback  bool   `json:"skip_callback"`
+}
+
+func (s OutcomeScript) Outcome() (Outcome, error) {
+	delay, err := parseDuration(s.Delay)
+	if err != nil {
+		return Outcome{}, err
+	}
+	callbackDelay, err := parseDuration(s.CallbackDelay)
+	if err != nil {
+		return Outcome{}, err
+	}
+
+	switch s.Status {
+	case "", DigiflazzSukses, DigiflazzPending, DigiflazzGagal:
+	default:
+		return Outcome{}, fmt.Errorf("unknown status %q", s.Status)
+	}
+
+	return Outcome{
+		Status:         s.Status,
+		Message:        s.Message,
+		SN:             s.SN,
+		Delay:          delay,
+		Malformed:      s.Malformed,
+		CallbackStatus: s.CallbackStatus,
+		CallbackDelay:  callbackDelay,
+	}, nil
+}
+
+func (s PaymentScript) PaymentOutcome() (PaymentOutcome, error) {
+	delay, err := parseDuration(s.Delay)
+	if err != nil {
+		return PaymentOutcome{}, err
+	}
+	callbackDelay, err := parseDuration(s.CallbackDelay)
+	if err != nil {
+		return PaymentOutcome{}, err
+	}
+
+	switch s.Result {
+	case DuitkuPaid, DuitkuPending, DuitkuFailed:
+	default:
+		return PaymentOutcome{}, fmt.Errorf("unknown result %q", s.Result)
+	}
+
+	return PaymentOutcome{
+		Result:        s.Result,
+		Delay:         delay,
+		Malformed:     s.Malformed,
+		CallbackDelay: callbackDelay,
+		SkipCallback:  s.SkipCallback,
+	}, nil
+}
+
+// ApplyScript sets the default or per-SKU outcome from its JSON form
+func (d *Digiflazz) ApplyScript(script OutcomeScript) error {
+	outcome, err := script.Outcome()
+	if err != nil {
+		return err
+	}
+	if script.SKU == "" {
+		d.SetDefault(outcome)
+	} else {
+		d.Script(script.SKU, outcome)
+	}
+	return nil
+}
+
+// ApplyScript sets the default or per-method outcome from its JSON form
+func (d *Duitku) ApplyScript(script PaymentScript) error {
+	outcome, err := script.PaymentOutcome()
+	if err != nil {
+		return err
+	}
+	if script.PaymentMethod == "" {
+		d.SetDefault(outcome)
+	} else {
+		d.Script(script.PaymentMethod, outcome)
+	}
+	return nil
+}
+
+func (d *Digiflazz) handleScript(w http.ResponseWriter, r *http.Request) {
+	var script OutcomeScript
+	if err := json.NewDecoder(r.Body).Decode(&script); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := d.ApplyScript(script); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, script)
+}
+
+func (d *Digiflazz) handleList(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, d.Transactions())
+}
+
+func (d *Duitku) handleScript(w http.ResponseWriter, r *http.Request) {
+	var script PaymentScript
+	if err := json.NewDecoder(r.Body).Decode(&script); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := d.ApplyScript(script); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, script)
+}
+
+func (d *Duitku) handleList(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, d.Payments())
+}
+
+func (h *H2H) handleScript(w http.ResponseWriter, r *http.Request) {
+	var script OutcomeScript
+	if err := json.NewDecoder(r.Body).Decode(&script); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := h.ApplyScript(script); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, script)
+}
+
+func (h *H2H) handleList(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.Transactions())
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %w", value, err)
+	}
+	return duration, nil
+}
@@ -0,0 +1,186 @@
+package fakeprovider_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wafi04/backendvazzz/pkg/config"
+	"github.com/wafi04/backendvazzz/pkg/fakeprovider"
+	"github.com/wafi04/backendvazzz/pkg/lib"
+	"github.com/wafi04/backendvazzz/pkg/model"
+	"github.com/wafi04/backendvazzz/pkg/server"
+	"github.com/wafi04/backendvazzz/pkg/supplier"
+	"github.com/wafi04/backendvazzz/pkg/testdb"
+	"github.com/wafi04/backendvazzz/pkg/types"
+	"github.com/wafi04/backendvazzz/service/category"
+	"github.com/wafi04/backendvazzz/service/fulfillment"
+	"github.com/wafi04/backendvazzz/service/postpaid"
+	"github.com/wafi04/backendvazzz/service/product"
+	"github.com/wafi04/backendvazzz/service/providerbalance"
+	"github.com/wafi04/backendvazzz/service/transaction"
+)
+
+const testBalance = 1000000
+
+// checkoutEnv is the app wired to the fake Digiflazz: callbacks from the fake go to the
+// real /api/transactions/callback/digiflazz route
+type checkoutEnv struct {
+	db           *sql.DB
+	servers      *fakeprovider.Servers
+	transactions *transaction.TransactionRepository
+}
+
+func newCheckoutEnv(t *testing.T) *checkoutEnv {
+	t.Helper()
+	db := testdb.Open(t)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	app := httptest.NewServer(engine)
+	t.Cleanup(app.Close)
+
+	servers := fakeprovider.Start(lib.DigiConfig{
+		DigiUsername:  testDigiUsername,
+		DigiKey:       testDigiKey,
+		WebhookSecret: testDigiSecret,
+	}, lib.DuitkuConfig{MerchantCode: "DTEST", Key: "duitkukey"})
+	t.Cleanup(servers.Close)
+
+	cfg := &config.AppConfig{
+		Mode:        config.ModeSandbox,
+		Digiflazz:   servers.DigiConfig(),
+		Duitku:      servers.DuitkuConfig(),
+		Fulfillment: config.FulfillmentConfig{AttemptTimeoutSeconds: 5},
+		Postpaid:    config.PostpaidConfig{QuoteValidityMinutes: 30},
+		Payment:     config.PaymentConfig{ExpiryMinutes: 60, ExpiryGraceMinutes: 10, ReconcileAfterMinutes: 10},
+		StatusCheck: config.StatusCheckConfig{AfterMinutes: 5, MaxAttempts: 8},
+	}
+	cfg.Digiflazz.CallbackUrl = app.URL + "/api/transactions/callback/digiflazz"
+	server.SetUpTransactionRoutes(engine.Group("/api"), db, cfg)
+
+	suppliers := supplier.NewRegistry(supplier.NewDigiflazz(lib.NewDigiflazzService(cfg.Digiflazz)))
+	balances := providerbalance.NewProviderBalanceRepository(db, suppliers, cfg.Balance)
+	return &checkoutEnv{
+		db:      db,
+		servers: servers,
+		transactions: transaction.NewTransactionRepository(
+			db,
+			lib.NewDuitkuService(cfg.Duitku),
+			fulfillment.NewFulfillmentRepository(db, suppliers, balances, cfg.Fulfillment),
+			balances,
+			postpaid.NewPostpaidRepository(db, suppliers, balances, cfg.Postpaid),
+			cfg.Payment,
+		),
+	}
+}
+
+// createProduct adds an active Digiflazz product under a new category
+func (env *checkoutEnv) createProduct(t *testing.T, sku string) {
+	t.Helper()
+	ctx := context.Background()
+
+	code := testdb.UniqueID("cat")
+	if err := category.NewCategoryRepository(env.db).Create(ctx, model.CreateCategory{
+		Name: code, Code: code, Brand: "MOBILE LEGENDS", Status: "active", Type: "games", IsCheckNickname: "inactive",
+	}); err != nil {
+		t.Fatalf("failed to create category: %v", err)
+	}
+	var categoryID int
+	if err := env.db.QueryRow(`SELECT id FROM categories WHERE code = $1`, code).Scan(&categoryID); err != nil {
+		t.Fatalf("failed to read category: %v", err)
+	}
+
+	_, err := product.NewProductRepository(env.db).AdminCreate(ctx, product.CreateProductRequest{
+		ProviderID:    sku,
+		Provider:      supplier.DefaultSupplier,
+		ServiceName:   "TEST " + sku,
+		CategoryID:    categoryID,
+		PricePurchase: 19500,
+		Status:        "active",
+	})
+	if err != nil {
+		t.Fatalf("failed to create product %s: %v", sku, err)
+	}
+}
+
+func (env *checkoutEnv) orderStatus(t *testing.T, orderID string) string {
+	t.Helper()
+	var status string
+	if err := env.db.QueryRow(`SELECT status FROM transactions WHERE order_id = $1`, orderID).Scan(&status); err != nil {
+		t.Fatalf("failed to read order %s: %v", orderID, err)
+	}
+	return status
+}
+
+// TestCheckoutRoundTrip pays an order with SALDO, lets the fake Digiflazz answer Pending and
+// then completes it through a signed webhook to the app's callback route
+func TestCheckoutRoundTrip(t *testing.T) {
+	tests := []struct {
+		name           string
+		callbackStatus string
+		wantStatus     string
+		wantRefund     bool
+	}{
+		{name: "success", callbackStatus: fakeprovider.DigiflazzSukses, wantStatus: types.StatusSuccess},
+		{name: "failed", callbackStatus: fakeprovider.DigiflazzGagal, wantStatus: types.StatusRefunded, wantRefund: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newCheckoutEnv(t)
+			sku := testdb.UniqueID("TST")
+			username := testdb.UniqueID("buyer")
+			env.createProduct(t, sku)
+			testdb.CreateUser(t, env.db, username, testBalance)
+			env.servers.Digiflazz.Script(sku, fakeprovider.Outcome{Status: fakeprovider.DigiflazzPending})
+
+			checkout, err := env.transactions.Create(context.Background(), transaction.CreateTransaction{
+				ProductCode: sku,
+				MethodCode:  "SALDO",
+				WhatsApp:    "08123456789",
+				Username:    username,
+				GameId:      "12345678",
+			})
+			if err != nil {
+				t.Fatalf("checkout: %v", err)
+			}
+			if status := env.orderStatus(t, checkout.OrderID); status != types.StatusProcess {
+				t.Fatalf("status after checkout = %s, want %s", status, types.StatusProcess)
+			}
+			if balance := testdb.UserBalance(t, env.db, username); balance != testBalance-checkout.Total {
+				t.Fatalf("balance after checkout = %d, want %d", balance, testBalance-checkout.Total)
+			}
+
+			trx, ok := env.servers.Digiflazz.Transaction(checkout.OrderID)
+			if !ok {
+				t.Fatalf("fake digiflazz has no transaction %s", checkout.OrderID)
+			}
+			trx.Status, trx.SN = tt.callbackStatus, "SN-"+checkout.OrderID
+			if err := env.servers.Digiflazz.SendCallback(trx, "update"); err != nil {
+				t.Fatalf("callback: %v", err)
+			}
+
+			if status := env.orderStatus(t, checkout.OrderID); status != tt.wantStatus {
+				t.Errorf("status after callback = %s, want %s", status, tt.wantStatus)
+			}
+			wantBalance := testBalance - checkout.Total
+			if tt.wantRefund {
+				wantBalance = testBalance
+			}
+			if balance := testdb.UserBalance(t, env.db, username); balance != wantBalance {
+				t.Errorf("balance after callback = %d, want %d", balance, wantBalance)
+			}
+
+			// Callback yang sama dikirim ulang tidak boleh mengubah status atau saldo lagi
+			if err := env.servers.Digiflazz.SendCallback(trx, "update"); err != nil {
+				t.Fatalf("duplicate callback: %v", err)
+			}
+			if balance := testdb.UserBalance(t, env.db, username); balance != wantBalance {
+				t.Errorf("balance after duplicate callback = %d, want %d", balance, wantBalance)
+			}
+		})
+	}
+}
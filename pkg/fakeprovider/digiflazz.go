@@ -0,0 +1,482 @@
+package fakeprovider
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wafi04/backendvazzz/pkg/lib"
+)
+
+// Status transaksi yang dikirim Digiflazz
+const (
+	DigiflazzSukses  = "Sukses"
+	DigiflazzPending = "Pending"
+	DigiflazzGagal   = "Gagal"
+)
+
+// Outcome describes how the fake Digiflazz answers a transaction for a SKU.
+// A Pending outcome with CallbackStatus set is completed later through the webhook.
+type Outcome struct {
+	Status         string
+	Message        string
+	SN             string
+	Delay          time.Duration
+	Malformed      bool
+	CallbackStatus string
+	CallbackDelay  time.Duration
+}
+
+// DigiflazzTransaction is a transaction recorded by the fake, keyed by ref_id
+type DigiflazzTransaction struct {
+	RefID          string `json:"ref_id"`
+	CustomerNo     string `json:"customer_no"`
+	BuyerSKUCode   string `json:"buyer_sku_code"`
+	Message        string `json:"message"`
+	Status         string `json:"status"`
+	RC             string `json:"rc"`
+	SN             string `json:"sn"`
+	Price          int    `json:"price"`
+	BuyerLastSaldo int    `json:"buyer_last_saldo"`
+	Admin          int    `json:"admin,omitempty"`
+	CustomerName   string `json:"customer_name,omitempty"`
+	Command        string `json:"-"`
+	CallbackURL    string `json:"-"`
+}
+
+// Digiflazz is an in-memory fake of the Digiflazz buyer API
+type Digiflazz struct {
+	config lib.DigiConfig
+
+	mu           sync.Mutex
+	products     []lib.ProductData
+	balance      int
+	defaults     Outcome
+	scripts      map[string]Outcome
+	transactions map[string]*DigiflazzTransaction
+	client       *http.Client
+}
+
+func NewDigiflazz(config lib.DigiConfig) *Digiflazz {
+	return &Digiflazz{
+		config:       config,
+		products:     DefaultProducts(),
+		balance:      10000000,
+		defaults:     Outcome{Status: DigiflazzSukses},
+		scripts:      make(map[string]Outcome),
+		transactions: make(map[string]*DigiflazzTransaction),
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// DefaultProducts is the price list served until SetProducts is called
+func DefaultProducts() []lib.ProductData {
+	product := func(sku, name, brand, category string, price int) lib.ProductData {
+		return lib.ProductData{
+			BuyerSkuCode:        sku,
+			ProductName:         name,
+			Brand:               brand,
+			Category:            category,
+			Type:                "Umum",
+			SellerName:          "FAKE",
+			Price:               price,
+			BuyerProductStatus:  true,
+			SellerProductStatus: true,
+			UnlimitedStock:      true,
+			Multi:               true,
+			StartCutOff:         "0:0",
+			EndCutOff:           "0:0",
+			Desc:                "-",
+		}
+	}
+
+	return []lib.ProductData{
+		product("ML5", "MOBILE LEGENDS 5 Diamonds", "MOBILE LEGENDS", "Games", 1400),
+		product("ML86", "MOBILE LEGENDS 86 Diamonds", "MOBILE LEGENDS", "Games", 19500),
+		product("FF50", "FREE FIRE 50 Diamonds", "FREE FIRE", "Games", 6800),
+		product("TSEL10", "Telkomsel 10.000", "TELKOMSEL", "Pulsa", 10300),
+		product("PLN20", "Token PLN 20.000", "PLN", "PLN", 20150),
+	}
+}
+
+// SetProducts replaces the price list
+func (d *Digiflazz) SetProducts(products []lib.ProductData) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.products = products
+}
+
+// SetBalance sets the deposit returned by cek-saldo
+func (d *Digiflazz) SetBalance(balance int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.balance = balance
+}
+
+// SetDefault sets the outcome used for SKUs without a script
+func (d *Digiflazz) SetDefault(outcome Outcome) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.defaults = outcome
+}
+
+// Script sets the outcome for one SKU
+func (d *Digiflazz) Script(sku string, outcome Outcome) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.scripts[sku] = outcome
+}
+
+// Transaction returns a copy of the recorded transaction
+func (d *Digiflazz) Transaction(refID string) (DigiflazzTransaction, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	trx, ok := d.transactions[refID]
+	if !ok {
+		return DigiflazzTransaction{}, false
+	}
+	return *trx, true
+}
+
+// Transactions returns copies of every recorded transaction
+func (d *Digiflazz) Transactions() []DigiflazzTransaction {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	result := make([]DigiflazzTransaction, 0, len(d.transactions))
+	for _, trx := range d.transactions {
+		result = append(result, *trx)
+	}
+	return result
+}
+
+func (d *Digiflazz) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/price-list", d.handlePriceList)
+	mux.HandleFunc("POST /v1/cek-saldo", d.handleBalance)
+	mux.HandleFunc("POST /v1/transaction", d.handleTransaction)
+	mux.HandleFunc("POST /_fake/script", d.handleScript)
+	mux.HandleFunc("GET /_fake/transactions", d.handleList)
+	return mux
+}
+
+func (d *Digiflazz) outcomeFor(sku string) Outcome {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if outcome, ok := d.scripts[sku]; ok {
+		return outcome
+	}
+	return d.defaults
+}
+
+func (d *Digiflazz) validSign(username, sign, suffix string) bool {
+	if d.config.DigiKey == "" {
+		return true
+	}
+	hash := md5.Sum([]byte(d.config.DigiUsername + d.config.DigiKey + suffix))
+	return username == d.config.DigiUsername && sign == hex.EncodeToString(hash[:])
+}
+
+func (d *Digiflazz) handlePriceList(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username string `json:"username"`
+		Sign     string `json:"sign"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDigiflazzError(w, "Format request salah", "49")
+		return
+	}
+	if !d.validSign(req.Username, req.Sign, "pricelist") {
+		writeDigiflazzError(w, "Signature Anda salah", "41")
+		return
+	}
+
+	d.mu.Lock()
+	products := append([]lib.ProductData(nil), d.products...)
+	d.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": products})
+}
+
+func (d *Digiflazz) handleBalance(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username string `json:"username"`
+		Sign     string `json:"sign"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDigiflazzError(w, "Format request salah", "49")
+		return
+	}
+	if !d.validSign(req.Username, req.Sign, "depo") {
+		writeDigiflazzError(w, "Signature Anda salah", "41")
+		return
+	}
+
+	d.mu.Lock()
+	balance := d.balance
+	d.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{"deposit": balance},
+	})
+}
+
+func (d *Digiflazz) handleTransaction(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username     string `json:"username"`
+		Commands     string `json:"commands"`
+		BuyerSKUCode string `json:"buyer_sku_code"`
+		CustomerNo   string `json:"customer_no"`
+		RefID        string `json:"ref_id"`
+		Sign         string `json:"sign"`
+		CallbackURL  string `json:"cb_url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDigiflazzError(w, "Format request salah", "49")
+		return
+	}
+	if !d.validSign(req.Username, req.Sign, req.RefID) {
+		writeDigiflazzError(w, "Signature Anda salah", "41")
+		return
+	}
+
+	outcome := d.outcomeFor(req.BuyerSKUCode)
+	if outcome.Delay > 0 {
+		time.Sleep(outcome.Delay)
+	}
+	if outcome.Malformed {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data": {"ref_id": `))
+		return
+	}
+
+	switch req.Commands {
+	case "":
+		// ref_id yang sama dianggap cek status, bukan transaksi baru
+		if trx, ok := d.Transaction(req.RefID); ok {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"data": trx})
+			return
+		}
+		trx := d.createTransaction(req.Commands, req.BuyerSKUCode, req.CustomerNo, req.RefID, req.CallbackURL, outcome)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": trx})
+	case "pay-pasca":
+		trx, ok := d.payInquiry(req.RefID, req.CallbackURL, outcome)
+		if !ok {
+			writeDigiflazzError(w, "Inquiry tidak ditemukan", "44")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": trx})
+	case "inq-pasca":
+		trx := d.inquiry(req.BuyerSKUCode, req.CustomerNo, req.RefID, outcome)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": trx})
+	case "status-pasca":
+		trx, ok := d.Transaction(req.RefID)
+		if !ok {
+			writeDigiflazzError(w, "Transaksi tidak ditemukan", "44")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": trx})
+	default:
+		writeDigiflazzError(w, fmt.Sprintf("Commands %s tidak dikenal", req.Commands), "49")
+	}
+}
+
+func (d *Digiflazz) priceOf(sku string) int {
+	for _, p := range d.products {
+		if strings.EqualFold(p.BuyerSkuCode, sku) {
+			return p.Price
+		}
+	}
+	return 0
+}
+
+func (d *Digiflazz) inquiry(sku, customerNo, refID string, outcome Outcome) DigiflazzTransaction {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	trx := &DigiflazzTransaction{
+		RefID:        refID,
+		CustomerNo:   customerNo,
+		BuyerSKUCode: sku,
+		CustomerName: "PELANGGAN FAKE",
+		Price:        d.priceOf(sku) + 50000,
+		Admin:        2500,
+		Command:      "inq-pasca",
+		Status:       DigiflazzSukses,
+		RC:           "00",
+		Message:      "Transaksi Sukses",
+	}
+	if outcome.Status == DigiflazzGagal {
+		trx.Status, trx.RC, trx.Message = DigiflazzGagal, "60", "Tagihan belum tersedia"
+	}
+
+	d.transactions[refID] = trx
+	return *trx
+}
+
+func (d *Digiflazz) createTransaction(command, sku, customerNo, refID, callbackURL string, outcome Outcome) DigiflazzTransaction {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	status := outcome.Status
+	if status == "" {
+		status = DigiflazzSukses
+	}
+
+	trx := &DigiflazzTransaction{
+		RefID:        refID,
+		CustomerNo:   customerNo,
+		BuyerSKUCode: sku,
+		Price:        d.priceOf(sku),
+		Command:      command,
+		CallbackURL:  callbackURL,
+	}
+	if trx.CallbackURL == "" {
+		trx.CallbackURL = d.config.CallbackUrl
+	}
+	d.applyStatus(trx, status, outcome)
+	d.transactions[refID] = trx
+
+	if status == DigiflazzPending && outcome.CallbackStatus != "" {
+		go d.completeLater(refID, outcome)
+	}
+
+	return *trx
+}
+
+// payInquiry pays the bill of a previous inq-pasca; paying twice returns the payment
+func (d *Digiflazz) payInquiry(refID, callbackURL string, outcome Outcome) (DigiflazzTransaction, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	trx, ok := d.transactions[refID]
+	if !ok {
+		return DigiflazzTransaction{}, false
+	}
+	if trx.Command != "inq-pasca" {
+		return *trx, true
+	}
+
+	status := outcome.Status
+	if status == "" {
+		status = DigiflazzSukses
+	}
+
+	trx.Command = "pay-pasca"
+	trx.CallbackURL = callbackURL
+	if trx.CallbackURL == "" {
+		trx.CallbackURL = d.config.CallbackUrl
+	}
+	d.applyStatus(trx, status, outcome)
+
+	if status == DigiflazzPending && outcome.CallbackStatus != "" {
+		go d.completeLater(refID, outcome)
+	}
+
+	return *trx, true
+}
+
+// applyStatus harus dipanggil dengan d.mu terkunci
+func (d *Digiflazz) applyStatus(trx *DigiflazzTransaction, status string, outcome Outcome) {
+	trx.Status = status
+	trx.Message = outcome.Message
+
+	switch status {
+	case DigiflazzSukses:
+		trx.RC = "00"
+		trx.SN = outcome.SN
+		if trx.SN == "" {
+			trx.SN = fmt.Sprintf("FAKE-%s", trx.RefID)
+		}
+		if trx.Message == "" {
+			trx.Message = "Transaksi Sukses"
+		}
+		d.balance -= trx.Price
+	case DigiflazzPending:
+		trx.RC = "03"
+		if trx.Message == "" {
+			trx.Message = "Transaksi Pending"
+		}
+	default:
+		trx.RC = "02"
+		if trx.Message == "" {
+			trx.Message = "Transaksi Gagal"
+		}
+	}
+	trx.BuyerLastSaldo = d.balance
+}
+
+func (d *Digiflazz) completeLater(refID string, outcome Outcome) {
+	time.Sleep(outcome.CallbackDelay)
+
+	d.mu.Lock()
+	trx, ok := d.transactions[refID]
+	if !ok {
+		d.mu.Unlock()
+		return
+	}
+	d.applyStatus(trx, outcome.CallbackStatus, Outcome{SN: outcome.SN})
+	snapshot := *trx
+	d.mu.Unlock()
+
+	if err := d.SendCallback(snapshot, "update"); err != nil {
+		log.Printf("fake digiflazz: callback for %s failed: %v", refID, err)
+	}
+}
+
+// SendCallback posts a signed webhook for the transaction to its cb_url
+func (d *Digiflazz) SendCallback(trx DigiflazzTransaction, event string) error {
+	if trx.CallbackURL == "" {
+		return fmt.Errorf("no callback url for %s", trx.RefID)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"data": trx})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, trx.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Digiflazz-Hookshot")
+	req.Header.Set("X-Digiflazz-Event", event)
+	if d.config.WebhookSecret != "" {
+		mac := hmac.New(sha1.New, []byte(d.config.WebhookSecret))
+		mac.Write(body)
+		req.Header.Set("X-Hub-Signature", "sha1="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func writeDigiflazzError(w http.ResponseWriter, message, rc string) {
+	writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+		"data": map[string]string{"message": message, "rc": rc},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,297 @@
+package fakeprovider
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wafi04/backendvazzz/pkg/lib"
+)
+
+// Hasil pembayaran di fake Duitku
+const (
+	DuitkuPaid    = "PAID"
+	DuitkuPending = "PENDING"
+	DuitkuFailed  = "FAILED"
+)
+
+// PaymentOutcome describes what happens to an inquiry for a payment method.
+// PAID and FAILED settle after CallbackDelay; SkipCallback settles silently so
+// only transactionStatus polling can see the result.
+type PaymentOutcome struct {
+	Result        string
+	Delay         time.Duration
+	Malformed     bool
+	CallbackDelay time.Duration
+	SkipCallback  bool
+}
+
+// DuitkuPayment is an inquiry recorded by the fake, keyed by merchantOrderId
+type DuitkuPayment struct {
+	MerchantOrderId string `json:"merchantOrderId"`
+	Reference       string `json:"reference"`
+	PaymentMethod   string `json:"paymentMethod"`
+	Amount          int    `json:"amount"`
+	StatusCode      string `json:"statusCode"`
+	CallbackUrl     string `json:"callbackUrl"`
+}
+
+// Duitku is an in-memory fake of the Duitku merchant API
+type Duitku struct {
+	config lib.DuitkuConfig
+
+	mu       sync.Mutex
+	defaults PaymentOutcome
+	scripts  map[string]PaymentOutcome
+	payments map[string]*DuitkuPayment
+	counter  int
+	client   *http.Client
+}
+
+func NewDuitku(config lib.DuitkuConfig) *Duitku {
+	return &Duitku{
+		config:   config,
+		defaults: PaymentOutcome{Result: DuitkuPaid, CallbackDelay: time.Second},
+		scripts:  make(map[string]PaymentOutcome),
+		payments: make(map[string]*DuitkuPayment),
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SetDefault sets the outcome used for payment methods without a script
+func (d *Duitku) SetDefault(outcome PaymentOutcome) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.defaults = outcome
+}
+
+// Script sets the outcome for one payment method code
+func (d *Duitku) Script(paymentMethod string, outcome PaymentOutcome) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.scripts[paymentMethod] = outcome
+}
+
+// Payment returns a copy of the recorded payment
+func (d *Duitku) Payment(merchantOrderId string) (DuitkuPayment, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	payment, ok := d.payments[merchantOrderId]
+	if !ok {
+		return DuitkuPayment{}, false
+	}
+	return *payment, true
+}
+
+// Payments returns copies of every recorded payment
+func (d *Duitku) Payments() []DuitkuPayment {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	result := make([]DuitkuPayment, 0, len(d.payments))
+	for _, payment := range d.payments {
+		result = append(result, *payment)
+	}
+	return result
+}
+
+func (d *Duitku) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /webapi/api/merchant/v2/inquiry", d.handleInquiry)
+	mux.HandleFunc("POST /webapi/api/merchant/transactionStatus", d.handleStatus)
+	mux.HandleFunc("POST /_fake/script", d.handleScript)
+	mux.HandleFunc("GET /_fake/payments", d.handleList)
+	return mux
+}
+
+func (d *Duitku) outcomeFor(paymentMethod string) PaymentOutcome {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if outcome, ok := d.scripts[paymentMethod]; ok {
+		return outcome
+	}
+	return d.defaults
+}
+
+func (d *Duitku) sign(parts ...string) string {
+	hash := md5.Sum([]byte(strings.Join(parts, "") + d.config.Key))
+	return hex.EncodeToString(hash[:])
+}
+
+func (d *Duitku) handleInquiry(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		MerchantCode    string `json:"merchantCode"`
+		PaymentAmount   int    `json:"paymentAmount"`
+		MerchantOrderId string `json:"merchantOrderId"`
+		PaymentMethod   string `json:"paymentMethod"`
+		Signature       string `json:"signature"`
+		CallbackUrl     string `json:"callbackUrl"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDuitkuError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.MerchantCode != d.config.MerchantCode {
+		writeDuitkuError(w, http.StatusNotFound, "Merchant not found")
+		return
+	}
+	if req.Signature != d.sign(req.MerchantCode, req.MerchantOrderId, strconv.Itoa(req.PaymentAmount)) {
+		writeDuitkuError(w, http.StatusUnauthorized, "Wrong signature")
+		return
+	}
+
+	outcome := d.outcomeFor(req.PaymentMethod)
+	if outcome.Delay > 0 {
+		time.Sleep(outcome.Delay)
+	}
+	if outcome.Malformed {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"merchantCode": `))
+		return
+	}
+
+	d.mu.Lock()
+	d.counter++
+	number := d.counter
+	payment := &DuitkuPayment{
+		MerchantOrderId: req.MerchantOrderId,
+		Reference:       fmt.Sprintf("FAKEDK%06d", number),
+		PaymentMethod:   req.PaymentMethod,
+		Amount:          req.PaymentAmount,
+		StatusCode:      "01",
+		CallbackUrl:     req.CallbackUrl,
+	}
+	if payment.CallbackUrl == "" {
+		payment.CallbackUrl = d.config.CallbackUrl
+	}
+	d.payments[req.MerchantOrderId] = payment
+	snapshot := *payment
+	d.mu.Unlock()
+
+	if outcome.Result == DuitkuPaid || outcome.Result == DuitkuFailed {
+		go d.settleLater(req.MerchantOrderId, outcome)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"merchantCode":  d.config.MerchantCode,
+		"reference":     snapshot.Reference,
+		"paymentUrl":    fmt.Sprintf("http://%s/pay/%s", r.Host, snapshot.Reference),
+		"vaNumber":      fmt.Sprintf("8800%08d", number),
+		"qrString":      "FAKEQR-" + snapshot.Reference,
+		"amount":        strconv.Itoa(snapshot.Amount),
+		"statusCode":    "00",
+		"statusMessage": "SUCCESS",
+	})
+}
+
+func (d *Duitku) handleStatus(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		MerchantCode    string `json:"merchantCode"`
+		MerchantOrderId string `json:"merchantOrderId"`
+		Signature       string `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDuitkuError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.MerchantCode != d.config.MerchantCode || req.Signature != d.sign(req.MerchantCode, req.MerchantOrderId) {
+		writeDuitkuError(w, http.StatusUnauthorized, "Wrong signature")
+		return
+	}
+
+	payment, ok := d.Payment(req.MerchantOrderId)
+	if !ok {
+		writeDuitkuError(w, http.StatusBadRequest, "Transaction not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"merchantOrderId": payment.MerchantOrderId,
+		"reference":       payment.Reference,
+		"amount":          strconv.Itoa(payment.Amount),
+		"fee":             "0",
+		"statusCode":      payment.StatusCode,
+		"statusMessage":   statusMessage(payment.StatusCode),
+	})
+}
+
+func (d *Duitku) settleLater(merchantOrderId string, outcome PaymentOutcome) {
+	time.Sleep(outcome.CallbackDelay)
+
+	d.mu.Lock()
+	payment, ok := d.payments[merchantOrderId]
+	if !ok {
+		d.mu.Unlock()
+		return
+	}
+	payment.StatusCode = "00"
+	if outcome.Result == DuitkuFailed {
+		payment.StatusCode = "02"
+	}
+	snapshot := *payment
+	d.mu.Unlock()
+
+	if outcome.SkipCallback {
+		return
+	}
+	if err := d.SendCallback(snapshot); err != nil {
+		log.Printf("fake duitku: callback for %s failed: %v", merchantOrderId, err)
+	}
+}
+
+// SendCallback posts the signed form callback Duitku sends after a payment settles
+func (d *Duitku) SendCallback(payment DuitkuPayment) error {
+	if payment.CallbackUrl == "" {
+		return fmt.Errorf("no callback url for %s", payment.MerchantOrderId)
+	}
+
+	resultCode := "00"
+	if payment.StatusCode != "00" {
+		resultCode = "01"
+	}
+	amount := strconv.Itoa(payment.Amount)
+
+	form := url.Values{}
+	form.Set("merchantCode", d.config.MerchantCode)
+	form.Set("amount", amount)
+	form.Set("merchantOrderId", payment.MerchantOrderId)
+	form.Set("paymentCode", payment.PaymentMethod)
+	form.Set("resultCode", resultCode)
+	form.Set("reference", payment.Reference)
+	form.Set("refId", payment.Reference)
+	form.Set("signature", d.sign(d.config.MerchantCode, amount, payment.MerchantOrderId))
+
+	resp, err := d.client.PostForm(payment.CallbackUrl, form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func statusMessage(code string) string {
+	switch code {
+	case "00":
+		return "SUCCESS"
+	case "01":
+		return "PROCESS"
+	default:
+		return "CANCELED"
+	}
+}
+
+func writeDuitkuError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"Message": message})
+}
@@ -0,0 +1,315 @@
+package fakeprovider
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wafi04/backendvazzz/pkg/lib"
+	"github.com/wafi04/backendvazzz/pkg/supplier"
+)
+
+// H2HTransaction is a transaction recorded by the fake H2H supplier
+type H2HTransaction struct {
+	RefID   string `json:"refID"`
+	TrxID   string `json:"trxID"`
+	Product string `json:"product"`
+	Dest    string `json:"dest"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	SN      string `json:"sn"`
+	Price   int    `json:"price"`
+}
+
+// H2H is an in-memory fake of a host-to-host supplier speaking supplier.H2H.
+// Outcomes reuse the Digiflazz wording (Sukses, Pending, Gagal).
+type H2H struct {
+	signer      *supplier.H2H
+	callbackURL string
+
+	mu           sync.Mutex
+	products     []lib.ProductData
+	balance      int
+	defaults     Outcome
+	scripts      map[string]Outcome
+	transactions map[string]*H2HTransaction
+	counter      int
+	client       *http.Client
+}
+
+// NewH2H creates the fake; callbacks are posted to callbackURL
+func NewH2H(config supplier.H2HConfig, callbackURL string) *H2H {
+	return &H2H{
+		signer:       supplier.NewH2H(config),
+		callbackURL:  callbackURL,
+		products:     DefaultProducts(),
+		balance:      10000000,
+		defaults:     Outcome{Status: DigiflazzSukses},
+		scripts:      make(map[string]Outcome),
+		transactions: make(map[string]*H2HTransaction),
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (h *H2H) SetProducts(products []lib.ProductData) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.products = products
+}
+
+func (h *H2H) SetBalance(balance int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.balance = balance
+}
+
+func (h *H2H) SetDefault(outcome Outcome) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.defaults = outcome
+}
+
+func (h *H2H) Script(sku string, outcome Outcome) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.scripts[sku] = outcome
+}
+
+// ApplyScript sets the default or per-SKU outcome from its JSON form
+func (h *H2H) ApplyScript(script OutcomeScript) error {
+	outcome, err := script.Outcome()
+	if err != nil {
+		return err
+	}
+	if script.SKU == "" {
+		h.SetDefault(outcome)
+	} else {
+		h.Script(script.SKU, outcome)
+	}
+	return nil
+}
+
+// Transactions returns copies of every recorded transaction
+func (h *H2H) Transactions() []H2HTransaction {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	result := make([]H2HTransaction, 0, len(h.transactions))
+	for _, trx := range h.transactions {
+		result = append(result, *trx)
+	}
+	return result
+}
+
+func (h *H2H) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /pricelist", h.handlePriceList)
+	mux.HandleFunc("GET /balance", h.handleBalance)
+	mux.HandleFunc("GET /trx", h.handleTrx)
+	mux.HandleFunc("GET /status", h.handleStatus)
+	mux.HandleFunc("POST /_fake/script", h.handleScript)
+	mux.HandleFunc("GET /_fake/transactions", h.handleList)
+	return mux
+}
+
+func (h *H2H) checkSign(w http.ResponseWriter, r *http.Request, parts ...string) bool {
+	if r.URL.Query().Get("sign") != h.signer.Sign(parts...) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"status": "GAGAL", "message": "Sign tidak valid"})
+		return false
+	}
+	return true
+}
+
+func (h *H2H) handlePriceList(w http.ResponseWriter, r *http.Request) {
+	if !h.checkSign(w, r) {
+		return
+	}
+
+	h.mu.Lock()
+	products := make([]map[string]interface{}, 0, len(h.products))
+	for _, p := range h.products {
+		products = append(products, map[string]interface{}{
+			"code":     p.BuyerSkuCode,
+			"name":     p.ProductName,
+			"brand":    p.Brand,
+			"category": p.Category,
+			"type":     p.Type,
+			"price":    p.Price,
+			"status":   p.BuyerProductStatus && p.SellerProductStatus,
+		})
+	}
+	h.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": products})
+}
+
+func (h *H2H) handleBalance(w http.ResponseWriter, r *http.Request) {
+	if !h.checkSign(w, r) {
+		return
+	}
+
+	h.mu.Lock()
+	balance := h.balance
+	h.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]int{"balance": balance})
+}
+
+func (h *H2H) handleTrx(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	product, dest, refID := q.Get("product"), q.Get("dest"), q.Get("refID")
+	if !h.checkSign(w, r, product, dest, refID) {
+		return
+	}
+
+	h.mu.Lock()
+	outcome, ok := h.scripts[product]
+	if !ok {
+		outcome = h.defaults
+	}
+	h.mu.Unlock()
+
+	if outcome.Delay > 0 {
+		time.Sleep(outcome.Delay)
+	}
+	if outcome.Malformed {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"refID": `))
+		return
+	}
+
+	h.mu.Lock()
+	trx, exists := h.transactions[refID]
+	if !exists {
+		h.counter++
+		trx = &H2HTransaction{
+			RefID:   refID,
+			TrxID:   fmt.Sprintf("H2H%06d", h.counter),
+			Product: product,
+			Dest:    dest,
+		}
+		for _, p := range h.products {
+			if strings.EqualFold(p.BuyerSkuCode, product) {
+				trx.Price = p.Price
+			}
+		}
+		h.applyStatus(trx, outcome.Status, outcome)
+		h.transactions[refID] = trx
+	}
+	snapshot := *trx
+	h.mu.Unlock()
+
+	if !exists && snapshot.Status == "PENDING" && outcome.CallbackStatus != "" {
+		go h.completeLater(refID, outcome)
+	}
+
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+func (h *H2H) handleStatus(w http.ResponseWriter, r *http.Request) {
+	refID := r.URL.Query().Get("refID")
+	if !h.checkSign(w, r, refID) {
+		return
+	}
+
+	h.mu.Lock()
+	trx, ok := h.transactions[refID]
+	var snapshot H2HTransaction
+	if ok {
+		snapshot = *trx
+	}
+	h.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusOK, H2HTransaction{RefID: refID, Status: "GAGAL", Message: "Transaksi tidak ditemukan"})
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+// applyStatus harus dipanggil dengan h.mu terkunci
+func (h *H2H) applyStatus(trx *H2HTransaction, status string, outcome Outcome) {
+	trx.Message = outcome.Message
+
+	switch status {
+	case "", DigiflazzSukses:
+		trx.Status = "SUKSES"
+		trx.SN = outcome.SN
+		if trx.SN == "" {
+			trx.SN = fmt.Sprintf("FAKEH2H-%s", trx.RefID)
+		}
+		if trx.Message == "" {
+			trx.Message = "SUKSES"
+		}
+		h.balance -= trx.Price
+	case DigiflazzPending:
+		trx.Status = "PENDING"
+		if trx.Message == "" {
+			trx.Message = "Transaksi sedang diproses"
+		}
+	default:
+		trx.Status = "GAGAL"
+		if trx.Message == "" {
+			trx.Message = "Transaksi gagal"
+		}
+	}
+}
+
+func (h *H2H) completeLater(refID string, outcome Outcome) {
+	time.Sleep(outcome.CallbackDelay)
+
+	h.mu.Lock()
+	trx, ok := h.transactions[refID]
+	if !ok {
+		h.mu.Unlock()
+		return
+	}
+	h.applyStatus(trx, outcome.CallbackStatus, Outcome{SN: outcome.SN})
+	snapshot := *trx
+	h.mu.Unlock()
+
+	if err := h.SendCallback(snapshot); err != nil {
+		log.Printf("fake h2h: callback for %s failed: %v", refID, err)
+	}
+}
+
+// SendCallback posts the signed form callback for the transaction
+func (h *H2H) SendCallback(trx H2HTransaction) error {
+	if h.callbackURL == "" {
+		return fmt.Errorf("no callback url for %s", trx.RefID)
+	}
+
+	form := url.Values{}
+	form.Set("refID", trx.RefID)
+	form.Set("trxID", trx.TrxID)
+	form.Set("product", trx.Product)
+	form.Set("dest", trx.Dest)
+	form.Set("status", trx.Status)
+	form.Set("message", trx.Message)
+	form.Set("sn", trx.SN)
+	form.Set("price", strconv.Itoa(trx.Price))
+	body := form.Encode()
+
+	req, err := http.NewRequest(http.MethodPost, h.callbackURL, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-H2H-Signature", h.signer.SignCallback([]byte(body)))
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package fakeprovider_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wafi04/backendvazzz/pkg/fakeprovider"
+	"github.com/wafi04/backendvazzz/pkg/lib"
+	"github.com/wafi04/backendvazzz/pkg/supplier"
+	"github.com/wafi04/backendvazzz/pkg/types"
+	"github.com/wafi04/backendvazzz/service/order"
+)
+
+const (
+	testDigiUsername = "fakeuser"
+	testDigiKey      = "fakekey"
+	testDigiSecret   = "fakesecret"
+)
+
+// startDigiflazz runs the fake Digiflazz and a receiver that verifies every webhook with the
+// real supplier client, the same way the callback endpoint does
+func startDigiflazz(t *testing.T) (*fakeprovider.Servers, *supplier.Digiflazz, <-chan *supplier.Callback) {
+	t.Helper()
+
+	callbacks := make(chan *supplier.Callback, 10)
+	var client *supplier.Digiflazz
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		callback, err := client.ParseCallback(r.Header, body)
+		if err != nil {
+			t.Errorf("callback rejected: %v", err)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		callbacks <- callback
+	}))
+	t.Cleanup(receiver.Close)
+
+	servers := fakeprovider.Start(lib.DigiConfig{
+		DigiUsername:  testDigiUsername,
+		DigiKey:       testDigiKey,
+		WebhookSecret: testDigiSecret,
+		CallbackUrl:   receiver.URL,
+	}, lib.DuitkuConfig{})
+	t.Cleanup(servers.Close)
+
+	client = supplier.NewDigiflazz(lib.NewDigiflazzService(servers.DigiConfig()))
+	return servers, client, callbacks
+}
+
+func waitCallback(t *testing.T, callbacks <-chan *supplier.Callback) *supplier.Callback {
+	t.Helper()
+	select {
+	case callback := <-callbacks:
+		return callback
+	case <-time.After(5 * time.Second):
+		t.Fatal("no callback received")
+		return nil
+	}
+}
+
+func TestDigiflazzPendingOrderCompletesThroughSignedCallback(t *testing.T) {
+	tests := []struct {
+		name           string
+		callbackStatus string
+		wantStatus     string
+		wantSN         bool
+	}{
+		{name: "success", callbackStatus: fakeprovider.DigiflazzSukses, wantStatus: types.StatusSuccess, wantSN: true},
+		{name: "failed", callbackStatus: fakeprovider.DigiflazzGagal, wantStatus: types.StatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			servers, client, callbacks := startDigiflazz(t)
+			servers.Digiflazz.Script("ML86", fakeprovider.Outcome{
+				Status:         fakeprovider.DigiflazzPending,
+				CallbackStatus: tt.callbackStatus,
+			})
+
+			result, err := client.Order(context.Background(), supplier.OrderRequest{
+				RefID:      "VAZZ-TEST-" + tt.name,
+				SKU:        "ML86",
+				CustomerNo: "12345678",
+			})
+			if err != nil {
+				t.Fatalf("Order: %v", err)
+			}
+			if result.Status != supplier.StatusPending {
+				t.Fatalf("order status = %q, want %q", result.Status, supplier.StatusPending)
+			}
+			if next, _ := order.FromSupplierStatus(result.Status); next != types.StatusProcess {
+				t.Fatalf("pending answer maps to %q, want %q", next, types.StatusProcess)
+			}
+
+			callback := waitCallback(t, callbacks)
+			if callback.Result.RefID != "VAZZ-TEST-"+tt.name {
+				t.Errorf("callback ref_id = %q", callback.Result.RefID)
+			}
+			next, ok := order.FromSupplierStatus(callback.Result.Status)
+			if !ok || next != tt.wantStatus {
+				t.Errorf("callback status %q maps to %q, want %q", callback.Result.Status, next, tt.wantStatus)
+			}
+			if tt.wantSN && callback.Result.SN == "" {
+				t.Error("successful callback has no serial number")
+			}
+			if callback.Result.LastBalance == nil {
+				t.Error("callback did not carry buyer_last_saldo")
+			}
+		})
+	}
+}
+
+func TestDigiflazzCallbackWithWrongSecretIsRejected(t *testing.T) {
+	servers, _, _ := startDigiflazz(t)
+
+	trx := fakeprovider.DigiflazzTransaction{RefID: "VAZZ-FORGED", Status: fakeprovider.DigiflazzSukses, SN: "X"}
+	forged := fakeprovider.NewDigiflazz(lib.DigiConfig{WebhookSecret: "wrong"})
+
+	rejected := make(chan error, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		client := supplier.NewDigiflazz(lib.NewDigiflazzService(servers.DigiConfig()))
+		_, err := client.ParseCallback(r.Header, body)
+		rejected <- err
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer receiver.Close()
+
+	trx.CallbackURL = receiver.URL
+	if err := forged.SendCallback(trx, "update"); err == nil {
+		t.Fatal("forged callback was accepted by the receiver")
+	}
+	if err := <-rejected; !errors.Is(err, supplier.ErrInvalidCallback) {
+		t.Fatalf("ParseCallback error = %v, want ErrInvalidCallback", err)
+	}
+}
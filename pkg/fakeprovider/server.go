@@ -0,0 +1,57 @@
+package fakeprovider
+
+import (
+	"net/http/httptest"
+
+	"github.com/wafi04/backendvazzz/pkg/lib"
+	"github.com/wafi04/backendvazzz/pkg/supplier"
+)
+
+// Servers runs both fakes on local httptest servers
+type Servers struct {
+	Digiflazz       *Digiflazz
+	Duitku          *Duitku
+	DigiflazzServer *httptest.Server
+	DuitkuServer    *httptest.Server
+}
+
+// Start runs the fakes with the given credentials. Callbacks go to the
+// CallbackUrl in each config unless the request carries its own.
+func Start(digiConfig lib.DigiConfig, duitkuConfig lib.DuitkuConfig) *Servers {
+	digiflazz := NewDigiflazz(digiConfig)
+	duitku := NewDuitku(duitkuConfig)
+
+	return &Servers{
+		Digiflazz:       digiflazz,
+		Duitku:          duitku,
+		DigiflazzServer: httptest.NewServer(digiflazz.Handler()),
+		DuitkuServer:    httptest.NewServer(duitku.Handler()),
+	}
+}
+
+// DigiConfig returns the config with BaseUrl pointed at the fake server
+func (s *Servers) DigiConfig() lib.DigiConfig {
+	config := s.Digiflazz.config
+	config.BaseUrl = s.DigiflazzServer.URL
+	return config
+}
+
+// DuitkuConfig returns the config with BaseUrl pointed at the fake server
+func (s *Servers) DuitkuConfig() lib.DuitkuConfig {
+	config := s.Duitku.config
+	config.BaseUrl = s.DuitkuServer.URL
+	return config
+}
+
+func (s *Servers) Close() {
+	s.DigiflazzServer.Close()
+	s.DuitkuServer.Close()
+}
+
+// StartH2H runs the fake H2H supplier and returns the config pointed at it
+func StartH2H(config supplier.H2HConfig, callbackURL string) (*H2H, *httptest.Server, supplier.H2HConfig) {
+	h2h := NewH2H(config, callbackURL)
+	server := httptest.NewServer(h2h.Handler())
+	config.BaseUrl = server.URL
+	return h2h, server, config
+}
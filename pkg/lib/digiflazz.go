@@ -3,12 +3,16 @@ package lib
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
 	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -45,7 +49,7 @@ type TransactionCreateDigiflazzResponse struct {
 		Status         string `json:"status"`
 		RC             string `json:"rc"`
 		SN             string `json:"sn"`
-		BuyerLastSaldo int    `json:"buyer_last_saldo"`
+		BuyerLastSaldo *int   `json:"buyer_last_saldo"`
 		Price          int    `json:"price"`
 		Tele           string `json:"tele"`
 		WA             string `json:"wa"`
@@ -66,6 +70,12 @@ type DigiflazzResponse struct {
 type DigiConfig struct {
 	DigiKey      string
 	DigiUsername string
+	// BaseUrl mengganti host default Digiflazz, dipakai untuk fake provider
+	BaseUrl       string
+	CallbackUrl   string
+	WebhookSecret string
+	// Testing mengirim flag testing ke Digiflazz (mode development)
+	Testing bool
 }
 
 type DigiflazzService struct {
@@ -73,11 +83,30 @@ type DigiflazzService struct {
 }
 
 func NewDigiflazzService(config DigiConfig) *DigiflazzService {
+	if config.BaseUrl == "" {
+		config.BaseUrl = "https://api.digiflazz.com"
+	}
+	config.BaseUrl = strings.TrimRight(config.BaseUrl, "/")
+
 	return &DigiflazzService{
 		config: config,
 	}
 }
 
+// VerifyWebhookSignature checks the X-Hub-Signature header sent by Digiflazz,
+// formatted as "sha1=" followed by the hex HMAC-SHA1 of the raw body.
+func (d *DigiflazzService) VerifyWebhookSignature(body []byte, signature string) bool {
+	if d.config.WebhookSecret == "" || !strings.HasPrefix(signature, "sha1=") {
+		return false
+	}
+
+	mac := hmac.New(sha1.New, []byte(d.config.WebhookSecret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(strings.TrimPrefix(signature, "sha1=")))
+}
+
 func (d *DigiflazzService) generateSign(username, apiKey, cmd string) string {
 	data := username + apiKey + cmd
 	hash := md5.Sum([]byte(data))
@@ -100,7 +129,7 @@ func (d *DigiflazzService) CheckPrice() ([]*ProductData, error) {
 	}
 
 	// Make HTTP request
-	resp, err := http.Post("https://api.digiflazz.com/v1/price-list", "application/json", bytes.NewBuffer(jsonData))
+	resp, err := http.Post(d.config.BaseUrl+"/v1/price-list", "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %v", err)
 	}
@@ -149,7 +178,15 @@ func (d *DigiflazzService) TopUp(ctx context.Context, req CreateTransactionToDig
 		"customer_no":    req.CustomerNo,
 		"ref_id":         req.RefID,
 		"sign":           sign,
-		"cb_url":         "https://e3abda9a4f73.ngrok-free.app/api/transactions/callback/digiflazz",
+	}
+
+	if req.CallbackURL != "" {
+		requestPayload["cb_url"] = req.CallbackURL
+	} else if d.config.CallbackUrl != "" {
+		requestPayload["cb_url"] = d.config.CallbackUrl
+	}
+	if d.config.Testing {
+		requestPayload["testing"] = true
 	}
 
 	jsonData, err := json.Marshal(requestPayload)
@@ -157,7 +194,7 @@ func (d *DigiflazzService) TopUp(ctx context.Context, req CreateTransactionToDig
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://api.digiflazz.com/v1/transaction", bytes.NewBuffer(jsonData))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", d.config.BaseUrl+"/v1/transaction", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -197,3 +234,109 @@ func (d *DigiflazzService) TopUp(ctx context.Context, req CreateTransactionToDig
 	}
 
 }
+
+// CheckStatus re-sends a transaction with the same ref_id, which Digiflazz
+// treats as a status check instead of a new purchase.
+func (d *DigiflazzService) CheckStatus(ctx context.Context, req CreateTransactionToDigiflazz) (*TransactionCreateDigiflazzResponse, error) {
+	data := d.config.DigiUsername + d.config.DigiKey + req.RefID
+	hash := md5.Sum([]byte(data))
+	sign := fmt.Sprintf("%x", hash)
+
+	requestPayload := map[string]interface{}{
+		"username":       d.config.DigiUsername,
+		"buyer_sku_code": req.BuyerSKUCode,
+		"customer_no":    req.CustomerNo,
+		"ref_id":         req.RefID,
+		"sign":           sign,
+	}
+	if d.config.Testing {
+		requestPayload["testing"] = true
+	}
+
+	jsonData, err := json.Marshal(requestPayload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", d.config.BaseUrl+"/v1/transaction", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var apiResponse TransactionCreateDigiflazzResponse
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w, body: %s", err, string(body))
+	}
+
+	return &apiResponse, nil
+}
+
+type DigiflazzBalanceResponse struct {
+	Data struct {
+		Deposit int    `json:"deposit"`
+		Message string `json:"message"`
+		RC      string `json:"rc"`
+	} `json:"data"`
+}
+
+// CheckBalance returns the remaining deposit on the Digiflazz account
+func (d *DigiflazzService) CheckBalance(ctx context.Context) (int, error) {
+	requestPayload := map[string]interface{}{
+		"cmd":      "deposit",
+		"username": d.config.DigiUsername,
+		"sign":     d.generateSign(d.config.DigiUsername, d.config.DigiKey, "depo"),
+	}
+
+	jsonData, err := json.Marshal(requestPayload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", d.config.BaseUrl+"/v1/cek-saldo", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return 0, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("API returned status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var apiResponse DigiflazzBalanceResponse
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal response: %w, body: %s", err, string(body))
+	}
+
+	return apiResponse.Data.Deposit, nil
+}
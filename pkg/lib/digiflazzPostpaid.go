@@ -0,0 +1,110 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Commands pascabayar Digiflazz. pay-pasca dan status-pasca memakai ref_id dari inq-pasca.
+const (
+	DigiflazzInquiryPostpaid = "inq-pasca"
+	DigiflazzPayPostpaid     = "pay-pasca"
+	DigiflazzStatusPostpaid  = "status-pasca"
+)
+
+type PostpaidDigiflazzResponse struct {
+	Data struct {
+		RefID          string          `json:"ref_id"`
+		CustomerNo     string          `json:"customer_no"`
+		CustomerName   string          `json:"customer_name"`
+		BuyerSKUCode   string          `json:"buyer_sku_code"`
+		Admin          int             `json:"admin"`
+		Message        string          `json:"message"`
+		Status         string          `json:"status"`
+		RC             string          `json:"rc"`
+		SN             string          `json:"sn"`
+		BuyerLastSaldo *int            `json:"buyer_last_saldo"`
+		Price          int             `json:"price"`
+		SellingPrice   int             `json:"selling_price"`
+		Desc           json.RawMessage `json:"desc,omitempty"`
+	} `json:"data"`
+}
+
+// InquiryPostpaid asks Digiflazz for the outstanding bill of a customer
+func (d *DigiflazzService) InquiryPostpaid(ctx context.Context, req CreateTransactionToDigiflazz) (*PostpaidDigiflazzResponse, error) {
+	return d.postpaid(ctx, DigiflazzInquiryPostpaid, req)
+}
+
+// PayPostpaid pays a bill previously returned by InquiryPostpaid with the same ref_id
+func (d *DigiflazzService) PayPostpaid(ctx context.Context, req CreateTransactionToDigiflazz) (*PostpaidDigiflazzResponse, error) {
+	return d.postpaid(ctx, DigiflazzPayPostpaid, req)
+}
+
+func (d *DigiflazzService) CheckPostpaidStatus(ctx context.Context, req CreateTransactionToDigiflazz) (*PostpaidDigiflazzResponse, error) {
+	return d.postpaid(ctx, DigiflazzStatusPostpaid, req)
+}
+
+func (d *DigiflazzService) postpaid(ctx context.Context, command string, req CreateTransactionToDigiflazz) (*PostpaidDigiflazzResponse, error) {
+	data := d.config.DigiUsername + d.config.DigiKey + req.RefID
+	hash := md5.Sum([]byte(data))
+	sign := fmt.Sprintf("%x", hash)
+
+	requestPayload := map[string]interface{}{
+		"commands":       command,
+		"username":       d.config.DigiUsername,
+		"buyer_sku_code": req.BuyerSKUCode,
+		"customer_no":    req.CustomerNo,
+		"ref_id":         req.RefID,
+		"sign":           sign,
+	}
+
+	if command == DigiflazzPayPostpaid {
+		if req.CallbackURL != "" {
+			requestPayload["cb_url"] = req.CallbackURL
+		} else if d.config.CallbackUrl != "" {
+			requestPayload["cb_url"] = d.config.CallbackUrl
+		}
+	}
+	if d.config.Testing {
+		requestPayload["testing"] = true
+	}
+
+	jsonData, err := json.Marshal(requestPayload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", d.config.BaseUrl+"/v1/transaction", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var apiResponse PostpaidDigiflazzResponse
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w, body: %s", err, string(body))
+	}
+
+	return &apiResponse, nil
+}
@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"crypto/md5"
+	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -21,21 +23,19 @@ type DuitkuCreateTransactionParams struct {
 	Cust            *string `json:"cust,omitempty"`
 	CallbackUrl     *string `json:"callbackUrl,omitempty"`
 	ReturnUrl       *string `json:"returnUrl,omitempty"`
+	ExpiryPeriod    *int    `json:"expiryPeriod,omitempty"`
 }
 
 type Duitku struct {
 }
 
 type ResponseFromDuitkuCheckTransaction struct {
-	Status int `json:"status"`
-	Data   struct {
-		MerchantOrderId string `json:"merchantOrderId"`
-		Reference       string `json:"reference"`
-		Amount          string `json:"amount"`
-		Fee             string `json:"fee"`
-		StatusCode      string `json:"statusCode"`
-		StatusMessage   string `json:"statusMessage"`
-	} `json:"data"`
+	MerchantOrderId string `json:"merchantOrderId"`
+	Reference       string `json:"reference"`
+	Amount          string `json:"amount"`
+	Fee             string `json:"fee"`
+	StatusCode      string `json:"statusCode"`
+	StatusMessage   string `json:"statusMessage"`
 }
 
 type DuitkuCreateTransactionResponse struct {
@@ -51,14 +51,26 @@ type DuitkuCreateTransactionResponse struct {
 	StatusMessage string `json:"statusMessage,omitempty"`
 }
 
+type DuitkuConfig struct {
+	Key          string
+	MerchantCode string
+	Sandbox      bool
+	// BaseUrl mengganti host default Duitku, dipakai untuk fake provider
+	BaseUrl      string
+	CallbackUrl  string
+	ReturnUrl    string
+	ExpiryPeriod *int64
+}
+
 type DuitkuService struct {
 	DuitkuKey             string
 	DuitkuMerchantCode    string
 	DuitkuExpiryPeriod    *int64
 	BaseUrl               string
-	SandboxUrl            string
 	BaseUrlGetTransaction string
 	BaseUrlGetBalance     string
+	CallbackUrl           string
+	ReturnUrl             string
 	HttpClient            *http.Client
 }
 
@@ -75,14 +87,25 @@ type PaymentResponse struct {
 	StatusMessage   string `json:"statusMessage"`
 }
 
-func NewDuitkuService() *DuitkuService {
+func NewDuitkuService(config DuitkuConfig) *DuitkuService {
+	host := config.BaseUrl
+	if host == "" {
+		host = "https://passport.duitku.com"
+		if config.Sandbox {
+			host = "https://sandbox.duitku.com"
+		}
+	}
+	host = strings.TrimRight(host, "/")
+
 	return &DuitkuService{
-		DuitkuKey:             "f08c81f8cd52e0f93dd80088dbf7f1c8",
-		DuitkuMerchantCode:    "DS22269",
-		BaseUrl:               "https://passport.duitku.com/webapi/api/merchant/v2/inquiry",
-		SandboxUrl:            "https://sandbox.duitku.com/webapi/api/merchant/v2/inquiry",
-		BaseUrlGetTransaction: "https://passport.duitku.com/webapi/api/merchant/transactionStatus",
-		BaseUrlGetBalance:     "https://passport.duitku.com/webapi/api/disbursement/checkbalance",
+		DuitkuKey:             config.Key,
+		DuitkuMerchantCode:    config.MerchantCode,
+		DuitkuExpiryPeriod:    config.ExpiryPeriod,
+		BaseUrl:               host + "/webapi/api/merchant/v2/inquiry",
+		BaseUrlGetTransaction: host + "/webapi/api/merchant/transactionStatus",
+		BaseUrlGetBalance:     host + "/webapi/api/disbursement/checkbalance",
+		CallbackUrl:           config.CallbackUrl,
+		ReturnUrl:             config.ReturnUrl,
 		HttpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -100,7 +123,23 @@ func (s *DuitkuService) CreateTransaction(ctx context.Context, params *DuitkuCre
 		"productDetails":  params.ProductDetails,
 		"paymentMethod":   params.PaymentCode,
 		"signature":       signature,
-		"callbackUrl":     "https://dea77f427946.ngrok-free.app/api/transactions/callback/duitku",
+		"callbackUrl":     s.CallbackUrl,
+	}
+
+	if params.CallbackUrl != nil && *params.CallbackUrl != "" {
+		payload["callbackUrl"] = *params.CallbackUrl
+	}
+	if params.ReturnUrl != nil && *params.ReturnUrl != "" {
+		payload["returnUrl"] = *params.ReturnUrl
+	} else if s.ReturnUrl != "" {
+		payload["returnUrl"] = s.ReturnUrl
+	}
+
+	// expiryPeriod dalam menit, default dari konfigurasi service
+	if params.ExpiryPeriod != nil {
+		payload["expiryPeriod"] = *params.ExpiryPeriod
+	} else if s.DuitkuExpiryPeriod != nil {
+		payload["expiryPeriod"] = *s.DuitkuExpiryPeriod
 	}
 
 	jsonData, err := json.Marshal(payload)
@@ -108,7 +147,7 @@ func (s *DuitkuService) CreateTransaction(ctx context.Context, params *DuitkuCre
 		return nil, nil
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", s.SandboxUrl, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", s.BaseUrl, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, nil
 	}
@@ -133,6 +172,59 @@ func (s *DuitkuService) CreateTransaction(ctx context.Context, params *DuitkuCre
 	return &duitkuResponse, nil
 }
 
+// CheckTransaction asks Duitku for the current status of an order.
+// StatusCode "00" means paid, "01" pending and "02" cancelled.
+func (s *DuitkuService) CheckTransaction(ctx context.Context, merchantOrderId string) (*ResponseFromDuitkuCheckTransaction, error) {
+	hash := md5.Sum([]byte(s.DuitkuMerchantCode + merchantOrderId + s.DuitkuKey))
+
+	payload := map[string]interface{}{
+		"merchantCode":    s.DuitkuMerchantCode,
+		"merchantOrderId": merchantOrderId,
+		"signature":       hex.EncodeToString(hash[:]),
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.BaseUrlGetTransaction, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.HttpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var result ResponseFromDuitkuCheckTransaction
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w, body: %s", err, string(body))
+	}
+
+	return &result, nil
+}
+
+// VerifyCallbackSignature checks the signature Duitku sends on its callback:
+// md5(merchantCode + amount + merchantOrderId + apiKey)
+func (s *DuitkuService) VerifyCallbackSignature(merchantCode, amount, merchantOrderId, signature string) bool {
+	hash := md5.Sum([]byte(merchantCode + amount + merchantOrderId + s.DuitkuKey))
+	expected := hex.EncodeToString(hash[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(signature))) == 1
+}
+
 func (s *DuitkuService) generateSignature(merchantOrderId string, paymentAmount int) string {
 
 	signatureString := s.DuitkuMerchantCode + merchantOrderId + strconv.Itoa(paymentAmount) + s.DuitkuKey
@@ -95,3 +95,32 @@ func AdminMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// OptionalAuthMiddleware sets the user of a valid token like AuthMiddleware, but lets
+// requests without a token (or with an invalid one) through as guests
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, err := c.Cookie("vazzaccess")
+		if err != nil || tokenString == "" {
+			if authHeader := c.GetHeader("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+				tokenString = strings.TrimPrefix(authHeader, "Bearer ")
+			}
+		}
+		if tokenString == "" {
+			c.Next()
+			return
+		}
+
+		claims, err := config.ValidateToken(tokenString)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		c.Set("user_id", claims["user_id"])
+		c.Set("username", claims["username"])
+		c.Set("role", claims["role"])
+
+		c.Next()
+	}
+}
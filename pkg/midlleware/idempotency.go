@@ -0,0 +1,217 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyHeader = "Idempotency-Key"
+	// IdempotencyWindow adalah lama sebuah key bisa dipakai ulang untuk replay
+	IdempotencyWindow = 24 * time.Hour
+)
+
+type idempotencyRecord struct {
+	RequestHash  string
+	StatusCode   sql.NullInt64
+	ResponseBody sql.NullString
+	CreatedAt    time.Time
+}
+
+// responseRecorder keeps a copy of the body written by the handler
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware replays the stored response when a request is retried with the
+// same Idempotency-Key header. A different body under the same key is rejected with 409.
+// Requests without the header are passed through untouched.
+func IdempotencyMiddleware(db *sql.DB, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Failed to read request body",
+			})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+
+		username := idempotencyOwner(c)
+		requestHash := hashRequest(c.Request.Method, c.FullPath(), body)
+
+		claimed, err := claimIdempotencyKey(c, db, scope, username, key, requestHash)
+		if err != nil {
+			log.Printf("Idempotency key %s error: %v", key, err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Failed to process idempotency key",
+			})
+			c.Abort()
+			return
+		}
+
+		if !claimed {
+			record, err := getIdempotencyRecord(c, db, scope, username, key)
+			if err != nil {
+				log.Printf("Idempotency key %s lookup error: %v", key, err)
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"success": false,
+					"message": "Failed to process idempotency key",
+				})
+				c.Abort()
+				return
+			}
+
+			if record.RequestHash != requestHash {
+				c.JSON(http.StatusConflict, gin.H{
+					"success": false,
+					"message": "Idempotency key already used with a different request",
+				})
+				c.Abort()
+				return
+			}
+
+			if !record.StatusCode.Valid {
+				c.JSON(http.StatusConflict, gin.H{
+					"success": false,
+					"message": "A request with this idempotency key is still being processed",
+				})
+				c.Abort()
+				return
+			}
+
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(int(record.StatusCode.Int64), "application/json; charset=utf-8", []byte(record.ResponseBody.String))
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = recorder
+
+		// Handler yang panic tidak boleh meninggalkan key dalam status "still being processed"
+		defer func() {
+			if r := recover(); r != nil {
+				releaseIdempotencyKey(db, scope, username, key)
+				panic(r)
+			}
+		}()
+
+		c.Next()
+
+		// Error server tidak disimpan supaya client bisa mencoba lagi dengan key yang sama
+		if recorder.Status() >= http.StatusInternalServerError {
+			releaseIdempotencyKey(db, scope, username, key)
+			return
+		}
+
+		_, err = db.Exec(`
+			UPDATE idempotency_keys
+			SET status_code = $1, response_body = $2
+			WHERE scope = $3 AND username = $4 AND idempotency_key = $5
+		`, recorder.Status(), recorder.body.String(), scope, username, key)
+		if err != nil {
+			log.Printf("Failed to store idempotent response for key %s: %v", key, err)
+		}
+	}
+}
+
+// idempotencyOwner returns the value keys are scoped by. Guests have no username, so their
+// keys are scoped by client IP instead of all sharing the empty username.
+func idempotencyOwner(c *gin.Context) string {
+	if username := c.GetString("username"); username != "" {
+		return username
+	}
+	return "guest:" + c.ClientIP()
+}
+
+func releaseIdempotencyKey(db *sql.DB, scope, username, key string) {
+	if _, err := db.Exec(`DELETE FROM idempotency_keys WHERE scope = $1 AND username = $2 AND idempotency_key = $3`,
+		scope, username, key); err != nil {
+		log.Printf("Failed to release idempotency key %s: %v", key, err)
+	}
+}
+
+// claimIdempotencyKey inserts the key, returning false when it already exists within the window
+func claimIdempotencyKey(c *gin.Context, db *sql.DB, scope, username, key, requestHash string) (bool, error) {
+	ctx := c.Request.Context()
+
+	// Key yang sudah lewat window boleh dipakai lagi
+	_, err := db.ExecContext(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE scope = $1 AND username = $2 AND idempotency_key = $3 AND created_at < $4
+	`, scope, username, key, time.Now().Add(-IdempotencyWindow))
+	if err != nil {
+		return false, fmt.Errorf("failed to clear expired key: %w", err)
+	}
+
+	result, err := db.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (scope, username, idempotency_key, request_hash, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (scope, username, idempotency_key) DO NOTHING
+	`, scope, username, key, requestHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+func getIdempotencyRecord(c *gin.Context, db *sql.DB, scope, username, key string) (*idempotencyRecord, error) {
+	var record idempotencyRecord
+	err := db.QueryRowContext(c.Request.Context(), `
+		SELECT request_hash, status_code, response_body, created_at
+		FROM idempotency_keys
+		WHERE scope = $1 AND username = $2 AND idempotency_key = $3
+	`, scope, username, key).Scan(
+		&record.RequestHash,
+		&record.StatusCode,
+		&record.ResponseBody,
+		&record.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func hashRequest(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte(path))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+type TransactionStatusHistory struct {
+	ID         int       `json:"id" db:"id"`
+	OrderID    string    `json:"orderId" db:"order_id"`
+	FromStatus *string   `json:"fromStatus,omitempty" db:"from_status"`
+	ToStatus   string    `json:"toStatus" db:"to_status"`
+	Actor      string    `json:"actor" db:"actor"`
+	Source     string    `json:"source" db:"source"`
+	Payload    *string   `json:"payload,omitempty" db:"payload"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+}
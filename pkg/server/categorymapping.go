@@ -0,0 +1,26 @@
+package server
+
+import (
+	"database/sql"
+
+	"github.com/gin-gonic/gin"
+	middleware "github.com/wafi04/backendvazzz/pkg/midlleware"
+	"github.com/wafi04/backendvazzz/service/categorymapping"
+)
+
+func SetupCategoryMappingRoutes(r *gin.RouterGroup, db *sql.DB) {
+	mappingRepo := categorymapping.NewCategoryMappingRepository(db)
+	mappingService := categorymapping.NewCategoryMappingService(mappingRepo)
+	mappingHandler := categorymapping.NewCategoryMappingHandler(mappingService)
+
+	admin := r.Group("/admin/category-mappings")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		admin.GET("", mappingHandler.List)
+		admin.POST("", mappingHandler.Create)
+		admin.PUT("/:id", mappingHandler.Update)
+		admin.DELETE("/:id", mappingHandler.Delete)
+		admin.GET("/unmapped", mappingHandler.Unmapped)
+		admin.POST("/unmapped/:providerId/resolve", mappingHandler.ResolveUnmapped)
+	}
+}
@@ -4,20 +4,22 @@ import (
 	"database/sql"
 
 	"github.com/gin-gonic/gin"
+	"github.com/wafi04/backendvazzz/pkg/config"
+	"github.com/wafi04/backendvazzz/pkg/lib"
 	middleware "github.com/wafi04/backendvazzz/pkg/midlleware"
 	"github.com/wafi04/backendvazzz/service/deposit"
 )
 
-func SetupDepositTransaction(r *gin.RouterGroup, DB *sql.DB) {
+func SetupDepositTransaction(r *gin.RouterGroup, DB *sql.DB, cfg *config.AppConfig) {
 
 	depositRepo := deposit.NewDepositRepository(DB)
-	depositService := deposit.NewDepositService(depositRepo)
+	depositService := deposit.NewDepositService(depositRepo, lib.NewDuitkuService(cfg.Duitku), cfg.Payment)
 	depositHandler := deposit.NewDepositHandler(depositService)
 
 	routes := r.Group("/deposit")
 	routes.Use(middleware.AuthMiddleware())
 	{
-		routes.POST("", depositHandler.Create)
+		routes.POST("", middleware.IdempotencyMiddleware(DB, "deposit"), depositHandler.Create)
 		routes.GET("/by/username", depositHandler.GetAllByUsername)
 		routes.GET("/:id", depositHandler.GetByDepositID)
 		routes.DELETE("/:id", depositHandler.Delete)
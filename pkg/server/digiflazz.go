@@ -1,151 +1,59 @@
 package server
 
 import (
-	"context"
 	"database/sql"
-	"fmt"
-	"log"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/wafi04/backendvazzz/pkg/config"
+	"github.com/wafi04/backendvazzz/pkg/lib"
+	middleware "github.com/wafi04/backendvazzz/pkg/midlleware"
+	"github.com/wafi04/backendvazzz/pkg/utils"
+	"github.com/wafi04/backendvazzz/service/productsync"
 )
 
-func getSyncManager(db *sql.DB) *config.SyncManager {
-	config.SyncManagerMutex.Lock()
-	defer config.SyncManagerMutex.Unlock()
+func getSyncManager(db *sql.DB, cfg *config.AppConfig) *productsync.SyncManager {
+	productsync.SyncManagerMutex.Lock()
+	defer productsync.SyncManagerMutex.Unlock()
 
-	if config.GlobalSyncManager == nil {
-		config.GlobalSyncManager = config.NewSyncManager(db)
+	if productsync.GlobalSyncManager == nil {
+		productsync.GlobalSyncManager = productsync.NewSyncManager(db, lib.NewDigiflazzService(cfg.Digiflazz), cfg.Sync)
 	}
-	return config.GlobalSyncManager
+	return productsync.GlobalSyncManager
 }
 
-func GetProductFromDigiflazz(r *gin.RouterGroup, db *sql.DB) {
-	syncManager := getSyncManager(db)
+func GetProductFromDigiflazz(r *gin.RouterGroup, db *sql.DB, cfg *config.AppConfig) {
+	syncManager := getSyncManager(db, cfg)
+
+	// Trigger manual dan terjadwal memakai job runner yang sama
+	syncService := productsync.NewProductSyncService(syncManager.Jobs)
+	syncHandler := productsync.NewProductSyncHandler(syncService)
 
 	digRoutes := r.Group("/sync")
 
-	digRoutes.GET("/", func(c *gin.Context) {
-		if syncManager.IsSyncing {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":   "Sync already in progress",
-				"message": "Please wait for current sync to complete",
-			})
-			return
-		}
+	// Route yang mengubah jadwal atau menjalankan sync hanya untuk admin
+	admin := digRoutes.Group("")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
 
-		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Minute)
-		defer cancel()
-
-		syncManager.SyncMutex.Lock()
-		syncManager.Mutex.Lock()
-		if syncManager.IsSyncing {
-			syncManager.Mutex.Unlock()
-			syncManager.SyncMutex.Unlock()
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":   "Sync already in progress",
-				"message": "Please wait for current sync to complete",
-			})
-			return
-		}
-		syncManager.IsSyncing = true
-		syncManager.Mutex.Unlock()
+	// Sync berjalan di background, response berisi job id untuk dipantau.
+	// GET /sync/ hanya membaca daftar job, sync dimulai lewat POST /sync/jobs
+	digRoutes.GET("/", syncHandler.List)
+	admin.POST("/jobs", syncHandler.Start)
+	digRoutes.GET("/jobs", syncHandler.List)
+	digRoutes.GET("/jobs/:id", syncHandler.Get)
+	digRoutes.GET("/jobs/:id/report", syncHandler.Report)
 
-		defer func() {
-			syncManager.Mutex.Lock()
-			syncManager.IsSyncing = false
-			syncManager.Mutex.Unlock()
-			syncManager.SyncMutex.Unlock()
-		}()
+	// Jadwal otomatis berlaku untuk semua instance, disimpan di sync_schedule
+	digRoutes.GET("/schedule", syncHandler.GetSchedule)
+	admin.PUT("/schedule", syncHandler.UpdateSchedule)
 
-		products, err := syncManager.Digi.CheckPrice()
+	admin.POST("/start", func(c *gin.Context) {
+		current, err := syncManager.Jobs.GetSchedule(c.Request.Context())
 		if err != nil {
-			log.Printf("Failed to fetch price list: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to fetch price list",
-				"details": err.Error(),
-			})
-			return
-		}
-
-		if len(products) == 0 {
-			c.JSON(http.StatusOK, gin.H{
-				"status":  "success",
-				"message": "No products found",
-				"count":   0,
-			})
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to start automatic sync", err.Error())
 			return
 		}
-
-		batchSize := 50
-		successCount := 0
-		errorCount := 0
-		var errors []string
-
-		for i := 0; i < len(products); i += batchSize {
-			select {
-			case <-ctx.Done():
-				c.JSON(http.StatusRequestTimeout, gin.H{
-					"error":   "Sync timeout",
-					"message": "Sync cancelled due to timeout",
-				})
-				return
-			default:
-			}
-
-			end := i + batchSize
-			if end > len(products) {
-				end = len(products)
-			}
-
-			batch := products[i:end]
-			for _, product := range batch {
-				if product == nil {
-					errorCount++
-					continue
-				}
-
-				exists, err := syncManager.ProductExists(ctx, product.BuyerSkuCode)
-				if err != nil {
-					errors = append(errors, fmt.Sprintf("Error checking %s: %v", product.ProductName, err))
-					errorCount++
-					continue
-				}
-
-				if exists {
-					err = syncManager.ProductRepo.UpdatePrice(ctx, product)
-				} else {
-					err = syncManager.ProductRepo.Create(ctx, *product)
-				}
-
-				if err != nil {
-					errors = append(errors, fmt.Sprintf("Failed to process %s: %v", product.ProductName, err))
-					errorCount++
-				} else {
-					successCount++
-				}
-			}
-		}
-
-		response := gin.H{
-			"status":        "success",
-			"message":       "Product sync completed",
-			"total_fetched": len(products),
-			"success_count": successCount,
-			"error_count":   errorCount,
-		}
-
-		if len(errors) > 0 {
-			response["errors"] = errors
-		}
-
-		c.JSON(http.StatusOK, response)
-	})
-
-	digRoutes.POST("/start", func(c *gin.Context) {
-		if syncManager.IsRunning {
+		if current.Enabled {
 			c.JSON(http.StatusOK, gin.H{
 				"status":  "already_running",
 				"message": "Automatic sync is already running",
@@ -153,15 +61,25 @@ func GetProductFromDigiflazz(r *gin.RouterGroup, db *sql.DB) {
 			return
 		}
 
-		syncManager.Start()
+		schedule, err := syncManager.Start(c.Request.Context())
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to start automatic sync", err.Error())
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{
-			"status":  "success",
-			"message": "Automatic sync started - will run every 10 minutes",
+			"status":   "success",
+			"message":  "Automatic sync started on all instances - schedule " + schedule.Cron,
+			"schedule": schedule,
 		})
 	})
 
-	digRoutes.POST("/stop", func(c *gin.Context) {
-		if !syncManager.IsRunning {
+	admin.POST("/stop", func(c *gin.Context) {
+		current, err := syncManager.Jobs.GetSchedule(c.Request.Context())
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to stop automatic sync", err.Error())
+			return
+		}
+		if !current.Enabled {
 			c.JSON(http.StatusOK, gin.H{
 				"status":  "not_running",
 				"message": "Automatic sync is not running",
@@ -169,19 +87,35 @@ func GetProductFromDigiflazz(r *gin.RouterGroup, db *sql.DB) {
 			return
 		}
 
-		syncManager.Stop()
+		if _, err := syncManager.Stop(c.Request.Context()); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to stop automatic sync", err.Error())
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{
 			"status":  "success",
-			"message": "Automatic sync stopped",
+			"message": "Automatic sync stopped on all instances",
 		})
 	})
 
 	digRoutes.GET("/status", func(c *gin.Context) {
+		status, err := syncManager.Status(c.Request.Context())
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch sync status", err.Error())
+			return
+		}
+
+		runningJob := 0
+		if status.RunningJob != nil {
+			runningJob = status.RunningJob.ID
+		}
 		c.JSON(http.StatusOK, gin.H{
-			"status":     "success",
-			"is_running": syncManager.IsRunning,
-			"is_syncing": syncManager.IsSyncing,
-			"interval":   "10 minutes",
+			"status":      "success",
+			"is_running":  status.Schedule.Enabled,
+			"is_syncing":  status.IsSyncing,
+			"running_job": runningJob,
+			"job":         status.RunningJob,
+			"schedule":    status.Schedule.Cron,
+			"next_run_at": status.Schedule.NextRunAt,
 		})
 	})
 }
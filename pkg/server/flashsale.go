@@ -0,0 +1,28 @@
+package server
+
+import (
+	"database/sql"
+
+	"github.com/gin-gonic/gin"
+	middleware "github.com/wafi04/backendvazzz/pkg/midlleware"
+	"github.com/wafi04/backendvazzz/service/flashsale"
+)
+
+func SetupFlashSaleRoutes(r *gin.RouterGroup, db *sql.DB) {
+	flashSaleRepo := flashsale.NewFlashSaleRepository(db)
+	flashSaleService := flashsale.NewFlashSaleService(flashSaleRepo)
+	flashSaleHandler := flashsale.NewFlashSaleHandler(flashSaleService)
+
+	// Sale yang sedang berjalan dan yang akan datang
+	r.GET("/flash-sales", flashSaleHandler.Current)
+
+	admin := r.Group("/admin/flash-sales")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		admin.GET("", flashSaleHandler.List)
+		admin.POST("", flashSaleHandler.Create)
+		admin.GET("/:id", flashSaleHandler.Get)
+		admin.PUT("/:id", flashSaleHandler.Update)
+		admin.POST("/:id/cancel", flashSaleHandler.Cancel)
+	}
+}
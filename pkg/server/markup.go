@@ -0,0 +1,32 @@
+package server
+
+import (
+	"database/sql"
+
+	"github.com/gin-gonic/gin"
+	middleware "github.com/wafi04/backendvazzz/pkg/midlleware"
+	"github.com/wafi04/backendvazzz/service/markup"
+	"github.com/wafi04/backendvazzz/service/product"
+)
+
+func SetupMarkupRoutes(r *gin.RouterGroup, db *sql.DB) {
+	markupRepo := markup.NewMarkupRepository(db)
+	markupService := markup.NewMarkupService(markupRepo)
+	markupHandler := markup.NewMarkupHandler(markupService)
+
+	productService := product.NewProductService(product.NewProductRepository(db))
+	productHandler := product.NewProductHandler(productService)
+
+	admin := r.Group("/admin/markup-rules")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		admin.GET("", markupHandler.List)
+		admin.POST("", markupHandler.Create)
+		admin.PUT("/:id", markupHandler.Update)
+		admin.DELETE("/:id", markupHandler.Delete)
+
+		// Preview dulu, lalu apply untuk menulis harga baru
+		admin.GET("/recalculate", productHandler.PreviewRecalculate)
+		admin.POST("/recalculate", productHandler.ApplyRecalculate)
+	}
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wafi04/backendvazzz/pkg/config"
+	"github.com/wafi04/backendvazzz/pkg/lib"
+	middleware "github.com/wafi04/backendvazzz/pkg/midlleware"
+	"github.com/wafi04/backendvazzz/pkg/utils"
+	"github.com/wafi04/backendvazzz/service/fulfillment"
+	"github.com/wafi04/backendvazzz/service/postpaid"
+	"github.com/wafi04/backendvazzz/service/providerbalance"
+	"github.com/wafi04/backendvazzz/service/transaction"
+)
+
+type PostpaidPayRequest struct {
+	QuoteID    string `json:"quoteId" validate:"required"`
+	MethodCode string `json:"methodCode" validate:"required"`
+	WhatsApp   string `json:"whatsapp" validate:"required"`
+}
+
+func SetupPostpaidRoutes(api *gin.RouterGroup, db *sql.DB, cfg *config.AppConfig) {
+	suppliers := newSupplierRegistry(cfg)
+	balanceRepo := providerbalance.NewProviderBalanceRepository(db, suppliers, cfg.Balance)
+	postpaidRepo := postpaid.NewPostpaidRepository(db, suppliers, balanceRepo, cfg.Postpaid)
+	postpaidService := postpaid.NewPostpaidService(postpaidRepo)
+	postpaidHandler := postpaid.NewPostpaidHandler(postpaidService)
+
+	transactionRepo := transaction.NewTransactionRepository(
+		db,
+		lib.NewDuitkuService(cfg.Duitku),
+		fulfillment.NewFulfillmentRepository(db, suppliers, balanceRepo, cfg.Fulfillment),
+		balanceRepo,
+		postpaidRepo,
+		cfg.Payment,
+	)
+
+	// Guest tetap bisa inquiry dan bayar lewat Duitku; pembayaran SALDO butuh user login
+	r := api.Group("/postpaid")
+	{
+		r.POST("/inquiry", middleware.OptionalAuthMiddleware(), postpaidHandler.Inquiry)
+		r.GET("/quotes/:quoteId", middleware.AuthMiddleware(), postpaidHandler.GetQuote)
+
+		r.POST("/pay", middleware.OptionalAuthMiddleware(), middleware.IdempotencyMiddleware(db, "postpaid"), func(ctx *gin.Context) {
+			var input PostpaidPayRequest
+			if err := ctx.ShouldBindJSON(&input); err != nil {
+				utils.ErrorResponse(ctx, http.StatusBadRequest, "Invalid input", err.Error())
+				return
+			}
+
+			if input.QuoteID == "" {
+				utils.ErrorResponse(ctx, http.StatusBadRequest, "Quote ID is required", "")
+				return
+			}
+			if input.MethodCode == "" {
+				utils.ErrorResponse(ctx, http.StatusBadRequest, "Method code is required", "")
+				return
+			}
+			if input.WhatsApp == "" {
+				utils.ErrorResponse(ctx, http.StatusBadRequest, "WhatsApp number is required", "")
+				return
+			}
+
+			response, err := transactionRepo.CreatePostpaid(ctx, transaction.CreatePostpaidTransaction{
+				QuoteID:    input.QuoteID,
+				MethodCode: input.MethodCode,
+				WhatsApp:   input.WhatsApp,
+				Username:   ctx.GetString("username"),
+			})
+			if err != nil {
+				switch {
+				case errors.Is(err, postpaid.ErrQuoteNotFound):
+					utils.ErrorResponse(ctx, http.StatusNotFound, "Tagihan tidak ditemukan", err.Error())
+				case errors.Is(err, postpaid.ErrQuoteExpired):
+					utils.ErrorResponse(ctx, http.StatusGone, "Tagihan sudah kedaluwarsa, silahkan cek ulang tagihan", err.Error())
+				case errors.Is(err, postpaid.ErrQuoteUsed):
+					utils.ErrorResponse(ctx, http.StatusConflict, "Tagihan sudah dibayar", err.Error())
+				case errors.Is(err, transaction.ErrUsernameRequired):
+					utils.ErrorResponse(ctx, http.StatusUnauthorized, "Silahkan login untuk membayar dengan saldo", err.Error())
+				case errors.Is(err, transaction.ErrInsufficientBalance):
+					utils.ErrorResponse(ctx, http.StatusBadRequest, "Failed to pay bill", err.Error())
+				default:
+					utils.ErrorResponse(ctx, http.StatusInternalServerError, "Failed to pay bill", err.Error())
+				}
+				return
+			}
+
+			utils.SuccessResponse(ctx, http.StatusCreated, "Bill payment created successfully", response)
+		})
+	}
+}
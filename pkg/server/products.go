@@ -4,6 +4,7 @@ import (
 	"database/sql"
 
 	"github.com/gin-gonic/gin"
+	middleware "github.com/wafi04/backendvazzz/pkg/midlleware"
 	"github.com/wafi04/backendvazzz/service/product"
 )
 
@@ -16,4 +17,32 @@ func SetupRoutesProducts(r *gin.RouterGroup, DB *sql.DB) {
 	{
 		protected.GET("", productHandler.GetProducts)
 	}
+
+	// Riwayat harga memuat harga beli, hanya untuk admin
+	admin := r.Group("/products")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		admin.GET("/:providerId/price-history", productHandler.PriceHistory)
+	}
+
+	// Kelola produk secara manual; field yang diubah dikunci dari sync
+	adminProducts := r.Group("/admin/products")
+	adminProducts.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		adminProducts.GET("", productHandler.AdminList)
+		adminProducts.POST("", productHandler.AdminCreate)
+		adminProducts.POST("/bulk", productHandler.Bulk)
+		adminProducts.GET("/:providerId", productHandler.AdminGet)
+		adminProducts.PATCH("/:providerId", productHandler.AdminUpdate)
+		adminProducts.DELETE("/:providerId", productHandler.AdminDelete)
+	}
+
+	// Perubahan harga supplier yang ditahan sync karena melebihi batas wajar
+	priceChanges := r.Group("/admin/price-changes")
+	priceChanges.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		priceChanges.GET("", productHandler.HeldPriceChanges)
+		priceChanges.POST("/:id/approve", productHandler.ApprovePriceChange)
+		priceChanges.POST("/:id/reject", productHandler.RejectPriceChange)
+	}
 }
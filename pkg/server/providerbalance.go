@@ -0,0 +1,24 @@
+package server
+
+import (
+	"database/sql"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wafi04/backendvazzz/pkg/config"
+	middleware "github.com/wafi04/backendvazzz/pkg/midlleware"
+	"github.com/wafi04/backendvazzz/service/providerbalance"
+)
+
+func SetupProviderBalanceRoutes(r *gin.RouterGroup, db *sql.DB, cfg *config.AppConfig) {
+	balanceRepo := providerbalance.NewProviderBalanceRepository(db, newSupplierRegistry(cfg), cfg.Balance)
+	balanceService := providerbalance.NewProviderBalanceService(balanceRepo)
+	balanceHandler := providerbalance.NewProviderBalanceHandler(balanceService)
+
+	admin := r.Group("/admin/provider-balances")
+	admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		admin.GET("", balanceHandler.List)
+		admin.POST("/refresh", balanceHandler.Refresh)
+		admin.GET("/alerts", balanceHandler.Alerts)
+	}
+}
@@ -0,0 +1,18 @@
+package server
+
+import (
+	"github.com/wafi04/backendvazzz/pkg/config"
+	"github.com/wafi04/backendvazzz/pkg/lib"
+	"github.com/wafi04/backendvazzz/pkg/supplier"
+)
+
+// newSupplierRegistry registers every configured fulfillment backend
+func newSupplierRegistry(cfg *config.AppConfig) *supplier.Registry {
+	suppliers := []supplier.Supplier{
+		supplier.NewDigiflazz(lib.NewDigiflazzService(cfg.Digiflazz)),
+	}
+	if cfg.H2HEnabled() {
+		suppliers = append(suppliers, supplier.NewH2H(cfg.H2H))
+	}
+	return supplier.NewRegistry(suppliers...)
+}
@@ -2,11 +2,19 @@ package server
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/wafi04/backendvazzz/pkg/config"
+	"github.com/wafi04/backendvazzz/pkg/lib"
 	middleware "github.com/wafi04/backendvazzz/pkg/midlleware"
 	"github.com/wafi04/backendvazzz/pkg/utils"
+	"github.com/wafi04/backendvazzz/service/fulfillment"
+	"github.com/wafi04/backendvazzz/service/order"
+	"github.com/wafi04/backendvazzz/service/postpaid"
+	"github.com/wafi04/backendvazzz/service/product"
+	"github.com/wafi04/backendvazzz/service/providerbalance"
 	"github.com/wafi04/backendvazzz/service/transaction"
 	"github.com/wafi04/backendvazzz/service/transactions"
 )
@@ -24,16 +32,34 @@ func StringPtr(s string) *string {
 	return &s
 }
 
-func SetUpTransactionRoutes(api *gin.RouterGroup, db *sql.DB) {
-	transactionRepo := transaction.NewTransactionRepository(db)
-	transactionsRepo := transactions.NewTransactionsRepository(db)
+func SetUpTransactionRoutes(api *gin.RouterGroup, db *sql.DB, cfg *config.AppConfig) {
+	duitkuService := lib.NewDuitkuService(cfg.Duitku)
+	suppliers := newSupplierRegistry(cfg)
+
+	balanceRepo := providerbalance.NewProviderBalanceRepository(db, suppliers, cfg.Balance)
+	fulfillmentRepo := fulfillment.NewFulfillmentRepository(db, suppliers, balanceRepo, cfg.Fulfillment)
+
+	postpaidRepo := postpaid.NewPostpaidRepository(db, suppliers, balanceRepo, cfg.Postpaid)
+
+	transactionRepo := transaction.NewTransactionRepository(db, duitkuService, fulfillmentRepo, balanceRepo, postpaidRepo, cfg.Payment)
+	transactionsRepo := transactions.NewTransactionsRepository(db, duitkuService, suppliers, fulfillmentRepo, balanceRepo, postpaidRepo, cfg.Payment, cfg.StatusCheck)
 	transactionsHandler := transactions.NewTransactionHandler(transactionsRepo)
+	orderRepo := order.NewOrderRepository(db)
+	orderService := order.NewOrderService(orderRepo)
+	orderHandler := order.NewOrderHandler(orderService)
+
+	// Callback dari provider diverifikasi lewat signature, bukan token user
+	callbacks := api.Group("/transactions/callback")
+	{
+		callbacks.POST("/duitku", transactionsHandler.CallbackDuitku)
+		callbacks.POST("/:supplier", transactionsHandler.CallbackSupplier)
+	}
 
 	r := api.Group("/transactions")
 	protected := r.Use(middleware.AuthMiddleware())
 	{
 
-		r.POST("", func(ctx *gin.Context) {
+		r.POST("", middleware.IdempotencyMiddleware(db, "transactions"), func(ctx *gin.Context) {
 			var input RequestFromClient
 			if err := ctx.ShouldBindJSON(&input); err != nil {
 				utils.ErrorResponse(ctx, http.StatusBadRequest, "Invalid input", err.Error())
@@ -58,26 +84,24 @@ func SetUpTransactionRoutes(api *gin.RouterGroup, db *sql.DB) {
 				return
 			}
 
-			// usernamePtr := GetFromContext(ctx, "username")
-
-			// if u, ok := ctx.Get("username"); ok {
-			// 	if usernameStr, isString := u.(string); isString {
-			// 		usernamePtr = StringPtr(usernameStr)
-			// 	}
-			// }
-
-			// fmt.Printf("%s", *usernamePtr)
-
 			response, err := transactionRepo.Create(ctx, transaction.CreateTransaction{
 				ProductCode: input.ProductCode,
 				MethodCode:  input.MethodCode,
 				WhatsApp:    input.WhatsApp,
-				Username:    "adminaja",
+				Username:    ctx.GetString("username"),
 				VoucherCode: input.VoucherCode,
 				GameId:      input.GameId,
 				Zone:        input.Zone,
 			})
 			if err != nil {
+				if errors.Is(err, product.ErrProductUnavailable) {
+					utils.ErrorResponse(ctx, http.StatusUnprocessableEntity, "Produk sedang tidak bisa dipesan", err.Error())
+					return
+				}
+				if errors.Is(err, providerbalance.ErrInsufficientProviderBalance) {
+					utils.ErrorResponse(ctx, http.StatusServiceUnavailable, "Produk sedang tidak tersedia, silahkan coba lagi nanti", err.Error())
+					return
+				}
 				utils.ErrorResponse(ctx, http.StatusInternalServerError, "Failed to create transaction", err.Error())
 				return
 			}
@@ -87,8 +111,7 @@ func SetUpTransactionRoutes(api *gin.RouterGroup, db *sql.DB) {
 
 		r.GET("", transactionsHandler.GetAll)
 		r.GET("/invoice/:id", transactionsHandler.Invoice)
-		r.POST("/callback/digiflazz", transactionsHandler.CallbackDigiflazz)
-		r.POST("/callback/duitku", transactionsHandler.CallbackDuitku)
+		r.GET("/:orderId/timeline", orderHandler.Timeline)
 		protected.GET("/history", transactionsHandler.GetRepostTransaction)
 	}
 
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/wafi04/backendvazzz/pkg/config"
+	"github.com/wafi04/backendvazzz/pkg/lib"
+	"github.com/wafi04/backendvazzz/pkg/worker"
+	"github.com/wafi04/backendvazzz/service/expiry"
+	"github.com/wafi04/backendvazzz/service/flashsale"
+	"github.com/wafi04/backendvazzz/service/fulfillment"
+	"github.com/wafi04/backendvazzz/service/postpaid"
+	"github.com/wafi04/backendvazzz/service/providerbalance"
+	"github.com/wafi04/backendvazzz/service/transactions"
+)
+
+func SetupWorkers(db *sql.DB, cfg *config.AppConfig) *worker.Supervisor {
+	supervisor := worker.NewSupervisor()
+
+	expiryRepo := expiry.NewExpiryRepository(db, cfg.Payment)
+	supervisor.Add(worker.Job{
+		Name:     "payment-expiry",
+		Interval: time.Minute,
+		Run:      expiryRepo.Run,
+	})
+
+	suppliers := newSupplierRegistry(cfg)
+	balanceRepo := providerbalance.NewProviderBalanceRepository(db, suppliers, cfg.Balance)
+	postpaidRepo := postpaid.NewPostpaidRepository(db, suppliers, balanceRepo, cfg.Postpaid)
+	transactionsRepo := transactions.NewTransactionsRepository(
+		db,
+		lib.NewDuitkuService(cfg.Duitku),
+		suppliers,
+		fulfillment.NewFulfillmentRepository(db, suppliers, balanceRepo, cfg.Fulfillment),
+		balanceRepo,
+		postpaidRepo,
+		cfg.Payment,
+		cfg.StatusCheck,
+	)
+	supervisor.Add(worker.Job{
+		Name:     "duitku-reconcile",
+		Interval: 5 * time.Minute,
+		Run:      transactionsRepo.ReconcilePendingPayments,
+	})
+
+	supervisor.Add(worker.Job{
+		Name:     "digiflazz-status-check",
+		Interval: time.Minute,
+		Run:      transactionsRepo.CheckStuckTopUps,
+	})
+
+	supervisor.Add(worker.Job{
+		Name:     "provider-balance-check",
+		Interval: 10 * time.Minute,
+		Run:      balanceRepo.Refresh,
+	})
+
+	supervisor.Add(worker.Job{
+		Name:     "postpaid-quote-expiry",
+		Interval: time.Minute,
+		Run:      postpaidRepo.ExpireQuotes,
+	})
+
+	// Kembalikan kuota order flash sale yang gagal dan matikan sale yang sudah selesai
+	flashSaleRepo := flashsale.NewFlashSaleRepository(db)
+	supervisor.Add(worker.Job{
+		Name:     "flash-sale-refresh",
+		Interval: time.Minute,
+		Run:      flashSaleRepo.Run,
+	})
+
+	// Semua instance mengecek jadwal, advisory lock memastikan hanya satu sync yang jalan
+	supervisor.Add(worker.Job{
+		Name:     "product-sync-scheduler",
+		Interval: time.Minute,
+		Run:      getSyncManager(db, cfg).Jobs.RunDue,
+	})
+
+	return supervisor
+}
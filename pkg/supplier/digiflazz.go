@@ -0,0 +1,213 @@
+package supplier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/wafi04/backendvazzz/pkg/lib"
+)
+
+// Event yang dikirim Digiflazz lewat header X-Digiflazz-Event
+const (
+	DigiflazzEventCreate = "create"
+	DigiflazzEventUpdate = "update"
+)
+
+// Digiflazz adapts lib.DigiflazzService to the Supplier interface
+type Digiflazz struct {
+	service *lib.DigiflazzService
+}
+
+func NewDigiflazz(service *lib.DigiflazzService) *Digiflazz {
+	return &Digiflazz{service: service}
+}
+
+func (d *Digiflazz) Name() string {
+	return "digiflazz"
+}
+
+func (d *Digiflazz) PriceList(ctx context.Context) ([]*lib.ProductData, error) {
+	return d.service.CheckPrice()
+}
+
+func (d *Digiflazz) Order(ctx context.Context, req OrderRequest) (*OrderResult, error) {
+	resp, err := d.service.TopUp(ctx, lib.CreateTransactionToDigiflazz{
+		BuyerSKUCode: req.SKU,
+		CustomerNo:   req.CustomerNo,
+		RefID:        req.RefID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d.result(resp), nil
+}
+
+func (d *Digiflazz) Status(ctx context.Context, req OrderRequest) (*OrderResult, error) {
+	resp, err := d.service.CheckStatus(ctx, lib.CreateTransactionToDigiflazz{
+		BuyerSKUCode: req.SKU,
+		CustomerNo:   req.CustomerNo,
+		RefID:        req.RefID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d.result(resp), nil
+}
+
+func (d *Digiflazz) Balance(ctx context.Context) (int, error) {
+	return d.service.CheckBalance(ctx)
+}
+
+// ParseCallback checks the X-Hub-Signature HMAC and the X-Digiflazz-Event header.
+// Digiflazz sends the detail inside "data", older payloads send it flat.
+func (d *Digiflazz) ParseCallback(header http.Header, body []byte) (*Callback, error) {
+	signature := header.Get("X-Hub-Signature")
+	if signature == "" {
+		return nil, fmt.Errorf("%w: missing X-Hub-Signature header", ErrInvalidCallback)
+	}
+	if !d.service.VerifyWebhookSignature(body, signature) {
+		return nil, fmt.Errorf("%w: invalid signature", ErrInvalidCallback)
+	}
+
+	event := header.Get("X-Digiflazz-Event")
+	switch event {
+	case DigiflazzEventCreate, DigiflazzEventUpdate:
+	default:
+		return nil, fmt.Errorf("%w: unknown event %q", ErrInvalidCallback, event)
+	}
+
+	var payload struct {
+		Data *digiflazzCallbackDetail `json:"data"`
+		digiflazzCallbackDetail
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedCallback, err)
+	}
+
+	detail := payload.digiflazzCallbackDetail
+	if payload.Data != nil {
+		detail = *payload.Data
+	}
+
+	if detail.RefID == "" {
+		return nil, fmt.Errorf("%w: ref_id is required", ErrMalformedCallback)
+	}
+	if detail.Status == "" {
+		return nil, fmt.Errorf("%w: status is required", ErrMalformedCallback)
+	}
+
+	return &Callback{
+		Event: event,
+		Result: OrderResult{
+			RefID:       detail.RefID,
+			SKU:         detail.BuyerSKUCode,
+			CustomerNo:  detail.CustomerNo,
+			Status:      normalizeDigiflazzStatus(detail.Status),
+			RawStatus:   detail.Status,
+			Message:     detail.Message,
+			SN:          detail.SN,
+			Price:       detail.Price,
+			LastBalance: detail.BuyerLastSaldo,
+		},
+	}, nil
+}
+
+type digiflazzCallbackDetail struct {
+	RefID          string `json:"ref_id"`
+	BuyerSKUCode   string `json:"buyer_sku_code"`
+	CustomerNo     string `json:"customer_no"`
+	Status         string `json:"status"`
+	Message        string `json:"message"`
+	SN             string `json:"sn"`
+	Price          int    `json:"price"`
+	BuyerLastSaldo *int   `json:"buyer_last_saldo"`
+}
+
+func (d *Digiflazz) result(resp *lib.TransactionCreateDigiflazzResponse) *OrderResult {
+	return &OrderResult{
+		RefID:       resp.Data.RefID,
+		SKU:         resp.Data.BuyerSKUCode,
+		CustomerNo:  resp.Data.CustomerNo,
+		Status:      normalizeDigiflazzStatus(resp.Data.Status),
+		RawStatus:   resp.Data.Status,
+		Message:     resp.Data.Message,
+		SN:          resp.Data.SN,
+		Price:       resp.Data.Price,
+		LastBalance: resp.Data.BuyerLastSaldo,
+	}
+}
+
+// normalizeDigiflazzStatus maps Sukses/Pending/Gagal; anything unknown is left empty
+func normalizeDigiflazzStatus(status string) string {
+	switch strings.ToUpper(status) {
+	case "SUKSES":
+		return StatusSuccess
+	case "PENDING":
+		return StatusPending
+	case "GAGAL":
+		return StatusFailed
+	default:
+		return ""
+	}
+}
+
+func (d *Digiflazz) Inquiry(ctx context.Context, req OrderRequest) (*BillQuote, error) {
+	resp, err := d.service.InquiryPostpaid(ctx, d.postpaidRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	return &BillQuote{
+		RefID:        resp.Data.RefID,
+		SKU:          resp.Data.BuyerSKUCode,
+		CustomerNo:   resp.Data.CustomerNo,
+		CustomerName: resp.Data.CustomerName,
+		Status:       normalizeDigiflazzStatus(resp.Data.Status),
+		RawStatus:    resp.Data.Status,
+		Message:      resp.Data.Message,
+		Price:        resp.Data.Price,
+		SellingPrice: resp.Data.SellingPrice,
+		Admin:        resp.Data.Admin,
+		Detail:       resp.Data.Desc,
+	}, nil
+}
+
+func (d *Digiflazz) Pay(ctx context.Context, req OrderRequest) (*OrderResult, error) {
+	resp, err := d.service.PayPostpaid(ctx, d.postpaidRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	return d.postpaidResult(resp), nil
+}
+
+func (d *Digiflazz) PayStatus(ctx context.Context, req OrderRequest) (*OrderResult, error) {
+	resp, err := d.service.CheckPostpaidStatus(ctx, d.postpaidRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	return d.postpaidResult(resp), nil
+}
+
+func (d *Digiflazz) postpaidRequest(req OrderRequest) lib.CreateTransactionToDigiflazz {
+	return lib.CreateTransactionToDigiflazz{
+		BuyerSKUCode: req.SKU,
+		CustomerNo:   req.CustomerNo,
+		RefID:        req.RefID,
+	}
+}
+
+func (d *Digiflazz) postpaidResult(resp *lib.PostpaidDigiflazzResponse) *OrderResult {
+	return &OrderResult{
+		RefID:       resp.Data.RefID,
+		SKU:         resp.Data.BuyerSKUCode,
+		CustomerNo:  resp.Data.CustomerNo,
+		Status:      normalizeDigiflazzStatus(resp.Data.Status),
+		RawStatus:   resp.Data.Status,
+		Message:     resp.Data.Message,
+		SN:          resp.Data.SN,
+		Price:       resp.Data.Price,
+		LastBalance: resp.Data.BuyerLastSaldo,
+	}
+}
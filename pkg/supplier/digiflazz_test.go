@@ -0,0 +1,151 @@
+package supplier
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/wafi04/backendvazzz/pkg/lib"
+)
+
+const testWebhookSecret = "secret"
+
+func sign(body []byte) string {
+	mac := hmac.New(sha1.New, []byte(testWebhookSecret))
+	mac.Write(body)
+	return "sha1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func callbackHeader(body []byte, event string) http.Header {
+	header := http.Header{}
+	header.Set("X-Hub-Signature", sign(body))
+	header.Set("X-Digiflazz-Event", event)
+	return header
+}
+
+func TestDigiflazzParseCallback(t *testing.T) {
+	client := NewDigiflazz(lib.NewDigiflazzService(lib.DigiConfig{WebhookSecret: testWebhookSecret}))
+
+	wrapped := []byte(`{"data":{"ref_id":"VAZZ-1","buyer_sku_code":"ML86","customer_no":"123","status":"Sukses","sn":"SN1","price":19000,"buyer_last_saldo":500000}}`)
+	flat := []byte(`{"ref_id":"VAZZ-2","status":"Gagal","message":"Nomor salah"}`)
+
+	tests := []struct {
+		name        string
+		body        []byte
+		header      http.Header
+		wantErr     error
+		wantRef     string
+		wantStatus  string
+		wantBalance *int
+	}{
+		{
+			name:        "wrapped payload",
+			body:        wrapped,
+			header:      callbackHeader(wrapped, DigiflazzEventUpdate),
+			wantRef:     "VAZZ-1",
+			wantStatus:  StatusSuccess,
+			wantBalance: intPtr(500000),
+		},
+		{
+			name:       "flat payload",
+			body:       flat,
+			header:     callbackHeader(flat, DigiflazzEventCreate),
+			wantRef:    "VAZZ-2",
+			wantStatus: StatusFailed,
+		},
+		{
+			name:    "missing signature",
+			body:    wrapped,
+			header:  http.Header{"X-Digiflazz-Event": {DigiflazzEventUpdate}},
+			wantErr: ErrInvalidCallback,
+		},
+		{
+			name: "wrong signature",
+			body: wrapped,
+			header: http.Header{
+				"X-Hub-Signature":   {"sha1=0000"},
+				"X-Digiflazz-Event": {DigiflazzEventUpdate},
+			},
+			wantErr: ErrInvalidCallback,
+		},
+		{
+			name:    "unknown event",
+			body:    wrapped,
+			header:  callbackHeader(wrapped, "ping"),
+			wantErr: ErrInvalidCallback,
+		},
+		{
+			name:    "missing ref_id",
+			body:    []byte(`{"status":"Sukses"}`),
+			header:  callbackHeader([]byte(`{"status":"Sukses"}`), DigiflazzEventUpdate),
+			wantErr: ErrMalformedCallback,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callback, err := client.ParseCallback(tt.header, tt.body)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if callback.Result.RefID != tt.wantRef {
+				t.Errorf("RefID = %q, want %q", callback.Result.RefID, tt.wantRef)
+			}
+			if callback.Result.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", callback.Result.Status, tt.wantStatus)
+			}
+			switch {
+			case tt.wantBalance == nil && callback.Result.LastBalance != nil:
+				t.Errorf("LastBalance = %d, want nil", *callback.Result.LastBalance)
+			case tt.wantBalance != nil && (callback.Result.LastBalance == nil || *callback.Result.LastBalance != *tt.wantBalance):
+				t.Errorf("LastBalance = %v, want %d", callback.Result.LastBalance, *tt.wantBalance)
+			}
+		})
+	}
+}
+
+func TestNormalizeDigiflazzStatus(t *testing.T) {
+	tests := map[string]string{
+		"Sukses":  StatusSuccess,
+		"SUKSES":  StatusSuccess,
+		"Pending": StatusPending,
+		"Gagal":   StatusFailed,
+		"":        "",
+		"Unknown": "",
+	}
+	for status, want := range tests {
+		if got := normalizeDigiflazzStatus(status); got != want {
+			t.Errorf("normalizeDigiflazzStatus(%q) = %q, want %q", status, got, want)
+		}
+	}
+}
+
+func TestRegistryGet(t *testing.T) {
+	registry := NewRegistry(NewDigiflazz(lib.NewDigiflazzService(lib.DigiConfig{})))
+
+	for _, name := range []string{"digiflazz", "Digiflazz", ""} {
+		s, err := registry.Get(name)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", name, err)
+		}
+		if s.Name() != "digiflazz" {
+			t.Errorf("Get(%q) = %s, want digiflazz", name, s.Name())
+		}
+	}
+	if _, err := registry.Get("unknown"); !errors.Is(err, ErrUnknownSupplier) {
+		t.Errorf("Get(unknown) err = %v, want ErrUnknownSupplier", err)
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
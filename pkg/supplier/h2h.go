@@ -0,0 +1,262 @@
+package supplier
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wafi04/backendvazzz/pkg/lib"
+)
+
+// H2HConfig configures a host-to-host supplier.
+//
+// Requests are GET with query parameters signed by
+// hex(sha1(memberID|...|pin|password)); callbacks are form posts signed by
+// hex(hmac-sha256(CallbackSecret, body)) in the X-H2H-Signature header.
+type H2HConfig struct {
+	Name           string
+	BaseUrl        string
+	MemberID       string
+	Pin            string
+	Password       string
+	CallbackSecret string
+}
+
+// H2H is a generic host-to-host supplier
+type H2H struct {
+	config     H2HConfig
+	httpClient *http.Client
+}
+
+func NewH2H(config H2HConfig) *H2H {
+	if config.Name == "" {
+		config.Name = "h2h"
+	}
+	config.BaseUrl = strings.TrimRight(config.BaseUrl, "/")
+
+	return &H2H{
+		config: config,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+type h2hProduct struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Brand    string `json:"brand"`
+	Category string `json:"category"`
+	Type     string `json:"type"`
+	Price    int    `json:"price"`
+	Status   bool   `json:"status"`
+}
+
+type h2hTransaction struct {
+	RefID   string `json:"refID"`
+	TrxID   string `json:"trxID"`
+	Product string `json:"product"`
+	Dest    string `json:"dest"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	SN      string `json:"sn"`
+	Price   int    `json:"price"`
+}
+
+func (h *H2H) Name() string {
+	return h.config.Name
+}
+
+// Sign builds the request signature from the given parts
+func (h *H2H) Sign(parts ...string) string {
+	parts = append(append([]string{h.config.MemberID}, parts...), h.config.Pin, h.config.Password)
+	hash := sha1.Sum([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(hash[:])
+}
+
+func (h *H2H) PriceList(ctx context.Context) ([]*lib.ProductData, error) {
+	var resp struct {
+		Data []h2hProduct `json:"data"`
+	}
+	if err := h.get(ctx, "/pricelist", url.Values{"sign": {h.Sign()}}, &resp); err != nil {
+		return nil, err
+	}
+
+	products := make([]*lib.ProductData, 0, len(resp.Data))
+	for _, p := range resp.Data {
+		products = append(products, &lib.ProductData{
+			BuyerSkuCode:        p.Code,
+			ProductName:         p.Name,
+			Brand:               p.Brand,
+			Category:            p.Category,
+			Type:                p.Type,
+			Price:               p.Price,
+			SellerName:          h.config.Name,
+			BuyerProductStatus:  p.Status,
+			SellerProductStatus: p.Status,
+			UnlimitedStock:      true,
+		})
+	}
+	return products, nil
+}
+
+func (h *H2H) Order(ctx context.Context, req OrderRequest) (*OrderResult, error) {
+	params := url.Values{
+		"product": {req.SKU},
+		"dest":    {req.CustomerNo},
+		"refID":   {req.RefID},
+		"sign":    {h.Sign(req.SKU, req.CustomerNo, req.RefID)},
+	}
+
+	var trx h2hTransaction
+	if err := h.get(ctx, "/trx", params, &trx); err != nil {
+		return nil, err
+	}
+	return h.result(trx, req), nil
+}
+
+func (h *H2H) Status(ctx context.Context, req OrderRequest) (*OrderResult, error) {
+	params := url.Values{
+		"refID": {req.RefID},
+		"sign":  {h.Sign(req.RefID)},
+	}
+
+	var trx h2hTransaction
+	if err := h.get(ctx, "/status", params, &trx); err != nil {
+		return nil, err
+	}
+	return h.result(trx, req), nil
+}
+
+func (h *H2H) Balance(ctx context.Context) (int, error) {
+	var resp struct {
+		Balance int `json:"balance"`
+	}
+	if err := h.get(ctx, "/balance", url.Values{"sign": {h.Sign()}}, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Balance, nil
+}
+
+// SignCallback returns the X-H2H-Signature value for a callback body
+func (h *H2H) SignCallback(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(h.config.CallbackSecret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (h *H2H) ParseCallback(header http.Header, body []byte) (*Callback, error) {
+	if h.config.CallbackSecret == "" {
+		return nil, fmt.Errorf("%w: callback secret is not configured", ErrInvalidCallback)
+	}
+
+	signature := header.Get("X-H2H-Signature")
+	if signature == "" {
+		return nil, fmt.Errorf("%w: missing X-H2H-Signature header", ErrInvalidCallback)
+	}
+	if !hmac.Equal([]byte(h.SignCallback(body)), []byte(strings.ToLower(signature))) {
+		return nil, fmt.Errorf("%w: invalid signature", ErrInvalidCallback)
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedCallback, err)
+	}
+
+	price, _ := strconv.Atoi(form.Get("price"))
+	trx := h2hTransaction{
+		RefID:   form.Get("refID"),
+		Product: form.Get("product"),
+		Dest:    form.Get("dest"),
+		Status:  form.Get("status"),
+		Message: form.Get("message"),
+		SN:      form.Get("sn"),
+		Price:   price,
+	}
+	if trx.RefID == "" {
+		return nil, fmt.Errorf("%w: refID is required", ErrMalformedCallback)
+	}
+	if trx.Status == "" {
+		return nil, fmt.Errorf("%w: status is required", ErrMalformedCallback)
+	}
+
+	return &Callback{
+		Event:  "update",
+		Result: *h.result(trx, OrderRequest{RefID: trx.RefID}),
+	}, nil
+}
+
+func (h *H2H) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	params.Set("memberID", h.config.MemberID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.config.BaseUrl+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w, body: %s", err, string(body))
+	}
+	return nil
+}
+
+func (h *H2H) result(trx h2hTransaction, req OrderRequest) *OrderResult {
+	result := &OrderResult{
+		RefID:      trx.RefID,
+		SKU:        trx.Product,
+		CustomerNo: trx.Dest,
+		Status:     normalizeH2HStatus(trx.Status),
+		RawStatus:  trx.Status,
+		Message:    trx.Message,
+		SN:         trx.SN,
+		Price:      trx.Price,
+	}
+	if result.RefID == "" {
+		result.RefID = req.RefID
+	}
+	if result.SKU == "" {
+		result.SKU = req.SKU
+	}
+	if result.CustomerNo == "" {
+		result.CustomerNo = req.CustomerNo
+	}
+	return result
+}
+
+func normalizeH2HStatus(status string) string {
+	switch strings.ToUpper(status) {
+	case "SUKSES", "SUCCESS":
+		return StatusSuccess
+	case "PENDING", "PROSES":
+		return StatusPending
+	case "GAGAL", "FAILED":
+		return StatusFailed
+	default:
+		return ""
+	}
+}
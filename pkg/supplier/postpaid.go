@@ -0,0 +1,48 @@
+package supplier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrPostpaidUnsupported = errors.New("supplier does not support postpaid")
+
+// BillQuote is the outstanding bill returned by a postpaid inquiry
+type BillQuote struct {
+	RefID        string `json:"ref_id"`
+	SKU          string `json:"sku"`
+	CustomerNo   string `json:"customer_no"`
+	CustomerName string `json:"customer_name"`
+	Status       string `json:"status"`
+	RawStatus    string `json:"raw_status"`
+	Message      string `json:"message"`
+	// Price is what we pay the supplier, SellingPrice the suggested customer price
+	Price        int             `json:"price"`
+	SellingPrice int             `json:"selling_price"`
+	Admin        int             `json:"admin"`
+	Detail       json.RawMessage `json:"detail,omitempty"`
+}
+
+// PostpaidSupplier can inquire and pay bills (PLN, PDAM, BPJS, ...).
+// Pay and PayStatus must reuse the RefID of the inquiry.
+type PostpaidSupplier interface {
+	Supplier
+	Inquiry(ctx context.Context, req OrderRequest) (*BillQuote, error)
+	Pay(ctx context.Context, req OrderRequest) (*OrderResult, error)
+	PayStatus(ctx context.Context, req OrderRequest) (*OrderResult, error)
+}
+
+// GetPostpaid returns the supplier for a provider name when it supports postpaid
+func (r *Registry) GetPostpaid(name string) (PostpaidSupplier, error) {
+	s, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	postpaid, ok := s.(PostpaidSupplier)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPostpaidUnsupported, s.Name())
+	}
+	return postpaid, nil
+}
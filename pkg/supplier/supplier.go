@@ -0,0 +1,102 @@
+package supplier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/wafi04/backendvazzz/pkg/lib"
+)
+
+// Status hasil order yang sudah dinormalisasi dari tiap supplier
+const (
+	StatusSuccess = "SUCCESS"
+	StatusPending = "PENDING"
+	StatusFailed  = "FAILED"
+)
+
+// DefaultSupplier dipakai untuk produk yang belum punya provider
+const DefaultSupplier = "digiflazz"
+
+var (
+	ErrUnknownSupplier   = errors.New("unknown supplier")
+	ErrInvalidCallback   = errors.New("invalid supplier callback")
+	ErrMalformedCallback = errors.New("malformed supplier callback")
+)
+
+// OrderRequest is what we ask a supplier to fulfill
+type OrderRequest struct {
+	RefID      string
+	SKU        string
+	CustomerNo string
+}
+
+// OrderResult is the supplier answer normalized to StatusSuccess, StatusPending or StatusFailed
+type OrderResult struct {
+	RefID      string `json:"ref_id"`
+	SKU        string `json:"sku"`
+	CustomerNo string `json:"customer_no"`
+	Status     string `json:"status"`
+	RawStatus  string `json:"raw_status"`
+	Message    string `json:"message"`
+	SN         string `json:"sn"`
+	Price      int    `json:"price"`
+	// LastBalance is our deposit left at the supplier, when it reports one
+	LastBalance *int `json:"last_balance,omitempty"`
+}
+
+// Callback is a verified status update pushed by a supplier
+type Callback struct {
+	Event  string
+	Result OrderResult
+}
+
+// Supplier is a fulfillment backend that sells products to us
+type Supplier interface {
+	Name() string
+	PriceList(ctx context.Context) ([]*lib.ProductData, error)
+	Order(ctx context.Context, req OrderRequest) (*OrderResult, error)
+	Status(ctx context.Context, req OrderRequest) (*OrderResult, error)
+	Balance(ctx context.Context) (int, error)
+	// ParseCallback verifies and decodes a webhook. Verification failures wrap
+	// ErrInvalidCallback, unreadable payloads wrap ErrMalformedCallback.
+	ParseCallback(header http.Header, body []byte) (*Callback, error)
+}
+
+// Registry looks suppliers up by the name stored in services.provider
+type Registry struct {
+	suppliers map[string]Supplier
+}
+
+func NewRegistry(suppliers ...Supplier) *Registry {
+	registry := &Registry{suppliers: make(map[string]Supplier)}
+	for _, s := range suppliers {
+		registry.suppliers[strings.ToLower(s.Name())] = s
+	}
+	return registry
+}
+
+// Get returns the supplier for a provider name, falling back to DefaultSupplier when empty
+func (r *Registry) Get(name string) (Supplier, error) {
+	if name == "" {
+		name = DefaultSupplier
+	}
+	s, ok := r.suppliers[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSupplier, name)
+	}
+	return s, nil
+}
+
+// Names returns the registered supplier names, sorted
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.suppliers))
+	for name := range r.suppliers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
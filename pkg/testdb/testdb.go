@@ -0,0 +1,68 @@
+// Package testdb opens the Postgres database used by tests that need real SQL.
+package testdb
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// EnvURL names the variable holding the test database URL. Database harus sudah berisi
+// schema aplikasi dan semua migrasi, dan boleh diisi data uji.
+const EnvURL = "TEST_DATABASE_URL"
+
+// Open connects to the test database, skipping the test when TEST_DATABASE_URL is not set
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+
+	url := os.Getenv(EnvURL)
+	if url == "" {
+		t.Skipf("%s is not set", EnvURL)
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		t.Fatalf("failed to ping test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+var sequence atomic.Int64
+
+// UniqueID returns an id that does not collide with other tests or test runs
+func UniqueID(prefix string) string {
+	return fmt.Sprintf("%s%d%d", prefix, time.Now().UnixNano(), sequence.Add(1))
+}
+
+// CreateUser inserts a member with the given balance
+func CreateUser(t testing.TB, db *sql.DB, username string, balance int) {
+	t.Helper()
+	now := time.Now()
+	_, err := db.Exec(`
+		INSERT INTO users (name, username, password, whatsapp, balance, role, created_at, updated_at, last_payment_at)
+		VALUES ($1, $1, '-', '08123456789', $2, 'MEMBER', $3, $3, $3)
+	`, username, balance, now)
+	if err != nil {
+		t.Fatalf("failed to create user %s: %v", username, err)
+	}
+}
+
+// UserBalance returns the current balance of a user
+func UserBalance(t testing.TB, db *sql.DB, username string) int {
+	t.Helper()
+	var balance float64
+	if err := db.QueryRow(`SELECT balance FROM users WHERE username = $1`, username).Scan(&balance); err != nil {
+		t.Fatalf("failed to read balance of %s: %v", username, err)
+	}
+	return int(balance)
+}
@@ -38,9 +38,13 @@ type Transaction struct {
 }
 
 const (
-	StatusPending   = "PENDING"
-	StatusProcess   = "PROCESS"
-	StatusSuccess   = "SUCCESS"
-	StatusFailed    = "FAILED"
-	StatusCancelled = "CANCELED"
+	StatusPending      = "PENDING"
+	StatusPaid         = "PAID"
+	StatusProcess      = "PROCESS"
+	StatusSuccess      = "SUCCESS"
+	StatusFailed       = "FAILED"
+	StatusExpired      = "EXPIRED"
+	StatusRefunded     = "REFUNDED"
+	StatusManualReview = "MANUAL_REVIEW"
+	StatusCancelled    = "CANCELED"
 )
@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronRejectsInvalid(t *testing.T) {
+	tests := []string{
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+	}
+
+	for _, expr := range tests {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) error = nil, want error", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	at := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2026, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	// 1 Januari 2026 jatuh pada hari Kamis
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"every minute is strictly after", "* * * * *", at(1, 1, 0, 0), at(1, 1, 0, 1)},
+		{"seconds are truncated", "* * * * *", at(1, 1, 0, 0).Add(30 * time.Second), at(1, 1, 0, 1)},
+		{"daily rolls to next day", "30 2 * * *", at(1, 1, 3, 0), at(1, 2, 2, 30)},
+		{"range with step", "10-20/5 * * * *", at(1, 1, 0, 0), at(1, 1, 0, 10)},
+		{"range with step middle", "10-20/5 * * * *", at(1, 1, 0, 10), at(1, 1, 0, 15)},
+		{"range with step wraps hour", "10-20/5 * * * *", at(1, 1, 0, 20), at(1, 1, 1, 10)},
+		{"value with step runs to field max", "50/5 * * * *", at(1, 1, 0, 55), at(1, 1, 1, 50)},
+		{"list", "0 6,18 * * *", at(1, 1, 7, 0), at(1, 1, 18, 0)},
+		{"weekday 7 is Sunday", "0 0 * * 7", at(1, 1, 0, 0), at(1, 4, 0, 0)},
+		{"weekday 0 is Sunday", "0 0 * * 0", at(1, 1, 0, 0), at(1, 4, 0, 0)},
+		{"weekday range", "30 9 * * 1-5", at(1, 2, 10, 0), at(1, 5, 9, 30)},
+		{"day of month or weekday matches weekday", "0 0 1 * 1", at(1, 1, 0, 0), at(1, 5, 0, 0)},
+		{"day of month or weekday matches day of month", "0 0 1 * 1", at(1, 31, 0, 0), at(2, 1, 0, 0)},
+		{"starred day of month step is unrestricted", "0 0 */2 * 1", at(1, 1, 0, 0), at(1, 5, 0, 0)},
+		{"starred weekday step is unrestricted", "0 0 15 * */2", at(1, 1, 0, 0), at(1, 15, 0, 0)},
+		{"month skips to next year", "0 0 1 1 *", at(3, 1, 0, 0), time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"31 February never runs", "0 0 31 2 *", at(1, 1, 0, 0), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) error = %v", tt.expr, err)
+			}
+			if got := c.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNextKeepsLocation(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	c, err := ParseCron("0 0 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := c.Next(time.Date(2026, 1, 1, 12, 0, 0, 0, jakarta))
+	want := time.Date(2026, 1, 2, 0, 0, 0, 0, jakarta)
+	if !got.Equal(want) || got.Location() != jakarta {
+		t.Errorf("Next = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Job is a unit of background work that runs every Interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Supervisor runs registered jobs on their own ticker and keeps them alive
+// when a run panics, so one broken job cannot take the process down.
+type Supervisor struct {
+	jobs      []Job
+	isRunning bool
+	mutex     sync.Mutex
+	stopChan  chan struct{}
+	wg        sync.WaitGroup
+}
+
+func NewSupervisor() *Supervisor {
+	return &Supervisor{
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Add registers a job. Jobs added after Start are ignored.
+func (s *Supervisor) Add(job Job) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.isRunning {
+		log.Printf("Worker %s not registered: supervisor already running", job.Name)
+		return
+	}
+	s.jobs = append(s.jobs, job)
+}
+
+// Start launches every registered job
+func (s *Supervisor) Start() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.isRunning {
+		return
+	}
+	s.isRunning = true
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(job)
+		log.Printf("Worker %s started - running every %v", job.Name, job.Interval)
+	}
+}
+
+// Stop signals all jobs to finish and waits for the running ones
+func (s *Supervisor) Stop() {
+	s.mutex.Lock()
+	if !s.isRunning {
+		s.mutex.Unlock()
+		return
+	}
+	s.isRunning = false
+	close(s.stopChan)
+	s.mutex.Unlock()
+
+	s.wg.Wait()
+	log.Println("All workers stopped")
+}
+
+func (s *Supervisor) loop(job Job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	s.runOnce(job)
+
+	for {
+		select {
+		case <-ticker.C:
+			s.runOnce(job)
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// runOnce executes a single run and recovers from panics
+func (s *Supervisor) runOnce(job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Worker %s panicked: %v\n%s", job.Name, r, debug.Stack())
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), job.Interval)
+	defer cancel()
+
+	if err := job.Run(ctx); err != nil {
+		log.Printf("Worker %s failed: %v", job.Name, err)
+	}
+}
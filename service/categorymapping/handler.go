@@ -0,0 +1,122 @@
+package categorymapping
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wafi04/backendvazzz/pkg/utils"
+)
+
+type CategoryMappingHandler struct {
+	service *CategoryMappingService
+}
+
+func NewCategoryMappingHandler(service *CategoryMappingService) *CategoryMappingHandler {
+	return &CategoryMappingHandler{
+		service: service,
+	}
+}
+
+func (h *CategoryMappingHandler) List(c *gin.Context) {
+	rules, err := h.service.List(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch mapping rules", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Mapping rules retrieved successfully", rules)
+}
+
+func (h *CategoryMappingHandler) Create(c *gin.Context) {
+	var req RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	rule, err := h.service.Create(c.Request.Context(), req)
+	if err != nil {
+		h.writeError(c, "Failed to create mapping rule", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Mapping rule created successfully", rule)
+}
+
+func (h *CategoryMappingHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid rule id", err.Error())
+		return
+	}
+
+	var req RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	rule, err := h.service.Update(c.Request.Context(), id, req)
+	if err != nil {
+		h.writeError(c, "Failed to update mapping rule", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Mapping rule updated successfully", rule)
+}
+
+func (h *CategoryMappingHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid rule id", err.Error())
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		h.writeError(c, "Failed to delete mapping rule", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Mapping rule deleted successfully", nil)
+}
+
+func (h *CategoryMappingHandler) Unmapped(c *gin.Context) {
+	includeResolved := c.Query("includeResolved") == "true"
+
+	products, err := h.service.Unmapped(c.Request.Context(), includeResolved)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch unmapped products", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Unmapped products retrieved successfully", products)
+}
+
+func (h *CategoryMappingHandler) ResolveUnmapped(c *gin.Context) {
+	var req RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	result, err := h.service.ResolveUnmapped(c.Request.Context(), c.Param("providerId"), req)
+	if err != nil {
+		h.writeError(c, "Failed to resolve unmapped product", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Unmapped product resolved, product will be created on the next sync", result)
+}
+
+func (h *CategoryMappingHandler) writeError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidRule):
+		utils.ErrorResponse(c, http.StatusBadRequest, message, err.Error())
+	case errors.Is(err, ErrRuleNotFound), errors.Is(err, ErrUnmappedMissing):
+		utils.ErrorResponse(c, http.StatusNotFound, message, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, message, err.Error())
+	}
+}
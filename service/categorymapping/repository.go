@@ -0,0 +1,324 @@
+package categorymapping
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/wafi04/backendvazzz/pkg/lib"
+)
+
+// Field produk Digiflazz yang bisa dicocokkan oleh aturan
+const (
+	MatchBrand     = "brand"
+	MatchCategory  = "category"
+	MatchType      = "type"
+	MatchSKUPrefix = "sku_prefix"
+	MatchNameRegex = "name_regex"
+)
+
+var (
+	ErrUnmapped        = errors.New("no category mapping for product")
+	ErrRuleNotFound    = errors.New("mapping rule not found")
+	ErrInvalidRule     = errors.New("invalid mapping rule")
+	ErrUnmappedMissing = errors.New("unmapped product not found")
+)
+
+type Rule struct {
+	ID              int       `json:"id"`
+	Priority        int       `json:"priority"`
+	MatchField      string    `json:"matchField"`
+	Pattern         string    `json:"pattern"`
+	CategoryID      int       `json:"categoryId"`
+	SubCategoryID   *int      `json:"subCategoryId,omitempty"`
+	AutoSubCategory bool      `json:"autoSubCategory"`
+	Status          string    `json:"status"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+
+	// nameRe menyimpan regex name_regex yang sudah dikompilasi
+	nameRe *regexp.Regexp
+}
+
+type RuleRequest struct {
+	Priority        int    `json:"priority"`
+	MatchField      string `json:"matchField" validate:"required,oneof=brand category type sku_prefix name_regex"`
+	Pattern         string `json:"pattern" validate:"required"`
+	CategoryID      int    `json:"categoryId" validate:"required"`
+	SubCategoryID   *int   `json:"subCategoryId,omitempty"`
+	AutoSubCategory bool   `json:"autoSubCategory"`
+	Status          string `json:"status" validate:"omitempty,oneof=active inactive"`
+}
+
+type UnmappedProduct struct {
+	ProviderID  string     `json:"providerId"`
+	ProductName string     `json:"productName"`
+	Brand       string     `json:"brand"`
+	Category    string     `json:"category"`
+	Type        string     `json:"type"`
+	SeenCount   int        `json:"seenCount"`
+	FirstSeenAt time.Time  `json:"firstSeenAt"`
+	LastSeenAt  time.Time  `json:"lastSeenAt"`
+	ResolvedAt  *time.Time `json:"resolvedAt,omitempty"`
+}
+
+// Target is where a product is placed; SubCategoryID 0 means no sub-category
+type Target struct {
+	CategoryID    int
+	SubCategoryID int
+}
+
+type CategoryMappingRepository struct {
+	DB *sql.DB
+}
+
+func NewCategoryMappingRepository(db *sql.DB) *CategoryMappingRepository {
+	return &CategoryMappingRepository{
+		DB: db,
+	}
+}
+
+// Validate checks the match field and compiles name regexes before they are saved
+func (req *RuleRequest) Validate() error {
+	switch req.MatchField {
+	case MatchBrand, MatchCategory, MatchType, MatchSKUPrefix:
+	case MatchNameRegex:
+		if _, err := regexp.Compile(req.Pattern); err != nil {
+			return fmt.Errorf("%w: pattern is not a valid regex: %v", ErrInvalidRule, err)
+		}
+	default:
+		return fmt.Errorf("%w: unknown match field %q", ErrInvalidRule, req.MatchField)
+	}
+
+	if strings.TrimSpace(req.Pattern) == "" {
+		return fmt.Errorf("%w: pattern is required", ErrInvalidRule)
+	}
+	if req.CategoryID <= 0 {
+		return fmt.Errorf("%w: categoryId is required", ErrInvalidRule)
+	}
+	if req.Status == "" {
+		req.Status = "active"
+	}
+	if req.Priority == 0 {
+		req.Priority = 100
+	}
+	return nil
+}
+
+// Matches reports whether the rule applies to the product
+func (r *Rule) Matches(p lib.ProductData) bool {
+	switch r.MatchField {
+	case MatchBrand:
+		return strings.EqualFold(strings.TrimSpace(p.Brand), strings.TrimSpace(r.Pattern))
+	case MatchCategory:
+		return strings.EqualFold(strings.TrimSpace(p.Category), strings.TrimSpace(r.Pattern))
+	case MatchType:
+		return strings.EqualFold(strings.TrimSpace(p.Type), strings.TrimSpace(r.Pattern))
+	case MatchSKUPrefix:
+		return strings.HasPrefix(strings.ToUpper(p.BuyerSkuCode), strings.ToUpper(r.Pattern))
+	case MatchNameRegex:
+		if r.nameRe == nil {
+			r.nameRe, _ = regexp.Compile("(?i)" + r.Pattern)
+		}
+		return r.nameRe != nil && r.nameRe.MatchString(p.ProductName)
+	}
+	return false
+}
+
+var nonAlnum = regexp.MustCompile(`[^a-z0-9]+`)
+
+func subCategoryCode(name string) string {
+	return strings.Trim(nonAlnum.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+func (repo *CategoryMappingRepository) recordUnmapped(ctx context.Context, p lib.ProductData) error {
+	_, err := repo.DB.ExecContext(ctx, `
+		INSERT INTO unmapped_products (provider_id, product_name, brand, category, type)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (provider_id) DO UPDATE SET
+			product_name = EXCLUDED.product_name,
+			brand = EXCLUDED.brand,
+			category = EXCLUDED.category,
+			type = EXCLUDED.type,
+			seen_count = unmapped_products.seen_count + 1,
+			last_seen_at = NOW(),
+			resolved_at = NULL
+	`, p.BuyerSkuCode, p.ProductName, p.Brand, p.Category, p.Type)
+	if err != nil {
+		return fmt.Errorf("failed to record unmapped product: %w", err)
+	}
+	return nil
+}
+
+func (repo *CategoryMappingRepository) markResolved(ctx context.Context, providerID string) error {
+	_, err := repo.DB.ExecContext(ctx, `
+		UPDATE unmapped_products SET resolved_at = NOW()
+		WHERE provider_id = $1 AND resolved_at IS NULL
+	`, providerID)
+	if err != nil {
+		return fmt.Errorf("failed to resolve unmapped product: %w", err)
+	}
+	return nil
+}
+
+const ruleColumns = `id, priority, match_field, pattern, category_id, sub_category_id, auto_sub_category, status, created_at, updated_at`
+
+func scanRule(row interface{ Scan(...interface{}) error }) (*Rule, error) {
+	var rule Rule
+	err := row.Scan(
+		&rule.ID, &rule.Priority, &rule.MatchField, &rule.Pattern, &rule.CategoryID,
+		&rule.SubCategoryID, &rule.AutoSubCategory, &rule.Status, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	return &rule, err
+}
+
+// List returns the rules in evaluation order
+func (repo *CategoryMappingRepository) List(ctx context.Context, activeOnly bool) ([]Rule, error) {
+	query := `SELECT ` + ruleColumns + ` FROM category_mapping_rules`
+	if activeOnly {
+		query += ` WHERE status = 'active'`
+	}
+	query += ` ORDER BY priority ASC, id ASC`
+
+	rows, err := repo.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query mapping rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []Rule{}
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan mapping rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
+
+func (repo *CategoryMappingRepository) Create(ctx context.Context, req RuleRequest) (*Rule, error) {
+	rule, err := scanRule(repo.DB.QueryRowContext(ctx, `
+		INSERT INTO category_mapping_rules (priority, match_field, pattern, category_id, sub_category_id, auto_sub_category, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+ruleColumns,
+		req.Priority, req.MatchField, req.Pattern, req.CategoryID, req.SubCategoryID, req.AutoSubCategory, req.Status,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mapping rule: %w", err)
+	}
+	return rule, nil
+}
+
+func (repo *CategoryMappingRepository) Update(ctx context.Context, id int, req RuleRequest) (*Rule, error) {
+	rule, err := scanRule(repo.DB.QueryRowContext(ctx, `
+		UPDATE category_mapping_rules
+		SET priority = $1, match_field = $2, pattern = $3, category_id = $4, sub_category_id = $5,
+			auto_sub_category = $6, status = $7, updated_at = NOW()
+		WHERE id = $8
+		RETURNING `+ruleColumns,
+		req.Priority, req.MatchField, req.Pattern, req.CategoryID, req.SubCategoryID, req.AutoSubCategory, req.Status, id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update mapping rule: %w", err)
+	}
+	return rule, nil
+}
+
+func (repo *CategoryMappingRepository) Delete(ctx context.Context, id int) error {
+	result, err := repo.DB.ExecContext(ctx, `DELETE FROM category_mapping_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete mapping rule: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrRuleNotFound
+	}
+	return nil
+}
+
+// Unmapped lists products waiting for a mapping, oldest resolved entries excluded
+func (repo *CategoryMappingRepository) Unmapped(ctx context.Context, includeResolved bool) ([]UnmappedProduct, error) {
+	query := `
+		SELECT provider_id, product_name, COALESCE(brand, ''), COALESCE(category, ''), COALESCE(type, ''),
+			seen_count, first_seen_at, last_seen_at, resolved_at
+		FROM unmapped_products
+	`
+	if !includeResolved {
+		query += ` WHERE resolved_at IS NULL`
+	}
+	query += ` ORDER BY last_seen_at DESC`
+
+	rows, err := repo.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unmapped products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []UnmappedProduct{}
+	for rows.Next() {
+		var p UnmappedProduct
+		if err := rows.Scan(
+			&p.ProviderID, &p.ProductName, &p.Brand, &p.Category, &p.Type,
+			&p.SeenCount, &p.FirstSeenAt, &p.LastSeenAt, &p.ResolvedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan unmapped product: %w", err)
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+func (repo *CategoryMappingRepository) GetUnmapped(ctx context.Context, providerID string) (*UnmappedProduct, error) {
+	var p UnmappedProduct
+	err := repo.DB.QueryRowContext(ctx, `
+		SELECT provider_id, product_name, COALESCE(brand, ''), COALESCE(category, ''), COALESCE(type, ''),
+			seen_count, first_seen_at, last_seen_at, resolved_at
+		FROM unmapped_products
+		WHERE provider_id = $1
+	`, providerID).Scan(
+		&p.ProviderID, &p.ProductName, &p.Brand, &p.Category, &p.Type,
+		&p.SeenCount, &p.FirstSeenAt, &p.LastSeenAt, &p.ResolvedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrUnmappedMissing
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load unmapped product: %w", err)
+	}
+	return &p, nil
+}
+
+// ResolveUnmapped marks every open entry matched by the rule as resolved; the products
+// are created on the next sync
+func (repo *CategoryMappingRepository) ResolveUnmapped(ctx context.Context, rule *Rule) (int, error) {
+	open, err := repo.Unmapped(ctx, false)
+	if err != nil {
+		return 0, err
+	}
+
+	resolved := 0
+	for _, p := range open {
+		product := lib.ProductData{
+			BuyerSkuCode: p.ProviderID,
+			ProductName:  p.ProductName,
+			Brand:        p.Brand,
+			Category:     p.Category,
+			Type:         p.Type,
+		}
+		if !rule.Matches(product) {
+			continue
+		}
+		if err := repo.markResolved(ctx, p.ProviderID); err != nil {
+			return resolved, err
+		}
+		resolved++
+	}
+	return resolved, nil
+}
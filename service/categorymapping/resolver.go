@@ -0,0 +1,173 @@
+package categorymapping
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/wafi04/backendvazzz/pkg/lib"
+)
+
+// Resolver places many products with the rules loaded once; category and sub-category
+// lookups are cached for the lifetime of the resolver. It is created per sync rather than
+// kept around, so rule and category changes show up in the next sync.
+type Resolver struct {
+	repo  *CategoryMappingRepository
+	rules []Rule
+	// categories memetakan brand+kategori Digiflazz ke category id, 0 jika tidak ada
+	categories    map[string]int
+	subCategories map[string]int
+	// unmapped berisi SKU yang masih terbuka di unmapped_products
+	unmapped map[string]bool
+}
+
+// NewResolver loads the active rules and the open unmapped products
+func (repo *CategoryMappingRepository) NewResolver(ctx context.Context) (*Resolver, error) {
+	rules, err := repo.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.DB.QueryContext(ctx, `SELECT provider_id FROM unmapped_products WHERE resolved_at IS NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unmapped products: %w", err)
+	}
+	defer rows.Close()
+
+	unmapped := map[string]bool{}
+	for rows.Next() {
+		var providerID string
+		if err := rows.Scan(&providerID); err != nil {
+			return nil, fmt.Errorf("failed to scan unmapped product: %w", err)
+		}
+		unmapped[providerID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &Resolver{
+		repo:          repo,
+		rules:         rules,
+		categories:    map[string]int{},
+		subCategories: map[string]int{},
+		unmapped:      unmapped,
+	}, nil
+}
+
+// Resolve finds the category of a product from the rules, then from categories.brand/name.
+// Products that match nothing are recorded in unmapped_products and return ErrUnmapped.
+func (r *Resolver) Resolve(ctx context.Context, p lib.ProductData) (*Target, error) {
+	for i := range r.rules {
+		rule := &r.rules[i]
+		if !rule.Matches(p) {
+			continue
+		}
+
+		target := &Target{CategoryID: rule.CategoryID}
+		var err error
+		switch {
+		case rule.SubCategoryID != nil:
+			target.SubCategoryID = *rule.SubCategoryID
+		case rule.AutoSubCategory:
+			target.SubCategoryID, err = r.subCategoryForType(ctx, rule.CategoryID, p.Type, true)
+		default:
+			target.SubCategoryID, err = r.subCategoryForType(ctx, rule.CategoryID, p.Type, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return target, r.markResolved(ctx, p.BuyerSkuCode)
+	}
+
+	// Fallback lama: kategori dengan brand atau nama yang sama
+	categoryID, err := r.categoryFor(ctx, p.Brand, p.Category)
+	if err != nil {
+		return nil, err
+	}
+	if categoryID == 0 {
+		if err := r.repo.recordUnmapped(ctx, p); err != nil {
+			return nil, err
+		}
+		r.unmapped[p.BuyerSkuCode] = true
+		return nil, fmt.Errorf("%w: %s (brand %q, category %q, type %q)", ErrUnmapped, p.BuyerSkuCode, p.Brand, p.Category, p.Type)
+	}
+
+	subCategoryID, err := r.subCategoryForType(ctx, categoryID, p.Type, false)
+	if err != nil {
+		return nil, err
+	}
+	return &Target{CategoryID: categoryID, SubCategoryID: subCategoryID}, r.markResolved(ctx, p.BuyerSkuCode)
+}
+
+func (r *Resolver) categoryFor(ctx context.Context, brand, category string) (int, error) {
+	key := strings.ToLower(strings.TrimSpace(brand)) + "\x00" + strings.ToLower(strings.TrimSpace(category))
+	if id, ok := r.categories[key]; ok {
+		return id, nil
+	}
+
+	var id int
+	err := r.repo.DB.QueryRowContext(ctx, `
+		SELECT id FROM categories
+		WHERE LOWER(brand) = LOWER($1) OR LOWER(name) = LOWER($2)
+		ORDER BY (LOWER(brand) = LOWER($1)) DESC, id ASC
+		LIMIT 1
+	`, brand, category).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to look up category: %w", err)
+	}
+
+	r.categories[key] = id
+	return id, nil
+}
+
+// subCategoryForType finds the sub-category named after the Digiflazz type, creating it when asked
+func (r *Resolver) subCategoryForType(ctx context.Context, categoryID int, productType string, create bool) (int, error) {
+	productType = strings.TrimSpace(productType)
+	if productType == "" {
+		return 0, nil
+	}
+
+	key := fmt.Sprintf("%d\x00%s", categoryID, strings.ToLower(productType))
+	if id, ok := r.subCategories[key]; ok && (id != 0 || !create) {
+		return id, nil
+	}
+
+	var id int
+	err := r.repo.DB.QueryRowContext(ctx, `
+		SELECT id FROM sub_categories
+		WHERE category_id = $1 AND LOWER(name) = LOWER($2)
+		ORDER BY id ASC
+		LIMIT 1
+	`, categoryID, productType).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to look up sub category: %w", err)
+	}
+
+	if err == sql.ErrNoRows && create {
+		err = r.repo.DB.QueryRowContext(ctx, `
+			INSERT INTO sub_categories (name, category_id, code, status)
+			VALUES ($1, $2, $3, 'active')
+			RETURNING id
+		`, productType, categoryID, subCategoryCode(productType)).Scan(&id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create sub category %q: %w", productType, err)
+		}
+	}
+
+	r.subCategories[key] = id
+	return id, nil
+}
+
+// markResolved hanya menyentuh database untuk SKU yang memang masih terbuka
+func (r *Resolver) markResolved(ctx context.Context, providerID string) error {
+	if !r.unmapped[providerID] {
+		return nil
+	}
+	if err := r.repo.markResolved(ctx, providerID); err != nil {
+		return err
+	}
+	delete(r.unmapped, providerID)
+	return nil
+}
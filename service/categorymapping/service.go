@@ -0,0 +1,69 @@
+package categorymapping
+
+import "context"
+
+type CategoryMappingService struct {
+	repo *CategoryMappingRepository
+}
+
+func NewCategoryMappingService(repo *CategoryMappingRepository) *CategoryMappingService {
+	return &CategoryMappingService{
+		repo: repo,
+	}
+}
+
+// ResolveResult is the rule created for an unmapped product and how many entries it resolved
+type ResolveResult struct {
+	Rule     *Rule `json:"rule"`
+	Resolved int   `json:"resolved"`
+}
+
+func (s *CategoryMappingService) List(ctx context.Context) ([]Rule, error) {
+	return s.repo.List(ctx, false)
+}
+
+func (s *CategoryMappingService) Create(ctx context.Context, req RuleRequest) (*Rule, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return s.repo.Create(ctx, req)
+}
+
+func (s *CategoryMappingService) Update(ctx context.Context, id int, req RuleRequest) (*Rule, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return s.repo.Update(ctx, id, req)
+}
+
+func (s *CategoryMappingService) Delete(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *CategoryMappingService) Unmapped(ctx context.Context, includeResolved bool) ([]UnmappedProduct, error) {
+	return s.repo.Unmapped(ctx, includeResolved)
+}
+
+// ResolveUnmapped creates a rule for an unmapped product. Tanpa matchField, aturan dibuat
+// khusus untuk SKU tersebut.
+func (s *CategoryMappingService) ResolveUnmapped(ctx context.Context, providerID string, req RuleRequest) (*ResolveResult, error) {
+	if _, err := s.repo.GetUnmapped(ctx, providerID); err != nil {
+		return nil, err
+	}
+
+	if req.MatchField == "" {
+		req.MatchField = MatchSKUPrefix
+		req.Pattern = providerID
+	}
+
+	rule, err := s.Create(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := s.repo.ResolveUnmapped(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+	return &ResolveResult{Rule: rule, Resolved: resolved}, nil
+}
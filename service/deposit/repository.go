@@ -52,6 +52,7 @@ func (r *DepositRepository) Create(c context.Context, deposit model.CreateDeposi
 	}
 	query := `INSERT INTO deposits (
 		method, 
+		method_code,
 		amount,
 		username,
 		deposit_id,
@@ -61,11 +62,12 @@ func (r *DepositRepository) Create(c context.Context, deposit model.CreateDeposi
 		updated_at,
 		log
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
 
 	var id int
 	err = tx.QueryRowContext(c, query,
 		methodName,
+		deposit.Method,
 		deposit.Amount,
 		username,
 		depositID,
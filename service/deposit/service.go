@@ -9,15 +9,20 @@ import (
 	"github.com/wafi04/backendvazzz/pkg/lib"
 	"github.com/wafi04/backendvazzz/pkg/model"
 	"github.com/wafi04/backendvazzz/pkg/utils"
+	"github.com/wafi04/backendvazzz/service/expiry"
 )
 
 type DepositService struct {
-	repo *DepositRepository
+	repo    *DepositRepository
+	duitku  *lib.DuitkuService
+	payment config.PaymentConfig
 }
 
-func NewDepositService(repo *DepositRepository) *DepositService {
+func NewDepositService(repo *DepositRepository, duitku *lib.DuitkuService, payment config.PaymentConfig) *DepositService {
 	return &DepositService{
-		repo: repo,
+		repo:    repo,
+		duitku:  duitku,
+		payment: payment,
 	}
 }
 
@@ -25,18 +30,20 @@ func stringPtr(s string) *string {
 	return &s
 }
 
+func intPtr(i int) *int {
+	return &i
+}
+
 func (service *DepositService) Create(c context.Context, amount int, methodCode, username string) (string, error) {
-	duitku := lib.NewDuitkuService()
 	depStr := "DEP"
 	depositID := utils.GenerateUniqeID(&depStr)
 
-	duitkuCall, err := duitku.CreateTransaction(c, &lib.DuitkuCreateTransactionParams{
+	duitkuCall, err := service.duitku.CreateTransaction(c, &lib.DuitkuCreateTransactionParams{
 		PaymentAmount:   amount,
 		MerchantOrderId: depositID,
 		ProductDetails:  "Deposit",
 		PaymentCode:     methodCode,
-		CallbackUrl:     stringPtr(config.GetEnv("DUITKU_CALLBACK_URL", "")),
-		ReturnUrl:       stringPtr(config.GetEnv("DUITKU_RETURN_URL", "")),
+		ExpiryPeriod:    intPtr(expiry.GetPeriodForMethod(c, service.repo.Repo, methodCode, service.payment.ExpiryMinutes)),
 	})
 
 	log.Println("Duitku response:", duitkuCall)
@@ -0,0 +1,22 @@
+package expiry
+
+import (
+	"context"
+	"database/sql"
+)
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// GetPeriodForMethod returns the expiry period in minutes configured for a payment method code,
+// or defaultPeriod when the method has none
+func GetPeriodForMethod(ctx context.Context, db queryRower, methodCode string, defaultPeriod int) int {
+	var period sql.NullInt64
+	err := db.QueryRowContext(ctx, `SELECT expiry_period FROM payment_methods WHERE code = $1`, methodCode).Scan(&period)
+	if err != nil || !period.Valid || period.Int64 <= 0 {
+		return defaultPeriod
+	}
+	return int(period.Int64)
+}
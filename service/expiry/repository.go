@@ -0,0 +1,179 @@
+package expiry
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/wafi04/backendvazzz/pkg/config"
+	"github.com/wafi04/backendvazzz/pkg/types"
+	"github.com/wafi04/backendvazzz/service/order"
+)
+
+type ExpiryRepository struct {
+	DB        *sql.DB
+	orderRepo *order.OrderRepository
+	config    config.PaymentConfig
+}
+
+func NewExpiryRepository(db *sql.DB, cfg config.PaymentConfig) *ExpiryRepository {
+	return &ExpiryRepository{
+		DB:        db,
+		orderRepo: order.NewOrderRepository(db),
+		config:    cfg,
+	}
+}
+
+type expiredOrder struct {
+	OrderID     string
+	VoucherCode *string
+}
+
+// batchSize membatasi jumlah order yang diproses setiap putaran worker
+const batchSize = 100
+
+// ExpireOrders marks unpaid Duitku orders older than their method's expiry period plus the
+// grace window as EXPIRED
+func (repo *ExpiryRepository) ExpireOrders(ctx context.Context) (int, error) {
+	query := `
+		SELECT t.order_id, t.voucher_code
+		FROM transactions t
+		JOIN payments p ON p.order_id = t.order_id
+		LEFT JOIN payment_methods pm ON pm.code = p.method_code
+		WHERE t.status = $1
+		  AND p.status = $1
+		  AND p.method <> 'SALDO'
+		  AND (
+			p.created_at < NOW() - make_interval(mins => COALESCE(pm.expiry_period, $2) + $4)
+			-- Tagihan pascabayar ikut kedaluwarsa bersama hasil inquiry-nya
+			OR EXISTS (SELECT 1 FROM bill_quotes bq WHERE bq.quote_id = t.order_id AND bq.expires_at <= NOW())
+		  )
+		ORDER BY p.created_at ASC
+		LIMIT $3
+	`
+
+	rows, err := repo.DB.QueryContext(ctx, query, types.StatusPending, repo.config.ExpiryMinutes, batchSize, repo.config.ExpiryGraceMinutes)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query expired orders: %w", err)
+	}
+
+	var orders []expiredOrder
+	for rows.Next() {
+		var o expiredOrder
+		if err := rows.Scan(&o.OrderID, &o.VoucherCode); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		orders = append(orders, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, o := range orders {
+		if err := repo.expireOrder(ctx, o); err != nil {
+			log.Printf("Failed to expire order %s: %v", o.OrderID, err)
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+func (repo *ExpiryRepository) expireOrder(ctx context.Context, o expiredOrder) error {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = repo.orderRepo.Transition(ctx, tx, order.Transition{
+		OrderID: o.OrderID,
+		To:      types.StatusExpired,
+		Actor:   order.SourceSystem,
+		Source:  order.SourceSystem,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE transactions
+		SET message = 'Pesanan Kadaluarsa', updated_at = NOW()
+		WHERE order_id = $1
+	`, o.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction message: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE payments
+		SET status = $1, updated_at = NOW()
+		WHERE order_id = $2 AND status = $3
+	`, types.StatusExpired, o.OrderID, types.StatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to expire payment: %w", err)
+	}
+
+	// Kembalikan kuota voucher yang dipakai saat order dibuat
+	if o.VoucherCode != nil && *o.VoucherCode != "" {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE vouchers
+			SET usage_count = GREATEST(COALESCE(usage_count, 0) - 1, 0),
+				updated_at = NOW()
+			WHERE code = $1
+		`, *o.VoucherCode)
+		if err != nil {
+			return fmt.Errorf("failed to release voucher %s: %w", *o.VoucherCode, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ExpireDeposits marks PENDING deposits older than their method's expiry period plus the
+// grace window as EXPIRED
+func (repo *ExpiryRepository) ExpireDeposits(ctx context.Context) (int, error) {
+	query := `
+		UPDATE deposits d
+		SET status = $1,
+			log = 'Deposit Kadaluarsa',
+			updated_at = NOW()
+		WHERE d.status = $2
+		  AND d.created_at < NOW() - make_interval(mins => COALESCE(
+				(SELECT pm.expiry_period FROM payment_methods pm WHERE pm.code = d.method_code LIMIT 1), $3) + $4)
+	`
+
+	result, err := repo.DB.ExecContext(ctx, query, types.StatusExpired, types.StatusPending, repo.config.ExpiryMinutes, repo.config.ExpiryGraceMinutes)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire deposits: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rowsAffected), nil
+}
+
+// Run is the worker entry point
+func (repo *ExpiryRepository) Run(ctx context.Context) error {
+	orders, err := repo.ExpireOrders(ctx)
+	if err != nil {
+		return err
+	}
+
+	deposits, err := repo.ExpireDeposits(ctx)
+	if err != nil {
+		return err
+	}
+
+	if orders > 0 || deposits > 0 {
+		log.Printf("Expiry worker - orders expired: %d, deposits expired: %d", orders, deposits)
+	}
+	return nil
+}
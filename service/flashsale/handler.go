@@ -0,0 +1,122 @@
+package flashsale
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wafi04/backendvazzz/pkg/utils"
+)
+
+type FlashSaleHandler struct {
+	service *FlashSaleService
+}
+
+func NewFlashSaleHandler(service *FlashSaleService) *FlashSaleHandler {
+	return &FlashSaleHandler{
+		service: service,
+	}
+}
+
+// Current lists the live and upcoming sales, public
+func (h *FlashSaleHandler) Current(c *gin.Context) {
+	sales, err := h.service.Current(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch flash sales", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Flash sales retrieved successfully", sales)
+}
+
+func (h *FlashSaleHandler) List(c *gin.Context) {
+	sales, err := h.service.List(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch flash sales", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Flash sales retrieved successfully", sales)
+}
+
+func (h *FlashSaleHandler) Get(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid flash sale id", err.Error())
+		return
+	}
+
+	sale, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		h.writeError(c, "Failed to fetch flash sale", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Flash sale retrieved successfully", sale)
+}
+
+func (h *FlashSaleHandler) Create(c *gin.Context) {
+	var req SaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	sale, err := h.service.Create(c.Request.Context(), req)
+	if err != nil {
+		h.writeError(c, "Failed to create flash sale", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Flash sale created successfully", sale)
+}
+
+func (h *FlashSaleHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid flash sale id", err.Error())
+		return
+	}
+
+	var req SaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	sale, err := h.service.Update(c.Request.Context(), id, req)
+	if err != nil {
+		h.writeError(c, "Failed to update flash sale", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Flash sale updated successfully", sale)
+}
+
+func (h *FlashSaleHandler) Cancel(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid flash sale id", err.Error())
+		return
+	}
+
+	sale, err := h.service.Cancel(c.Request.Context(), id)
+	if err != nil {
+		h.writeError(c, "Failed to cancel flash sale", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Flash sale cancelled successfully", sale)
+}
+
+func (h *FlashSaleHandler) writeError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidSale):
+		utils.ErrorResponse(c, http.StatusBadRequest, message, err.Error())
+	case errors.Is(err, ErrSaleNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, message, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, message, err.Error())
+	}
+}
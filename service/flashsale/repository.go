@@ -0,0 +1,570 @@
+package flashsale
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/wafi04/backendvazzz/pkg/types"
+)
+
+// Status flash_sales
+const (
+	StatusActive    = "active"
+	StatusCancelled = "cancelled"
+)
+
+// State dihitung dari jadwal saat dibaca
+const (
+	StateUpcoming  = "upcoming"
+	StateLive      = "live"
+	StateEnded     = "ended"
+	StateCancelled = "cancelled"
+)
+
+var (
+	ErrSaleNotFound = errors.New("flash sale not found")
+	ErrInvalidSale  = errors.New("invalid flash sale")
+)
+
+type FlashSale struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Banner    *string   `json:"banner,omitempty"`
+	StartAt   time.Time `json:"startAt"`
+	EndAt     time.Time `json:"endAt"`
+	Status    string    `json:"status"`
+	State     string    `json:"state"`
+	Items     []Item    `json:"items"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type Item struct {
+	ID               int    `json:"id"`
+	ProviderID       string `json:"providerId"`
+	ServiceName      string `json:"serviceName"`
+	NormalPrice      int    `json:"normalPrice"`
+	SalePrice        int    `json:"salePrice"`
+	Quota            *int   `json:"quota,omitempty"`
+	PerCustomerLimit *int   `json:"perCustomerLimit,omitempty"`
+	Sold             int    `json:"sold"`
+	Remaining        *int   `json:"remaining,omitempty"`
+}
+
+type SaleRequest struct {
+	Title   string        `json:"title" validate:"required"`
+	Banner  *string       `json:"banner,omitempty"`
+	StartAt time.Time     `json:"startAt" validate:"required"`
+	EndAt   time.Time     `json:"endAt" validate:"required"`
+	Items   []ItemRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+type ItemRequest struct {
+	ProviderID       string `json:"providerId" validate:"required"`
+	SalePrice        int    `json:"salePrice" validate:"required,gt=0"`
+	Quota            *int   `json:"quota,omitempty"`
+	PerCustomerLimit *int   `json:"perCustomerLimit,omitempty"`
+}
+
+// Validate checks the schedule and the items of a sale
+func (req *SaleRequest) Validate() error {
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalidSale)
+	}
+	if req.StartAt.IsZero() || req.EndAt.IsZero() {
+		return fmt.Errorf("%w: startAt and endAt are required", ErrInvalidSale)
+	}
+	if !req.EndAt.After(req.StartAt) {
+		return fmt.Errorf("%w: endAt must be after startAt", ErrInvalidSale)
+	}
+	if len(req.Items) == 0 {
+		return fmt.Errorf("%w: at least one item is required", ErrInvalidSale)
+	}
+
+	seen := map[string]bool{}
+	for i := range req.Items {
+		item := &req.Items[i]
+		item.ProviderID = strings.TrimSpace(item.ProviderID)
+		switch {
+		case item.ProviderID == "":
+			return fmt.Errorf("%w: item %d has no providerId", ErrInvalidSale, i)
+		case seen[item.ProviderID]:
+			return fmt.Errorf("%w: %s is listed twice", ErrInvalidSale, item.ProviderID)
+		case item.SalePrice <= 0:
+			return fmt.Errorf("%w: salePrice of %s must be positive", ErrInvalidSale, item.ProviderID)
+		case item.Quota != nil && *item.Quota <= 0:
+			return fmt.Errorf("%w: quota of %s must be positive", ErrInvalidSale, item.ProviderID)
+		case item.PerCustomerLimit != nil && *item.PerCustomerLimit <= 0:
+			return fmt.Errorf("%w: perCustomerLimit of %s must be positive", ErrInvalidSale, item.ProviderID)
+		}
+		seen[item.ProviderID] = true
+	}
+	return nil
+}
+
+// Claim is a checkout asking for the flash sale price of a product
+type Claim struct {
+	ProviderID  string
+	OrderID     string
+	CustomerKey string
+	// Price adalah harga normal untuk role customer; sale hanya dipakai jika lebih murah
+	Price int
+}
+
+// Reservation is the flash sale price taken by an order
+type Reservation struct {
+	ItemID      int
+	FlashSaleID int
+	Title       string
+	SalePrice   int
+}
+
+type FlashSaleRepository struct {
+	DB *sql.DB
+}
+
+func NewFlashSaleRepository(db *sql.DB) *FlashSaleRepository {
+	return &FlashSaleRepository{
+		DB: db,
+	}
+}
+
+func stateOf(status string, startAt, endAt, now time.Time) string {
+	switch {
+	case status == StatusCancelled:
+		return StateCancelled
+	case now.Before(startAt):
+		return StateUpcoming
+	case now.Before(endAt):
+		return StateLive
+	default:
+		return StateEnded
+	}
+}
+
+// List returns sales newest first; activeOnly keeps the live and upcoming ones
+func (repo *FlashSaleRepository) List(ctx context.Context, activeOnly bool) ([]FlashSale, error) {
+	query := `SELECT id, title, banner, start_at, end_at, status, created_at, updated_at FROM flash_sales`
+	if activeOnly {
+		query += ` WHERE status = 'active' AND end_at > NOW() ORDER BY start_at ASC, id ASC`
+	} else {
+		query += ` ORDER BY start_at DESC, id DESC`
+	}
+
+	rows, err := repo.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query flash sales: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	sales := []FlashSale{}
+	ids := []int64{}
+	for rows.Next() {
+		var sale FlashSale
+		if err := rows.Scan(
+			&sale.ID, &sale.Title, &sale.Banner, &sale.StartAt, &sale.EndAt,
+			&sale.Status, &sale.CreatedAt, &sale.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan flash sale: %w", err)
+		}
+		sale.State = stateOf(sale.Status, sale.StartAt, sale.EndAt, now)
+		sale.Items = []Item{}
+		sales = append(sales, sale)
+		ids = append(ids, int64(sale.ID))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	items, err := repo.items(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sales {
+		if list, ok := items[sales[i].ID]; ok {
+			sales[i].Items = list
+		}
+	}
+	return sales, nil
+}
+
+func (repo *FlashSaleRepository) Get(ctx context.Context, id int) (*FlashSale, error) {
+	var sale FlashSale
+	err := repo.DB.QueryRowContext(ctx, `
+		SELECT id, title, banner, start_at, end_at, status, created_at, updated_at
+		FROM flash_sales
+		WHERE id = $1
+	`, id).Scan(
+		&sale.ID, &sale.Title, &sale.Banner, &sale.StartAt, &sale.EndAt,
+		&sale.Status, &sale.CreatedAt, &sale.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrSaleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load flash sale: %w", err)
+	}
+	sale.State = stateOf(sale.Status, sale.StartAt, sale.EndAt, time.Now())
+
+	items, err := repo.items(ctx, []int64{int64(id)})
+	if err != nil {
+		return nil, err
+	}
+	sale.Items = items[id]
+	if sale.Items == nil {
+		sale.Items = []Item{}
+	}
+	return &sale, nil
+}
+
+// items loads the items of the sales, keyed by sale id
+func (repo *FlashSaleRepository) items(ctx context.Context, saleIDs []int64) (map[int][]Item, error) {
+	if len(saleIDs) == 0 {
+		return map[int][]Item{}, nil
+	}
+
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT i.flash_sale_id, i.id, i.provider_id, COALESCE(s.service_name, ''), COALESCE(s.price, 0),
+			i.sale_price, i.quota, i.per_customer_limit, i.sold
+		FROM flash_sale_items i
+		LEFT JOIN services s ON s.provider_id = i.provider_id
+		WHERE i.flash_sale_id = ANY($1)
+		ORDER BY i.sale_price ASC, i.id ASC
+	`, pq.Array(saleIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query flash sale items: %w", err)
+	}
+	defer rows.Close()
+
+	items := map[int][]Item{}
+	for rows.Next() {
+		var (
+			saleID int
+			item   Item
+		)
+		if err := rows.Scan(
+			&saleID, &item.ID, &item.ProviderID, &item.ServiceName, &item.NormalPrice,
+			&item.SalePrice, &item.Quota, &item.PerCustomerLimit, &item.Sold,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan flash sale item: %w", err)
+		}
+		if item.Quota != nil {
+			remaining := *item.Quota - item.Sold
+			if remaining < 0 {
+				remaining = 0
+			}
+			item.Remaining = &remaining
+		}
+		items[saleID] = append(items[saleID], item)
+	}
+	return items, rows.Err()
+}
+
+func (repo *FlashSaleRepository) Create(ctx context.Context, req SaleRequest) (*FlashSale, error) {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO flash_sales (title, banner, start_at, end_at) VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, req.Title, req.Banner, req.StartAt, req.EndAt).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create flash sale: %w", err)
+	}
+
+	if err := repo.saveItems(ctx, tx, id, req.Items); err != nil {
+		return nil, err
+	}
+	if err := repo.refresh(ctx, tx); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return repo.Get(ctx, id)
+}
+
+// Update changes the schedule and replaces the item list. Items that already have orders
+// cannot be removed; lower their quota instead.
+func (repo *FlashSaleRepository) Update(ctx context.Context, id int, req SaleRequest) (*FlashSale, error) {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE flash_sales SET title = $1, banner = $2, start_at = $3, end_at = $4, updated_at = NOW()
+		WHERE id = $5
+	`, req.Title, req.Banner, req.StartAt, req.EndAt, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update flash sale: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, ErrSaleNotFound
+	}
+
+	keep := make([]string, len(req.Items))
+	for i, item := range req.Items {
+		keep[i] = item.ProviderID
+	}
+
+	var sold string
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(string_agg(i.provider_id, ', '), '')
+		FROM flash_sale_items i
+		WHERE i.flash_sale_id = $1 AND NOT (i.provider_id = ANY($2))
+		  AND EXISTS (SELECT 1 FROM flash_sale_orders o WHERE o.item_id = i.id)
+	`, id, pq.Array(keep)).Scan(&sold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check flash sale items: %w", err)
+	}
+	if sold != "" {
+		return nil, fmt.Errorf("%w: %s already have orders and cannot be removed", ErrInvalidSale, sold)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM flash_sale_items WHERE flash_sale_id = $1 AND NOT (provider_id = ANY($2))
+	`, id, pq.Array(keep)); err != nil {
+		return nil, fmt.Errorf("failed to remove flash sale items: %w", err)
+	}
+
+	if err := repo.saveItems(ctx, tx, id, req.Items); err != nil {
+		return nil, err
+	}
+	if err := repo.refresh(ctx, tx); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return repo.Get(ctx, id)
+}
+
+func (repo *FlashSaleRepository) saveItems(ctx context.Context, tx *sql.Tx, saleID int, items []ItemRequest) error {
+	skus := make([]string, len(items))
+	for i, item := range items {
+		skus[i] = item.ProviderID
+	}
+
+	var missing string
+	err := tx.QueryRowContext(ctx, `
+		SELECT COALESCE(string_agg(sku, ', '), '')
+		FROM UNNEST($1::text[]) AS sku
+		WHERE NOT EXISTS (SELECT 1 FROM services s WHERE s.provider_id = sku)
+	`, pq.Array(skus)).Scan(&missing)
+	if err != nil {
+		return fmt.Errorf("failed to check flash sale products: %w", err)
+	}
+	if missing != "" {
+		return fmt.Errorf("%w: unknown products %s", ErrInvalidSale, missing)
+	}
+
+	for _, item := range items {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO flash_sale_items (flash_sale_id, provider_id, sale_price, quota, per_customer_limit)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (flash_sale_id, provider_id) DO UPDATE SET
+				sale_price = EXCLUDED.sale_price,
+				quota = EXCLUDED.quota,
+				per_customer_limit = EXCLUDED.per_customer_limit,
+				updated_at = NOW()
+		`, saleID, item.ProviderID, item.SalePrice, item.Quota, item.PerCustomerLimit)
+		if err != nil {
+			return fmt.Errorf("failed to save flash sale item %s: %w", item.ProviderID, err)
+		}
+	}
+	return nil
+}
+
+// Cancel stops a sale right away; order yang sudah dibuat tetap memakai harga sale
+func (repo *FlashSaleRepository) Cancel(ctx context.Context, id int) (*FlashSale, error) {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE flash_sales SET status = $1, updated_at = NOW() WHERE id = $2
+	`, StatusCancelled, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel flash sale: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, ErrSaleNotFound
+	}
+
+	if err := repo.refresh(ctx, tx); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return repo.Get(ctx, id)
+}
+
+// Reserve takes one unit of the cheapest live sale of the product inside the checkout
+// transaction. The item row is locked FOR UPDATE so concurrent checkouts cannot exceed the
+// total or per-customer quota. A nil reservation means no sale applies.
+func (repo *FlashSaleRepository) Reserve(ctx context.Context, tx *sql.Tx, claim Claim) (*Reservation, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT i.id, i.flash_sale_id, f.title, i.sale_price, i.per_customer_limit
+		FROM flash_sale_items i
+		JOIN flash_sales f ON f.id = i.flash_sale_id
+		WHERE i.provider_id = $1
+		  AND i.sale_price < $2
+		  AND f.status = 'active' AND f.start_at <= NOW() AND f.end_at > NOW()
+		  AND (i.quota IS NULL OR i.sold < i.quota)
+		ORDER BY i.sale_price ASC, i.id ASC
+		FOR UPDATE OF i
+	`, claim.ProviderID, claim.Price)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query flash sale: %w", err)
+	}
+
+	type candidate struct {
+		reservation Reservation
+		limit       *int
+	}
+	var candidates []candidate
+	for rows.Next() {
+		var c candidate
+		if err := rows.Scan(
+			&c.reservation.ItemID, &c.reservation.FlashSaleID, &c.reservation.Title,
+			&c.reservation.SalePrice, &c.limit,
+		); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan flash sale: %w", err)
+		}
+		candidates = append(candidates, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, c := range candidates {
+		if c.limit != nil {
+			var bought int
+			err := tx.QueryRowContext(ctx, `
+				SELECT COUNT(*) FROM flash_sale_orders
+				WHERE item_id = $1 AND customer_key = $2 AND NOT released
+			`, c.reservation.ItemID, claim.CustomerKey).Scan(&bought)
+			if err != nil {
+				return nil, fmt.Errorf("failed to count flash sale orders: %w", err)
+			}
+			// Batas per customer tercapai: coba sale lain, jika tidak ada pakai harga normal
+			if bought >= *c.limit {
+				continue
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE flash_sale_items SET sold = sold + 1, updated_at = NOW() WHERE id = $1
+		`, c.reservation.ItemID); err != nil {
+			return nil, fmt.Errorf("failed to update flash sale quota: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO flash_sale_orders (item_id, order_id, customer_key) VALUES ($1, $2, $3)
+		`, c.reservation.ItemID, claim.OrderID, claim.CustomerKey); err != nil {
+			return nil, fmt.Errorf("failed to record flash sale order: %w", err)
+		}
+
+		reservation := c.reservation
+		return &reservation, nil
+	}
+	return nil, nil
+}
+
+// Run returns the quota of failed orders and refreshes the flash sale columns of services
+func (repo *FlashSaleRepository) Run(ctx context.Context) error {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := repo.releaseFailed(ctx, tx); err != nil {
+		return err
+	}
+	if err := repo.refresh(ctx, tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// releaseFailed mengembalikan kuota dari order yang gagal, kedaluwarsa, dibatalkan atau direfund
+func (repo *FlashSaleRepository) releaseFailed(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `
+		WITH released AS (
+			UPDATE flash_sale_orders o SET released = TRUE
+			FROM transactions t
+			WHERE t.order_id = o.order_id AND NOT o.released AND t.status = ANY($1)
+			RETURNING o.item_id
+		)
+		UPDATE flash_sale_items i
+		SET sold = GREATEST(i.sold - r.n, 0), updated_at = NOW()
+		FROM (SELECT item_id, COUNT(*) AS n FROM released GROUP BY item_id) r
+		WHERE i.id = r.item_id
+	`, pq.Array([]string{types.StatusFailed, types.StatusExpired, types.StatusCancelled, types.StatusRefunded}))
+	if err != nil {
+		return fmt.Errorf("failed to release flash sale quota: %w", err)
+	}
+	return nil
+}
+
+// liveItems adalah item termurah per produk dari sale yang sedang berjalan dan masih ada kuota
+const liveItems = `
+	SELECT DISTINCT ON (i.provider_id) i.provider_id, i.sale_price, f.title, f.banner, f.end_at
+	FROM flash_sale_items i
+	JOIN flash_sales f ON f.id = i.flash_sale_id
+	WHERE f.status = 'active' AND f.start_at <= NOW() AND f.end_at > NOW()
+	  AND (i.quota IS NULL OR i.sold < i.quota)
+	ORDER BY i.provider_id, i.sale_price ASC, i.id ASC
+`
+
+// refresh copies the live sales to the flash sale columns of services shown in the catalog
+// and clears the flag on products whose sale has ended, was cancelled or ran out of quota
+func (repo *FlashSaleRepository) refresh(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `
+		WITH live AS (`+liveItems+`)
+		UPDATE services s
+		SET is_flash_sale = 'inactive', price_flash_sale = NULL, title_flash_sale = NULL,
+			banner_flash_sale = NULL, expired_flash_sale = NULL
+		WHERE s.is_flash_sale = 'active'
+		  AND NOT EXISTS (SELECT 1 FROM live WHERE live.provider_id = s.provider_id)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to clear ended flash sales: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		WITH live AS (`+liveItems+`)
+		UPDATE services s
+		SET is_flash_sale = 'active', price_flash_sale = live.sale_price, title_flash_sale = live.title,
+			banner_flash_sale = live.banner, expired_flash_sale = live.end_at
+		FROM live
+		WHERE s.provider_id = live.provider_id
+		  AND (s.is_flash_sale <> 'active'
+			OR s.price_flash_sale IS DISTINCT FROM live.sale_price
+			OR s.title_flash_sale IS DISTINCT FROM live.title
+			OR s.banner_flash_sale IS DISTINCT FROM live.banner
+			OR s.expired_flash_sale IS DISTINCT FROM live.end_at)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to publish live flash sales: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package flashsale
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wafi04/backendvazzz/pkg/testdb"
+)
+
+func TestStateOf(t *testing.T) {
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+
+	tests := []struct {
+		name   string
+		status string
+		now    time.Time
+		want   string
+	}{
+		{name: "before start", status: StatusActive, now: start.Add(-time.Minute), want: StateUpcoming},
+		{name: "at start", status: StatusActive, now: start, want: StateLive},
+		{name: "running", status: StatusActive, now: start.Add(time.Hour), want: StateLive},
+		{name: "at end", status: StatusActive, now: end, want: StateEnded},
+		{name: "cancelled while running", status: StatusCancelled, now: start.Add(time.Hour), want: StateCancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stateOf(tt.status, start, end, tt.now); got != tt.want {
+				t.Errorf("stateOf() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaleRequestValidate(t *testing.T) {
+	start := time.Now()
+	end := start.Add(time.Hour)
+	zero := 0
+
+	tests := []struct {
+		name    string
+		req     SaleRequest
+		wantErr bool
+	}{
+		{name: "valid", req: SaleRequest{Title: "Promo", StartAt: start, EndAt: end, Items: []ItemRequest{{ProviderID: "ML86", SalePrice: 15000}}}},
+		{name: "blank title", req: SaleRequest{Title: "  ", StartAt: start, EndAt: end, Items: []ItemRequest{{ProviderID: "ML86", SalePrice: 15000}}}, wantErr: true},
+		{name: "end before start", req: SaleRequest{Title: "Promo", StartAt: end, EndAt: start, Items: []ItemRequest{{ProviderID: "ML86", SalePrice: 15000}}}, wantErr: true},
+		{name: "no items", req: SaleRequest{Title: "Promo", StartAt: start, EndAt: end}, wantErr: true},
+		{name: "duplicate item", req: SaleRequest{Title: "Promo", StartAt: start, EndAt: end, Items: []ItemRequest{{ProviderID: "ML86", SalePrice: 15000}, {ProviderID: " ML86 ", SalePrice: 14000}}}, wantErr: true},
+		{name: "zero quota", req: SaleRequest{Title: "Promo", StartAt: start, EndAt: end, Items: []ItemRequest{{ProviderID: "ML86", SalePrice: 15000, Quota: &zero}}}, wantErr: true},
+		{name: "zero per customer limit", req: SaleRequest{Title: "Promo", StartAt: start, EndAt: end, Items: []ItemRequest{{ProviderID: "ML86", SalePrice: 15000, PerCustomerLimit: &zero}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.wantErr && !errors.Is(err, ErrInvalidSale) {
+				t.Fatalf("Validate() = %v, want ErrInvalidSale", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("Validate() = %v, want nil", err)
+			}
+		})
+	}
+}
+
+// createLiveSale inserts a running sale with one item and returns the item id
+func createLiveSale(t *testing.T, db *sql.DB, providerID string, quota, perCustomerLimit *int) int {
+	t.Helper()
+	var saleID, itemID int
+	err := db.QueryRow(`
+		INSERT INTO flash_sales (title, start_at, end_at)
+		VALUES ($1, NOW() - INTERVAL '1 hour', NOW() + INTERVAL '1 hour')
+		RETURNING id
+	`, "TEST "+providerID).Scan(&saleID)
+	if err != nil {
+		t.Fatalf("failed to create flash sale: %v", err)
+	}
+	err = db.QueryRow(`
+		INSERT INTO flash_sale_items (flash_sale_id, provider_id, sale_price, quota, per_customer_limit)
+		VALUES ($1, $2, 10000, $3, $4)
+		RETURNING id
+	`, saleID, providerID, quota, perCustomerLimit).Scan(&itemID)
+	if err != nil {
+		t.Fatalf("failed to create flash sale item: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM flash_sales WHERE id = $1`, saleID)
+	})
+	return itemID
+}
+
+func reserve(repo *FlashSaleRepository, claim Claim) (*Reservation, error) {
+	ctx := context.Background()
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	reservation, err := repo.Reserve(ctx, tx, claim)
+	if err != nil {
+		return nil, err
+	}
+	return reservation, tx.Commit()
+}
+
+func TestReserveConcurrentCheckoutsRespectQuota(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewFlashSaleRepository(db)
+
+	quota := 3
+	providerID := testdb.UniqueID("FS")
+	itemID := createLiveSale(t, db, providerID, &quota, nil)
+
+	const checkouts = 20
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		reserved int
+	)
+	for i := 0; i < checkouts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reservation, err := reserve(repo, Claim{
+				ProviderID:  providerID,
+				OrderID:     testdb.UniqueID("FSORDER"),
+				CustomerKey: testdb.UniqueID("game:"),
+				Price:       20000,
+			})
+			if err != nil {
+				t.Errorf("Reserve: %v", err)
+				return
+			}
+			if reservation != nil {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if reserved != quota {
+		t.Errorf("reserved %d units, want %d", reserved, quota)
+	}
+	var sold int
+	if err := db.QueryRow(`SELECT sold FROM flash_sale_items WHERE id = $1`, itemID).Scan(&sold); err != nil {
+		t.Fatal(err)
+	}
+	if sold != quota {
+		t.Errorf("sold = %d, want %d", sold, quota)
+	}
+}
+
+func TestReservePerCustomerLimit(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewFlashSaleRepository(db)
+
+	limit := 1
+	providerID := testdb.UniqueID("FS")
+	createLiveSale(t, db, providerID, nil, &limit)
+	customer := testdb.UniqueID("game:")
+
+	first, err := reserve(repo, Claim{ProviderID: providerID, OrderID: testdb.UniqueID("FSORDER"), CustomerKey: customer, Price: 20000})
+	if err != nil {
+		t.Fatalf("first Reserve: %v", err)
+	}
+	if first == nil || first.SalePrice != 10000 {
+		t.Fatalf("first reservation = %+v, want sale price 10000", first)
+	}
+
+	second, err := reserve(repo, Claim{ProviderID: providerID, OrderID: testdb.UniqueID("FSORDER"), CustomerKey: customer, Price: 20000})
+	if err != nil {
+		t.Fatalf("second Reserve: %v", err)
+	}
+	if second != nil {
+		t.Errorf("second reservation for the same customer = %+v, want nil", second)
+	}
+
+	other, err := reserve(repo, Claim{ProviderID: providerID, OrderID: testdb.UniqueID("FSORDER"), CustomerKey: testdb.UniqueID("game:"), Price: 20000})
+	if err != nil {
+		t.Fatalf("other customer Reserve: %v", err)
+	}
+	if other == nil {
+		t.Error("another customer did not get the sale price")
+	}
+}
+
+func TestReserveIgnoresSaleNotCheaperThanPrice(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewFlashSaleRepository(db)
+
+	providerID := testdb.UniqueID("FS")
+	createLiveSale(t, db, providerID, nil, nil)
+
+	reservation, err := reserve(repo, Claim{ProviderID: providerID, OrderID: testdb.UniqueID("FSORDER"), CustomerKey: "game:1", Price: 9000})
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if reservation != nil {
+		t.Errorf("reservation = %+v, want nil when the normal price is cheaper", reservation)
+	}
+}
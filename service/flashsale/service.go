@@ -0,0 +1,44 @@
+package flashsale
+
+import "context"
+
+type FlashSaleService struct {
+	repo *FlashSaleRepository
+}
+
+func NewFlashSaleService(repo *FlashSaleRepository) *FlashSaleService {
+	return &FlashSaleService{
+		repo: repo,
+	}
+}
+
+func (s *FlashSaleService) List(ctx context.Context) ([]FlashSale, error) {
+	return s.repo.List(ctx, false)
+}
+
+// Current returns the live and upcoming sales for the storefront
+func (s *FlashSaleService) Current(ctx context.Context) ([]FlashSale, error) {
+	return s.repo.List(ctx, true)
+}
+
+func (s *FlashSaleService) Get(ctx context.Context, id int) (*FlashSale, error) {
+	return s.repo.Get(ctx, id)
+}
+
+func (s *FlashSaleService) Create(ctx context.Context, req SaleRequest) (*FlashSale, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return s.repo.Create(ctx, req)
+}
+
+func (s *FlashSaleService) Update(ctx context.Context, id int, req SaleRequest) (*FlashSale, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return s.repo.Update(ctx, id, req)
+}
+
+func (s *FlashSaleService) Cancel(ctx context.Context, id int) (*FlashSale, error) {
+	return s.repo.Cancel(ctx, id)
+}
@@ -0,0 +1,411 @@
+package fulfillment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wafi04/backendvazzz/pkg/config"
+	"github.com/wafi04/backendvazzz/pkg/supplier"
+	"github.com/wafi04/backendvazzz/pkg/types"
+	"github.com/wafi04/backendvazzz/service/providerbalance"
+)
+
+var (
+	// ErrNoRouteLeft is returned by Fulfill when every route was already tried
+	ErrNoRouteLeft = errors.New("no supplier route left")
+	// ErrAttemptInProgress is returned by Fulfill while an earlier attempt has not failed
+	ErrAttemptInProgress = errors.New("fulfillment attempt still in progress")
+	// ErrNotFulfillable is returned by Fulfill for orders that are not PAID or PROCESS
+	ErrNotFulfillable = errors.New("order is not waiting for fulfillment")
+)
+
+// Status fulfillment_attempts selain status supplier
+const (
+	// AttemptSent dicatat sebelum request dikirim ke supplier
+	AttemptSent = "SENT"
+	// AttemptError dicatat jika supplier tidak menjawab order maupun cek status
+	AttemptError = "ERROR"
+)
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Route is one supplier SKU able to fulfill a product
+type Route struct {
+	Supplier string
+	SKU      string
+	Price    int
+	InStock  bool
+	Priority int
+}
+
+// Attempt is one order sent to a supplier. SupplierRef is the ref_id the supplier
+// sees: the order id for the first attempt, "<order id>-<n>" afterwards.
+type Attempt struct {
+	OrderID     string
+	Attempt     int
+	Supplier    string
+	SKU         string
+	SupplierRef string
+	Status      string
+}
+
+// Outcome describes the last attempt made by Fulfill
+type Outcome struct {
+	Attempt  Attempt
+	Supplier supplier.Supplier
+	// Result is nil when the supplier did not answer the order nor the status check, Err holds the reason
+	Result *supplier.OrderResult
+	Err    error
+	// FellBack is true when the order is no longer on its first attempt
+	FellBack bool
+}
+
+type FulfillmentRepository struct {
+	DB        *sql.DB
+	suppliers *supplier.Registry
+	balances  *providerbalance.ProviderBalanceRepository
+	config    config.FulfillmentConfig
+}
+
+func NewFulfillmentRepository(db *sql.DB, suppliers *supplier.Registry, balances *providerbalance.ProviderBalanceRepository, cfg config.FulfillmentConfig) *FulfillmentRepository {
+	return &FulfillmentRepository{
+		DB:        db,
+		suppliers: suppliers,
+		balances:  balances,
+		config:    cfg,
+	}
+}
+
+func SupplierRef(orderID string, attempt int) string {
+	if attempt <= 1 {
+		return orderID
+	}
+	return fmt.Sprintf("%s-%d", orderID, attempt)
+}
+
+// Routes returns the candidates for a product: the primary from services,
+// then the in-stock alternates by priority (or all of them by price when
+// FULFILLMENT_CHEAPEST_FIRST is set).
+func (repo *FulfillmentRepository) Routes(ctx context.Context, q querier, productCode string) ([]Route, error) {
+	primary := Route{InStock: true}
+	err := q.QueryRowContext(ctx, `
+		SELECT COALESCE(provider, ''), provider_id, price_purchase
+		FROM services
+		WHERE provider_id = $1
+	`, productCode).Scan(&primary.Supplier, &primary.SKU, &primary.Price)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("product %s not found", productCode)
+		}
+		return nil, fmt.Errorf("failed to load product %s: %w", productCode, err)
+	}
+	if primary.Supplier == "" {
+		primary.Supplier = supplier.DefaultSupplier
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT supplier, sku, price, in_stock, priority
+		FROM service_supplier_routes
+		WHERE service_provider_id = $1 AND status = 'active'
+		ORDER BY priority ASC, id ASC
+	`, productCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load supplier routes for %s: %w", productCode, err)
+	}
+	defer rows.Close()
+
+	routes := []Route{primary}
+	for rows.Next() {
+		var r Route
+		if err := rows.Scan(&r.Supplier, &r.SKU, &r.Price, &r.InStock, &r.Priority); err != nil {
+			return nil, err
+		}
+		if !r.InStock {
+			continue
+		}
+		routes = append(routes, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if repo.config.CheapestFirst {
+		sortByPrice(routes)
+	}
+
+	return routes, nil
+}
+
+// sortByPrice orders routes cheapest first; routes with the same price keep their priority order
+func sortByPrice(routes []Route) {
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].Price < routes[j].Price
+	})
+}
+
+// Attempts returns every attempt recorded for an order, oldest first
+func (repo *FulfillmentRepository) Attempts(ctx context.Context, q querier, orderID string) ([]Attempt, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT order_id, attempt, supplier, sku, supplier_ref, status
+		FROM fulfillment_attempts
+		WHERE order_id = $1
+		ORDER BY attempt ASC
+	`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fulfillment attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []Attempt
+	for rows.Next() {
+		var a Attempt
+		if err := rows.Scan(&a.OrderID, &a.Attempt, &a.Supplier, &a.SKU, &a.SupplierRef, &a.Status); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
+
+// Resolve maps the ref_id a supplier sent back to its attempt.
+// Returns nil for orders placed before attempts were recorded.
+func (repo *FulfillmentRepository) Resolve(ctx context.Context, q querier, supplierRef string) (*Attempt, error) {
+	var a Attempt
+	err := q.QueryRowContext(ctx, `
+		SELECT order_id, attempt, supplier, sku, supplier_ref, status
+		FROM fulfillment_attempts
+		WHERE supplier_ref = $1
+	`, supplierRef).Scan(&a.OrderID, &a.Attempt, &a.Supplier, &a.SKU, &a.SupplierRef, &a.Status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to resolve supplier ref %s: %w", supplierRef, err)
+	}
+	return &a, nil
+}
+
+// MarkAttempt stores the latest status a supplier reported for an attempt
+func (repo *FulfillmentRepository) MarkAttempt(ctx context.Context, tx *sql.Tx, supplierRef, status, message string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE fulfillment_attempts
+		SET status = $1, message = $2, updated_at = NOW()
+		WHERE supplier_ref = $3
+	`, status, message, supplierRef)
+	if err != nil {
+		return fmt.Errorf("failed to update fulfillment attempt %s: %w", supplierRef, err)
+	}
+	return nil
+}
+
+// Fulfill sends the order to the routes not tried yet and stops at the first
+// SUCCESS or PENDING. Only an explicit FAILED moves on to the next route: when a
+// supplier does not answer, it is asked for the status of the same ref_id, and if
+// that fails too the order is left for the status-check poller instead of being
+// bought twice. Every attempt is recorded and committed before the supplier is
+// called, so call Fulfill after committing the transaction that paid the order.
+// When every route fails the outcome of the last attempt is returned.
+func (repo *FulfillmentRepository) Fulfill(ctx context.Context, orderID, productCode, customerNo string) (*Outcome, error) {
+	var last *Outcome
+	for {
+		outcome, err := repo.next(ctx, orderID, productCode)
+		if err != nil {
+			if errors.Is(err, ErrNoRouteLeft) && last != nil {
+				return last, nil
+			}
+			return nil, err
+		}
+
+		repo.send(ctx, outcome, customerNo)
+		if outcome.Result == nil || outcome.Result.Status != supplier.StatusFailed {
+			return outcome, nil
+		}
+		log.Printf("Order %s attempt %d on %s returned %s: %s", orderID, outcome.Attempt.Attempt, outcome.Supplier.Name(), outcome.Result.RawStatus, outcome.Result.Message)
+		last = outcome
+	}
+}
+
+// next records the first untried route as a SENT attempt and points the order at it.
+// The order row is locked only for this short transaction.
+func (repo *FulfillmentRepository) next(ctx context.Context, orderID, productCode string) (*Outcome, error) {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRowContext(ctx, `SELECT status FROM transactions WHERE order_id = $1 FOR UPDATE`, orderID).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("order %s not found", orderID)
+		}
+		return nil, fmt.Errorf("failed to lock order %s: %w", orderID, err)
+	}
+	if status != types.StatusPaid && status != types.StatusProcess {
+		return nil, fmt.Errorf("%w: order %s is %s", ErrNotFulfillable, orderID, status)
+	}
+
+	routes, err := repo.Routes(ctx, tx, productCode)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := repo.Attempts(ctx, tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	tried := make(map[string]bool, len(previous))
+	for _, a := range previous {
+		// Percobaan yang belum pasti gagal bisa saja sudah diproses supplier
+		if a.Status != supplier.StatusFailed {
+			return nil, fmt.Errorf("%w: order %s attempt %d is %s", ErrAttemptInProgress, orderID, a.Attempt, a.Status)
+		}
+		tried[routeKey(a.Supplier, a.SKU)] = true
+	}
+
+	attempt := len(previous) + 1
+	for _, route := range routes {
+		if tried[routeKey(route.Supplier, route.SKU)] {
+			continue
+		}
+
+		s, err := repo.suppliers.Get(route.Supplier)
+		if err != nil {
+			log.Printf("Skip route %s/%s for order %s: %v", route.Supplier, route.SKU, orderID, err)
+			continue
+		}
+
+		outcome := &Outcome{
+			Supplier: s,
+			Attempt: Attempt{
+				OrderID:     orderID,
+				Attempt:     attempt,
+				Supplier:    s.Name(),
+				SKU:         route.SKU,
+				SupplierRef: SupplierRef(orderID, attempt),
+				Status:      AttemptSent,
+			},
+			FellBack: attempt > 1,
+		}
+		if err := repo.record(ctx, tx, outcome); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit attempt %d for order %s: %w", attempt, orderID, err)
+		}
+		return outcome, nil
+	}
+
+	return nil, fmt.Errorf("%w for order %s", ErrNoRouteLeft, orderID)
+}
+
+// send places a recorded attempt and stores the answer. When the order request
+// errors, the supplier is asked for the status of the same ref_id before giving up.
+func (repo *FulfillmentRepository) send(ctx context.Context, outcome *Outcome, customerNo string) {
+	s := outcome.Supplier
+	req := supplier.OrderRequest{
+		RefID:      outcome.Attempt.SupplierRef,
+		SKU:        outcome.Attempt.SKU,
+		CustomerNo: customerNo,
+	}
+
+	outcome.Result, outcome.Err = repo.place(ctx, s, req)
+
+	var message string
+	var price int
+	if outcome.Err != nil {
+		outcome.Attempt.Status = AttemptError
+		message = outcome.Err.Error()
+	} else {
+		repo.balances.Observe(ctx, s.Name(), outcome.Result, providerbalance.SourceOrder)
+		if outcome.Result.RefID == "" {
+			outcome.Result.RefID = req.RefID
+		}
+		outcome.Attempt.Status = outcome.Result.Status
+		message = outcome.Result.Message
+		price = outcome.Result.Price
+	}
+
+	_, err := repo.DB.ExecContext(ctx, `
+		UPDATE fulfillment_attempts
+		SET status = $1, message = $2, price = $3, updated_at = NOW()
+		WHERE supplier_ref = $4
+	`, outcome.Attempt.Status, message, price, req.RefID)
+	if err != nil {
+		log.Printf("Failed to store answer of %s for attempt %s: %v", s.Name(), req.RefID, err)
+	}
+}
+
+// place orders once and, when the order request errors, asks for the status of the same
+// ref_id instead of ordering again
+func (repo *FulfillmentRepository) place(ctx context.Context, s supplier.Supplier, req supplier.OrderRequest) (*supplier.OrderResult, error) {
+	result, err := repo.call(ctx, s.Order, req)
+	if err == nil {
+		return result, nil
+	}
+	log.Printf("Order %s on %s failed, checking status: %v", req.RefID, s.Name(), err)
+
+	if result, statusErr := repo.call(ctx, s.Status, req); statusErr == nil {
+		return result, nil
+	}
+	return nil, err
+}
+
+// call runs one supplier request within the attempt timeout. An answer without a
+// known status is treated like no answer.
+func (repo *FulfillmentRepository) call(ctx context.Context, request func(context.Context, supplier.OrderRequest) (*supplier.OrderResult, error), req supplier.OrderRequest) (*supplier.OrderResult, error) {
+	callCtx, cancel := context.WithTimeout(ctx, time.Duration(repo.config.AttemptTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	result, err := request(callCtx, req)
+	if err != nil {
+		return nil, err
+	}
+	if result.Status == "" {
+		return nil, fmt.Errorf("unknown status %q for %s", result.RawStatus, req.RefID)
+	}
+	return result, nil
+}
+
+func (repo *FulfillmentRepository) record(ctx context.Context, tx *sql.Tx, outcome *Outcome) error {
+	a := outcome.Attempt
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO fulfillment_attempts (order_id, attempt, supplier, sku, supplier_ref, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, a.OrderID, a.Attempt, a.Supplier, a.SKU, a.SupplierRef, a.Status)
+	if err != nil {
+		return fmt.Errorf("failed to record fulfillment attempt for order %s: %w", a.OrderID, err)
+	}
+
+	isReOrder := "inactive"
+	if outcome.FellBack {
+		isReOrder = "active"
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE transactions
+		SET supplier = $1, ref_id = $2, is_re_order = $3,
+			status_check_attempts = 0, next_status_check_at = NULL, updated_at = NOW()
+		WHERE order_id = $4
+	`, a.Supplier, a.SupplierRef, isReOrder, a.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to point order %s at attempt %d: %w", a.OrderID, a.Attempt, err)
+	}
+	return nil
+}
+
+func routeKey(supplierName, sku string) string {
+	return strings.ToLower(supplierName) + "/" + strings.ToLower(sku)
+}
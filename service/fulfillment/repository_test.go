@@ -0,0 +1,115 @@
+package fulfillment
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/wafi04/backendvazzz/pkg/config"
+	"github.com/wafi04/backendvazzz/pkg/supplier"
+)
+
+func TestSupplierRef(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    string
+	}{
+		{attempt: 0, want: "VAZZ123"},
+		{attempt: 1, want: "VAZZ123"},
+		{attempt: 2, want: "VAZZ123-2"},
+		{attempt: 3, want: "VAZZ123-3"},
+	}
+	for _, tt := range tests {
+		if got := SupplierRef("VAZZ123", tt.attempt); got != tt.want {
+			t.Errorf("SupplierRef(VAZZ123, %d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestSortByPrice(t *testing.T) {
+	routes := []Route{
+		{Supplier: "digiflazz", SKU: "ML86", Price: 20000},
+		{Supplier: "h2h-a", SKU: "ML86A", Price: 19000, Priority: 1},
+		{Supplier: "h2h-b", SKU: "ML86B", Price: 21000, Priority: 2},
+		{Supplier: "h2h-c", SKU: "ML86C", Price: 19000, Priority: 3},
+	}
+	sortByPrice(routes)
+
+	var got []string
+	for _, r := range routes {
+		got = append(got, r.Supplier)
+	}
+	want := []string{"h2h-a", "h2h-c", "digiflazz", "h2h-b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestRouteKeyIgnoresCase(t *testing.T) {
+	if routeKey("Digiflazz", "ml86") != routeKey("digiflazz", "ML86") {
+		t.Error("routeKey should not depend on case")
+	}
+	if routeKey("digiflazz", "ML86") == routeKey("h2h", "ML86") {
+		t.Error("routeKey should differ per supplier")
+	}
+}
+
+// stubSupplier answers Order and Status from fixed results and counts the calls
+type stubSupplier struct {
+	supplier.Supplier
+	order, status       *supplier.OrderResult
+	orderErr, statusErr error
+	orders, statuses    int
+}
+
+func (s *stubSupplier) Name() string { return "stub" }
+
+func (s *stubSupplier) Order(ctx context.Context, req supplier.OrderRequest) (*supplier.OrderResult, error) {
+	s.orders++
+	return s.order, s.orderErr
+}
+
+func (s *stubSupplier) Status(ctx context.Context, req supplier.OrderRequest) (*supplier.OrderResult, error) {
+	s.statuses++
+	return s.status, s.statusErr
+}
+
+func TestPlaceChecksStatusInsteadOfOrderingTwice(t *testing.T) {
+	timeout := errors.New("timeout")
+	pending := &supplier.OrderResult{Status: supplier.StatusPending}
+	success := &supplier.OrderResult{Status: supplier.StatusSuccess}
+
+	tests := []struct {
+		name         string
+		stub         *stubSupplier
+		wantStatus   string
+		wantErr      bool
+		wantStatuses int
+	}{
+		{name: "answered", stub: &stubSupplier{order: pending}, wantStatus: supplier.StatusPending},
+		{name: "timeout then accepted", stub: &stubSupplier{orderErr: timeout, status: success}, wantStatus: supplier.StatusSuccess, wantStatuses: 1},
+		{name: "timeout and status unknown", stub: &stubSupplier{orderErr: timeout, statusErr: timeout}, wantErr: true, wantStatuses: 1},
+		{name: "unknown answer", stub: &stubSupplier{order: &supplier.OrderResult{RawStatus: "?"}, status: pending}, wantStatus: supplier.StatusPending, wantStatuses: 1},
+	}
+
+	repo := &FulfillmentRepository{config: config.FulfillmentConfig{AttemptTimeoutSeconds: 1}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := repo.place(context.Background(), tt.stub, supplier.OrderRequest{RefID: "VAZZ1"})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("place() = %+v, want error", result)
+				}
+			} else if err != nil || result.Status != tt.wantStatus {
+				t.Fatalf("place() = (%+v, %v), want status %s", result, err, tt.wantStatus)
+			}
+			if tt.stub.orders != 1 {
+				t.Errorf("Order called %d times, want 1", tt.stub.orders)
+			}
+			if tt.stub.statuses != tt.wantStatuses {
+				t.Errorf("Status called %d times, want %d", tt.stub.statuses, tt.wantStatuses)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package markup
+
+import "strings"
+
+// Mode markup: persen dari harga beli atau rupiah tetap
+const (
+	ModePercent = "percent"
+	ModeFixed   = "fixed"
+)
+
+// Config is the markup applied to a purchase price for every price tier
+type Config struct {
+	Mode           string `json:"mode"`
+	Profit         int    `json:"profit"`
+	ProfitReseller int    `json:"profitReseller"`
+	ProfitPlatinum int    `json:"profitPlatinum"`
+	// MinMargin adalah margin minimal dalam rupiah untuk setiap tier
+	MinMargin int `json:"minMargin"`
+	// Rounding membulatkan harga jual ke atas ke kelipatan ini, misal 100
+	Rounding int `json:"rounding"`
+}
+
+// Prices are the selling prices computed from a purchase price
+type Prices struct {
+	Purchase int
+	Price    int
+	Reseller int
+	Platinum int
+}
+
+// Apply returns the selling prices for the purchase price
+func (c Config) Apply(purchase int) Prices {
+	return Prices{
+		Purchase: purchase,
+		Price:    c.price(purchase, c.Profit),
+		Reseller: c.price(purchase, c.ProfitReseller),
+		Platinum: c.price(purchase, c.ProfitPlatinum),
+	}
+}
+
+func (c Config) price(purchase, profit int) int {
+	margin := profit
+	if c.Mode != ModeFixed {
+		margin = (purchase * profit) / 100
+	}
+	if margin < c.MinMargin {
+		margin = c.MinMargin
+	}
+
+	price := purchase + margin
+	if c.Rounding > 1 && price%c.Rounding != 0 {
+		price += c.Rounding - price%c.Rounding
+	}
+	return price
+}
+
+// Subject is the product a rule is matched against
+type Subject struct {
+	CategoryID    int
+	SubCategoryID int
+	Brand         string
+	SKU           string
+	PurchasePrice int
+}
+
+// Matches reports whether every scope set on the rule fits the product
+func (r *Rule) Matches(s Subject) bool {
+	if r.CategoryID != nil && *r.CategoryID != s.CategoryID {
+		return false
+	}
+	if r.SubCategoryID != nil && *r.SubCategoryID != s.SubCategoryID {
+		return false
+	}
+	if r.Brand != nil && !strings.EqualFold(strings.TrimSpace(*r.Brand), strings.TrimSpace(s.Brand)) {
+		return false
+	}
+	if r.SKU != nil && !strings.EqualFold(strings.TrimSpace(*r.SKU), strings.TrimSpace(s.SKU)) {
+		return false
+	}
+	if r.MinPrice != nil && s.PurchasePrice < *r.MinPrice {
+		return false
+	}
+	if r.MaxPrice != nil && s.PurchasePrice > *r.MaxPrice {
+		return false
+	}
+	return true
+}
+
+// Select returns the first rule matching the product; rules must be in priority order
+func Select(rules []Rule, s Subject) *Rule {
+	for i := range rules {
+		if rules[i].Matches(s) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
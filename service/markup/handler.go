@@ -0,0 +1,94 @@
+package markup
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wafi04/backendvazzz/pkg/utils"
+)
+
+type MarkupHandler struct {
+	service *MarkupService
+}
+
+func NewMarkupHandler(service *MarkupService) *MarkupHandler {
+	return &MarkupHandler{
+		service: service,
+	}
+}
+
+func (h *MarkupHandler) List(c *gin.Context) {
+	rules, err := h.service.List(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch markup rules", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Markup rules retrieved successfully", rules)
+}
+
+func (h *MarkupHandler) Create(c *gin.Context) {
+	var req RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	rule, err := h.service.Create(c.Request.Context(), req)
+	if err != nil {
+		h.writeError(c, "Failed to create markup rule", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Markup rule created successfully", rule)
+}
+
+func (h *MarkupHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid rule id", err.Error())
+		return
+	}
+
+	var req RuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	rule, err := h.service.Update(c.Request.Context(), id, req)
+	if err != nil {
+		h.writeError(c, "Failed to update markup rule", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Markup rule updated successfully", rule)
+}
+
+func (h *MarkupHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid rule id", err.Error())
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		h.writeError(c, "Failed to delete markup rule", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Markup rule deleted successfully", nil)
+}
+
+func (h *MarkupHandler) writeError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidRule):
+		utils.ErrorResponse(c, http.StatusBadRequest, message, err.Error())
+	case errors.Is(err, ErrRuleNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, message, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, message, err.Error())
+	}
+}
@@ -0,0 +1,216 @@
+package markup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrRuleNotFound = errors.New("markup rule not found")
+	ErrInvalidRule  = errors.New("invalid markup rule")
+)
+
+// rulesCacheTTL membatasi query aturan saat sync memproses ribuan produk
+const rulesCacheTTL = 30 * time.Second
+
+type Rule struct {
+	ID            int       `json:"id"`
+	Name          string    `json:"name"`
+	Priority      int       `json:"priority"`
+	CategoryID    *int      `json:"categoryId,omitempty"`
+	SubCategoryID *int      `json:"subCategoryId,omitempty"`
+	Brand         *string   `json:"brand,omitempty"`
+	SKU           *string   `json:"sku,omitempty"`
+	MinPrice      *int      `json:"minPrice,omitempty"`
+	MaxPrice      *int      `json:"maxPrice,omitempty"`
+	Config        Config    `json:"config"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+type RuleRequest struct {
+	Name          string  `json:"name" validate:"required"`
+	Priority      int     `json:"priority"`
+	CategoryID    *int    `json:"categoryId,omitempty"`
+	SubCategoryID *int    `json:"subCategoryId,omitempty"`
+	Brand         *string `json:"brand,omitempty"`
+	SKU           *string `json:"sku,omitempty"`
+	MinPrice      *int    `json:"minPrice,omitempty"`
+	MaxPrice      *int    `json:"maxPrice,omitempty"`
+	Config        Config  `json:"config" validate:"required"`
+	Status        string  `json:"status" validate:"omitempty,oneof=active inactive"`
+}
+
+// Validate checks the request and fills in defaults
+func (req *RuleRequest) Validate() error {
+	if strings.TrimSpace(req.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRule)
+	}
+	if req.Config.Mode != ModePercent && req.Config.Mode != ModeFixed {
+		return fmt.Errorf("%w: mode must be %q or %q", ErrInvalidRule, ModePercent, ModeFixed)
+	}
+	if req.Config.Profit < 0 || req.Config.ProfitReseller < 0 || req.Config.ProfitPlatinum < 0 {
+		return fmt.Errorf("%w: profit cannot be negative", ErrInvalidRule)
+	}
+	if req.Config.MinMargin < 0 || req.Config.Rounding < 0 {
+		return fmt.Errorf("%w: minMargin and rounding cannot be negative", ErrInvalidRule)
+	}
+	if req.MinPrice != nil && req.MaxPrice != nil && *req.MinPrice > *req.MaxPrice {
+		return fmt.Errorf("%w: minPrice is greater than maxPrice", ErrInvalidRule)
+	}
+	if req.Status == "" {
+		req.Status = "active"
+	}
+	if req.Priority == 0 {
+		req.Priority = 100
+	}
+	return nil
+}
+
+type MarkupRepository struct {
+	DB *sql.DB
+}
+
+func NewMarkupRepository(db *sql.DB) *MarkupRepository {
+	return &MarkupRepository{
+		DB: db,
+	}
+}
+
+// Cache aturan aktif dibagi semua instance repository di proses ini
+var (
+	cacheMu       sync.Mutex
+	cachedRules   []Rule
+	cacheLoadedAt time.Time
+)
+
+func invalidateCache() {
+	cacheMu.Lock()
+	cachedRules = nil
+	cacheMu.Unlock()
+}
+
+// ActiveRules returns the active rules in priority order, cached for a short time
+func (repo *MarkupRepository) ActiveRules(ctx context.Context) ([]Rule, error) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	if cachedRules != nil && time.Since(cacheLoadedAt) < rulesCacheTTL {
+		return cachedRules, nil
+	}
+
+	rules, err := repo.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	cachedRules, cacheLoadedAt = rules, time.Now()
+	return rules, nil
+}
+
+// Match returns the first active rule for the product, or nil
+func (repo *MarkupRepository) Match(ctx context.Context, s Subject) (*Rule, error) {
+	rules, err := repo.ActiveRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return Select(rules, s), nil
+}
+
+const ruleColumns = `id, name, priority, category_id, sub_category_id, brand, sku, min_price, max_price,
+	mode, profit, profit_reseller, profit_platinum, min_margin, rounding, status, created_at, updated_at`
+
+func scanRule(row interface{ Scan(...interface{}) error }) (*Rule, error) {
+	var rule Rule
+	err := row.Scan(
+		&rule.ID, &rule.Name, &rule.Priority, &rule.CategoryID, &rule.SubCategoryID, &rule.Brand, &rule.SKU,
+		&rule.MinPrice, &rule.MaxPrice, &rule.Config.Mode, &rule.Config.Profit, &rule.Config.ProfitReseller,
+		&rule.Config.ProfitPlatinum, &rule.Config.MinMargin, &rule.Config.Rounding, &rule.Status,
+		&rule.CreatedAt, &rule.UpdatedAt,
+	)
+	return &rule, err
+}
+
+func (repo *MarkupRepository) List(ctx context.Context, activeOnly bool) ([]Rule, error) {
+	query := `SELECT ` + ruleColumns + ` FROM markup_rules`
+	if activeOnly {
+		query += ` WHERE status = 'active'`
+	}
+	query += ` ORDER BY priority ASC, id ASC`
+
+	rows, err := repo.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query markup rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []Rule{}
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan markup rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
+
+func (repo *MarkupRepository) Create(ctx context.Context, req RuleRequest) (*Rule, error) {
+	rule, err := scanRule(repo.DB.QueryRowContext(ctx, `
+		INSERT INTO markup_rules (
+			name, priority, category_id, sub_category_id, brand, sku, min_price, max_price,
+			mode, profit, profit_reseller, profit_platinum, min_margin, rounding, status
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING `+ruleColumns,
+		req.Name, req.Priority, req.CategoryID, req.SubCategoryID, req.Brand, req.SKU, req.MinPrice, req.MaxPrice,
+		req.Config.Mode, req.Config.Profit, req.Config.ProfitReseller, req.Config.ProfitPlatinum,
+		req.Config.MinMargin, req.Config.Rounding, req.Status,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create markup rule: %w", err)
+	}
+
+	invalidateCache()
+	return rule, nil
+}
+
+func (repo *MarkupRepository) Update(ctx context.Context, id int, req RuleRequest) (*Rule, error) {
+	rule, err := scanRule(repo.DB.QueryRowContext(ctx, `
+		UPDATE markup_rules
+		SET name = $1, priority = $2, category_id = $3, sub_category_id = $4, brand = $5, sku = $6,
+			min_price = $7, max_price = $8, mode = $9, profit = $10, profit_reseller = $11,
+			profit_platinum = $12, min_margin = $13, rounding = $14, status = $15, updated_at = NOW()
+		WHERE id = $16
+		RETURNING `+ruleColumns,
+		req.Name, req.Priority, req.CategoryID, req.SubCategoryID, req.Brand, req.SKU, req.MinPrice, req.MaxPrice,
+		req.Config.Mode, req.Config.Profit, req.Config.ProfitReseller, req.Config.ProfitPlatinum,
+		req.Config.MinMargin, req.Config.Rounding, req.Status, id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update markup rule: %w", err)
+	}
+
+	invalidateCache()
+	return rule, nil
+}
+
+func (repo *MarkupRepository) Delete(ctx context.Context, id int) error {
+	result, err := repo.DB.ExecContext(ctx, `DELETE FROM markup_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete markup rule: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrRuleNotFound
+	}
+
+	invalidateCache()
+	return nil
+}
@@ -0,0 +1,35 @@
+package markup
+
+import "context"
+
+type MarkupService struct {
+	repo *MarkupRepository
+}
+
+func NewMarkupService(repo *MarkupRepository) *MarkupService {
+	return &MarkupService{
+		repo: repo,
+	}
+}
+
+func (s *MarkupService) List(ctx context.Context) ([]Rule, error) {
+	return s.repo.List(ctx, false)
+}
+
+func (s *MarkupService) Create(ctx context.Context, req RuleRequest) (*Rule, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return s.repo.Create(ctx, req)
+}
+
+func (s *MarkupService) Update(ctx context.Context, id int, req RuleRequest) (*Rule, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return s.repo.Update(ctx, id, req)
+}
+
+func (s *MarkupService) Delete(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
+}
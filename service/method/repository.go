@@ -29,13 +29,12 @@ func (repo *MethodRepository) Create(ctx context.Context, req *types.CreateMetho
 	query := `
 		INSERT INTO payment_methods (
 			code, name, description, type, min_amount, max_amount, 
-			fee, fee_type, status, image, created_at, updated_at
+			fee, fee_type, status, image, expiry_period, created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW()
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW(), NOW()
 		) RETURNING id, created_at, updated_at`
 
 	var method types.MethodData
-	now := time.Now()
 
 	err := repo.DB.QueryRowContext(ctx, query,
 		req.Code,
@@ -48,8 +47,7 @@ func (repo *MethodRepository) Create(ctx context.Context, req *types.CreateMetho
 		req.FeeType,
 		req.Status,
 		req.Image, // Added image field
-		now,
-		now,
+		req.ExpiryPeriod,
 	).Scan(&method.Id, &method.CreatedAt, &method.UpdatedAt)
 
 	if err != nil {
@@ -67,6 +65,7 @@ func (repo *MethodRepository) Create(ctx context.Context, req *types.CreateMetho
 	method.FeeType = req.FeeType
 	method.Status = req.Status
 	method.Image = req.Image // Added image field
+	method.ExpiryPeriod = req.ExpiryPeriod
 
 	return &method, nil
 }
@@ -75,7 +74,7 @@ func (repo *MethodRepository) Create(ctx context.Context, req *types.CreateMetho
 func (repo *MethodRepository) GetByID(ctx context.Context, id int) (*types.MethodData, error) {
 	query := `
 		SELECT id, code, name, description, type, min_amount, max_amount,
-			   fee, fee_type, status, image, created_at, updated_at
+			   fee, fee_type, status, image, expiry_period, created_at, updated_at
 		FROM payment_methods WHERE id = $1`
 
 	var method types.MethodData
@@ -91,6 +90,7 @@ func (repo *MethodRepository) GetByID(ctx context.Context, id int) (*types.Metho
 		&method.FeeType,
 		&method.Status,
 		&method.Image, // Added image field
+		&method.ExpiryPeriod,
 		&method.CreatedAt,
 		&method.UpdatedAt,
 	)
@@ -106,7 +106,7 @@ func (repo *MethodRepository) GetByID(ctx context.Context, id int) (*types.Metho
 func (repo *MethodRepository) GetByCode(ctx context.Context, code string) (*types.MethodData, error) {
 	query := `
 		SELECT id, code, name, description, type, min_amount, max_amount,
-			   fee, fee_type, status, image, created_at, updated_at
+			   fee, fee_type, status, image, expiry_period, created_at, updated_at
 		FROM payment_methods WHERE code = $1`
 
 	var method types.MethodData
@@ -122,6 +122,7 @@ func (repo *MethodRepository) GetByCode(ctx context.Context, code string) (*type
 		&method.FeeType,
 		&method.Status,
 		&method.Image, // Added image field
+		&method.ExpiryPeriod,
 		&method.CreatedAt,
 		&method.UpdatedAt,
 	)
@@ -152,7 +153,7 @@ func (repo *MethodRepository) GetAll(ctx context.Context, skip, limit int, searc
 	if search == "" && filterType == "" && status == "" {
 		query := `
 			SELECT id, code, name, description, type, min_amount, max_amount,
-				   fee, fee_type, status, image, created_at, updated_at
+				   fee, fee_type, status, image, expiry_period, created_at, updated_at
 			FROM payment_methods 
 			ORDER BY created_at DESC
 			LIMIT $1 OFFSET $2
@@ -180,6 +181,7 @@ func (repo *MethodRepository) GetAll(ctx context.Context, skip, limit int, searc
 				&method.FeeType,
 				&method.Status,
 				&method.Image,
+				&method.ExpiryPeriod,
 				&method.CreatedAt,
 				&method.UpdatedAt,
 			)
@@ -237,7 +239,7 @@ func (repo *MethodRepository) GetAll(ctx context.Context, skip, limit int, searc
 	// Data query dengan filter
 	dataQuery := fmt.Sprintf(`
 		SELECT id, code, name, description, type, min_amount, max_amount,
-			   fee, fee_type, status, image, created_at, updated_at
+			   fee, fee_type, status, image, expiry_period, created_at, updated_at
 		FROM payment_methods %s
 		ORDER BY created_at DESC
 		LIMIT $%d OFFSET $%d
@@ -267,6 +269,7 @@ func (repo *MethodRepository) GetAll(ctx context.Context, skip, limit int, searc
 			&method.FeeType,
 			&method.Status,
 			&method.Image,
+			&method.ExpiryPeriod,
 			&method.CreatedAt,
 			&method.UpdatedAt,
 		)
@@ -289,7 +292,7 @@ func (repo *MethodRepository) GetAll(ctx context.Context, skip, limit int, searc
 func (repo *MethodRepository) GetActiveOnly(ctx context.Context, limit, offset int) ([]types.MethodData, error) {
 	query := `
 		SELECT id, code, name, description, type, min_amount, max_amount,
-			   fee, fee_type, status, image, created_at, updated_at
+			   fee, fee_type, status, image, expiry_period, created_at, updated_at
 		FROM payment_methods 
 		WHERE active = true
 		ORDER BY created_at DESC
@@ -316,6 +319,7 @@ func (repo *MethodRepository) GetActiveOnly(ctx context.Context, limit, offset i
 			&method.FeeType,
 			&method.Status,
 			&method.Image, // Added image field
+			&method.ExpiryPeriod,
 			&method.CreatedAt,
 			&method.UpdatedAt,
 		)
@@ -332,7 +336,7 @@ func (repo *MethodRepository) GetActiveOnly(ctx context.Context, limit, offset i
 func (repo *MethodRepository) GetByType(ctx context.Context, methodType string) ([]types.MethodData, error) {
 	query := `
 		SELECT id, code, name, description, type, min_amount, max_amount,
-			   fee, fee_type, status, image, created_at, updated_at
+			   fee, fee_type, status, image, expiry_period, created_at, updated_at
 		FROM payment_methods 
 		WHERE type = $1 AND active = true
 		ORDER BY name ASC`
@@ -358,6 +362,7 @@ func (repo *MethodRepository) GetByType(ctx context.Context, methodType string)
 			&method.FeeType,
 			&method.Status,
 			&method.Image, // Added image field
+			&method.ExpiryPeriod,
 			&method.CreatedAt,
 			&method.UpdatedAt,
 		)
@@ -422,6 +427,11 @@ func (repo *MethodRepository) Update(ctx context.Context, id int, req *types.Upd
 		args = append(args, *req.Image)
 		argIndex++
 	}
+	if req.ExpiryPeriod != nil {
+		setParts = append(setParts, "expiry_period = $"+fmt.Sprintf("%d", argIndex))
+		args = append(args, *req.ExpiryPeriod)
+		argIndex++
+	}
 
 	setParts = append(setParts, "updated_at = $"+fmt.Sprintf("%d", argIndex))
 	args = append(args, time.Now())
@@ -0,0 +1,53 @@
+package order
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wafi04/backendvazzz/pkg/types"
+	"github.com/wafi04/backendvazzz/pkg/utils"
+)
+
+type OrderHandler struct {
+	service *OrderService
+}
+
+func NewOrderHandler(service *OrderService) *OrderHandler {
+	return &OrderHandler{
+		service: service,
+	}
+}
+
+func (h *OrderHandler) Timeline(c *gin.Context) {
+	orderID := c.Param("orderId")
+	if orderID == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "order id is required", "")
+		return
+	}
+
+	username := c.GetString("username")
+	if username == "" {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "unauthorized", "username not found in context")
+		return
+	}
+	isAdmin := strings.EqualFold(c.GetString("role"), string(types.RoleAdmin))
+
+	timeline, err := h.service.GetTimeline(c.Request.Context(), orderID, username, isAdmin)
+	if err != nil {
+		if errors.Is(err, ErrOrderNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Order not found", "")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch order timeline", err.Error())
+		return
+	}
+
+	if len(timeline) == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, "Order timeline not found", "")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Order timeline retrieved successfully", timeline)
+}
@@ -0,0 +1,75 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/wafi04/backendvazzz/pkg/types"
+)
+
+// Sources yang boleh mengubah status order
+const (
+	SourceSystem    = "system"
+	SourceDuitku    = "duitku"
+	SourceDigiflazz = "digiflazz"
+	SourceAdmin     = "admin"
+)
+
+var (
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrInvalidTransition = errors.New("invalid status transition")
+	ErrAlreadyInStatus   = errors.New("order already in requested status")
+)
+
+// transitions berisi perpindahan status yang diizinkan.
+// Status yang tidak punya tujuan (SUCCESS, REFUNDED, CANCELED) adalah status akhir. EXPIRED hanya
+// bisa ke MANUAL_REVIEW, untuk pembayaran terverifikasi yang datang setelah order kedaluwarsa.
+var transitions = map[string][]string{
+	types.StatusPending:      {types.StatusPaid, types.StatusFailed, types.StatusExpired, types.StatusCancelled},
+	types.StatusPaid:         {types.StatusProcess, types.StatusSuccess, types.StatusFailed, types.StatusManualReview},
+	types.StatusProcess:      {types.StatusSuccess, types.StatusFailed, types.StatusManualReview},
+	types.StatusManualReview: {types.StatusSuccess, types.StatusFailed},
+	types.StatusFailed:       {types.StatusRefunded},
+	types.StatusExpired:      {types.StatusManualReview},
+}
+
+// CanTransition checks whether an order may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, next := range transitions[strings.ToUpper(from)] {
+		if next == strings.ToUpper(to) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition returns a descriptive error when the transition is not allowed
+func ValidateTransition(from, to string) error {
+	if strings.EqualFold(from, to) {
+		return fmt.Errorf("%w: %s", ErrAlreadyInStatus, to)
+	}
+	if !CanTransition(from, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
+
+// IsFinal reports whether no further transition is possible from the status
+func IsFinal(status string) bool {
+	return len(transitions[strings.ToUpper(status)]) == 0
+}
+
+// FromSupplierStatus maps a supplier result (normalized or Digiflazz wording) to the order lifecycle
+func FromSupplierStatus(status string) (string, bool) {
+	switch strings.ToUpper(status) {
+	case "SUKSES", "SUCCESS", "COMPLETED":
+		return types.StatusSuccess, true
+	case "GAGAL", "FAILED", "ERROR", "CANCELLED":
+		return types.StatusFailed, true
+	case "PENDING", "PROCESS":
+		return types.StatusProcess, true
+	default:
+		return "", false
+	}
+}
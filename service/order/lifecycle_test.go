@@ -0,0 +1,105 @@
+package order
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wafi04/backendvazzz/pkg/types"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{types.StatusPending, types.StatusPaid, true},
+		{types.StatusPending, types.StatusExpired, true},
+		{types.StatusPaid, types.StatusProcess, true},
+		{types.StatusProcess, types.StatusSuccess, true},
+		{types.StatusProcess, types.StatusManualReview, true},
+		{types.StatusFailed, types.StatusRefunded, true},
+		{types.StatusExpired, types.StatusManualReview, true},
+		{"process", "success", true},
+
+		{types.StatusPending, types.StatusSuccess, false},
+		{types.StatusPending, types.StatusProcess, false},
+		{types.StatusSuccess, types.StatusFailed, false},
+		{types.StatusSuccess, types.StatusRefunded, false},
+		{types.StatusRefunded, types.StatusFailed, false},
+		{types.StatusExpired, types.StatusPaid, false},
+		{types.StatusFailed, types.StatusSuccess, false},
+		{"UNKNOWN", types.StatusPaid, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     error
+	}{
+		{types.StatusPending, types.StatusPaid, nil},
+		{types.StatusSuccess, types.StatusSuccess, ErrAlreadyInStatus},
+		{"success", types.StatusSuccess, ErrAlreadyInStatus},
+		{types.StatusSuccess, types.StatusFailed, ErrInvalidTransition},
+		{types.StatusRefunded, types.StatusRefunded, ErrAlreadyInStatus},
+	}
+
+	for _, tt := range tests {
+		err := ValidateTransition(tt.from, tt.to)
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("ValidateTransition(%s, %s) = %v, want nil", tt.from, tt.to, err)
+			}
+			continue
+		}
+		if !errors.Is(err, tt.want) {
+			t.Errorf("ValidateTransition(%s, %s) = %v, want %v", tt.from, tt.to, err, tt.want)
+		}
+	}
+}
+
+func TestIsFinal(t *testing.T) {
+	final := []string{types.StatusSuccess, types.StatusRefunded, types.StatusCancelled}
+	for _, status := range final {
+		if !IsFinal(status) {
+			t.Errorf("IsFinal(%s) = false, want true", status)
+		}
+	}
+
+	open := []string{types.StatusPending, types.StatusPaid, types.StatusProcess, types.StatusManualReview, types.StatusFailed, types.StatusExpired}
+	for _, status := range open {
+		if IsFinal(status) {
+			t.Errorf("IsFinal(%s) = true, want false", status)
+		}
+	}
+}
+
+func TestFromSupplierStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+		ok     bool
+	}{
+		{"Sukses", types.StatusSuccess, true},
+		{"SUCCESS", types.StatusSuccess, true},
+		{"Gagal", types.StatusFailed, true},
+		{"FAILED", types.StatusFailed, true},
+		{"Pending", types.StatusProcess, true},
+		{"PROCESS", types.StatusProcess, true},
+		{"", "", false},
+		{"REFUND", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := FromSupplierStatus(tt.status)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("FromSupplierStatus(%q) = (%q, %v), want (%q, %v)", tt.status, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/wafi04/backendvazzz/pkg/model"
+)
+
+type OrderRepository struct {
+	DB *sql.DB
+}
+
+func NewOrderRepository(db *sql.DB) *OrderRepository {
+	return &OrderRepository{
+		DB: db,
+	}
+}
+
+// Transition describes a single status change of an order
+type Transition struct {
+	OrderID string
+	To      string
+	Actor   string
+	Source  string
+	Payload *string
+}
+
+// Transition moves an order to a new status inside the given database transaction.
+// The order row is locked so concurrent callbacks cannot race each other,
+// and every accepted change is written to transaction_status_history.
+func (repo *OrderRepository) Transition(ctx context.Context, tx *sql.Tx, t Transition) (string, error) {
+	var current string
+	err := tx.QueryRowContext(ctx, `SELECT status FROM transactions WHERE order_id = $1 FOR UPDATE`, t.OrderID).Scan(&current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("%w: %s", ErrOrderNotFound, t.OrderID)
+		}
+		return "", fmt.Errorf("failed to lock order %s: %w", t.OrderID, err)
+	}
+
+	if err := ValidateTransition(current, t.To); err != nil {
+		return current, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE transactions
+		SET status = $1, updated_at = NOW()
+		WHERE order_id = $2
+	`, t.To, t.OrderID)
+	if err != nil {
+		return current, fmt.Errorf("failed to update status for order %s: %w", t.OrderID, err)
+	}
+
+	if err := repo.insertHistory(ctx, tx, t.OrderID, &current, t); err != nil {
+		return current, err
+	}
+
+	return current, nil
+}
+
+// RecordCreated writes the first history entry of a freshly inserted order
+func (repo *OrderRepository) RecordCreated(ctx context.Context, tx *sql.Tx, t Transition) error {
+	return repo.insertHistory(ctx, tx, t.OrderID, nil, t)
+}
+
+func (repo *OrderRepository) insertHistory(ctx context.Context, tx *sql.Tx, orderID string, from *string, t Transition) error {
+	query := `
+		INSERT INTO transaction_status_history (
+			order_id, from_status, to_status, actor, source, payload, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, NOW())
+	`
+
+	_, err := tx.ExecContext(ctx, query, orderID, from, t.To, t.Actor, t.Source, t.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to insert status history for order %s: %w", orderID, err)
+	}
+	return nil
+}
+
+// GetOwner returns the username an order belongs to
+func (repo *OrderRepository) GetOwner(ctx context.Context, orderID string) (string, error) {
+	var username string
+	err := repo.DB.QueryRowContext(ctx, `SELECT username FROM transactions WHERE order_id = $1`, orderID).Scan(&username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("%w: %s", ErrOrderNotFound, orderID)
+		}
+		return "", fmt.Errorf("failed to load owner of order %s: %w", orderID, err)
+	}
+	return username, nil
+}
+
+func (repo *OrderRepository) GetTimeline(ctx context.Context, orderID string) ([]model.TransactionStatusHistory, error) {
+	query := `
+		SELECT id, order_id, from_status, to_status, actor, source, payload, created_at
+		FROM transaction_status_history
+		WHERE order_id = $1
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := repo.DB.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	timeline := []model.TransactionStatusHistory{}
+	for rows.Next() {
+		var h model.TransactionStatusHistory
+		err := rows.Scan(
+			&h.ID,
+			&h.OrderID,
+			&h.FromStatus,
+			&h.ToStatus,
+			&h.Actor,
+			&h.Source,
+			&h.Payload,
+			&h.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		timeline = append(timeline, h)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return timeline, nil
+}
@@ -0,0 +1,45 @@
+package order
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wafi04/backendvazzz/pkg/model"
+)
+
+type OrderService struct {
+	orderRepo *OrderRepository
+}
+
+func NewOrderService(repo *OrderRepository) *OrderService {
+	return &OrderService{
+		orderRepo: repo,
+	}
+}
+
+// GetTimeline returns the status history of an order to its owner or an admin.
+// Raw provider payloads are only shown to admins, and orders of other users are
+// reported as not found so order ids cannot be probed.
+func (s *OrderService) GetTimeline(ctx context.Context, orderID, username string, isAdmin bool) ([]model.TransactionStatusHistory, error) {
+	if !isAdmin {
+		owner, err := s.orderRepo.GetOwner(ctx, orderID)
+		if err != nil {
+			return nil, err
+		}
+		if owner != username {
+			return nil, fmt.Errorf("%w: %s", ErrOrderNotFound, orderID)
+		}
+	}
+
+	timeline, err := s.orderRepo.GetTimeline(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isAdmin {
+		for i := range timeline {
+			timeline[i].Payload = nil
+		}
+	}
+	return timeline, nil
+}
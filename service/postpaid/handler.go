@@ -0,0 +1,68 @@
+package postpaid
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wafi04/backendvazzz/pkg/supplier"
+	"github.com/wafi04/backendvazzz/pkg/utils"
+)
+
+type InquiryRequest struct {
+	ProductCode string `json:"productCode" validate:"required"`
+	CustomerNo  string `json:"customerNo" validate:"required"`
+}
+
+type PostpaidHandler struct {
+	service *PostpaidService
+}
+
+func NewPostpaidHandler(service *PostpaidService) *PostpaidHandler {
+	return &PostpaidHandler{
+		service: service,
+	}
+}
+
+func (h *PostpaidHandler) Inquiry(c *gin.Context) {
+	var req InquiryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid input", err.Error())
+		return
+	}
+	if req.ProductCode == "" || req.CustomerNo == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Product code and customer number are required", "")
+		return
+	}
+
+	quote, err := h.service.Inquiry(c.Request.Context(), req.ProductCode, req.CustomerNo, c.GetString("username"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrProductNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "Produk tidak ditemukan", err.Error())
+		case errors.Is(err, supplier.ErrPostpaidUnsupported):
+			utils.ErrorResponse(c, http.StatusBadRequest, "Produk bukan produk pascabayar", err.Error())
+		case errors.Is(err, ErrInquiryFailed):
+			utils.ErrorResponse(c, http.StatusUnprocessableEntity, "Tagihan tidak ditemukan", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to inquire bill", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Bill retrieved successfully", quote)
+}
+
+func (h *PostpaidHandler) GetQuote(c *gin.Context) {
+	quote, err := h.service.GetQuote(c.Request.Context(), c.Param("quoteId"), c.GetString("username"))
+	if err != nil {
+		if errors.Is(err, ErrQuoteNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Bill quote not found", "")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch bill quote", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Bill quote retrieved successfully", quote)
+}
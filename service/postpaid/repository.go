@@ -0,0 +1,295 @@
+package postpaid
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wafi04/backendvazzz/pkg/config"
+	"github.com/wafi04/backendvazzz/pkg/supplier"
+	"github.com/wafi04/backendvazzz/pkg/utils"
+	"github.com/wafi04/backendvazzz/service/providerbalance"
+)
+
+// TransactionType untuk order pembayaran tagihan
+const TransactionType = "POSTPAID"
+
+// Status bill_quotes
+const (
+	QuoteActive  = "ACTIVE"
+	QuoteUsed    = "USED"
+	QuoteExpired = "EXPIRED"
+)
+
+var (
+	ErrProductNotFound = errors.New("postpaid product not found")
+	ErrInquiryFailed   = errors.New("bill inquiry failed")
+	ErrQuoteNotFound   = errors.New("bill quote not found")
+	ErrQuoteExpired    = errors.New("bill quote expired")
+	ErrQuoteUsed       = errors.New("bill quote already used")
+)
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// BillQuote is a stored inquiry result. QuoteID becomes the order id and is
+// the ref_id sent to the supplier for both inquiry and payment.
+type BillQuote struct {
+	QuoteID       string          `json:"quoteId"`
+	Supplier      string          `json:"-"`
+	ProductCode   string          `json:"productCode"`
+	ServiceName   string          `json:"serviceName"`
+	CustomerNo    string          `json:"customerNo"`
+	CustomerName  string          `json:"customerName"`
+	BillAmount    int             `json:"billAmount"`
+	PurchasePrice int             `json:"-"`
+	AdminFee      int             `json:"adminFee"`
+	Detail        json.RawMessage `json:"detail,omitempty"`
+	Status        string          `json:"status"`
+	Username      string          `json:"-"`
+	ExpiresAt     time.Time       `json:"expiresAt"`
+	CreatedAt     time.Time       `json:"createdAt"`
+}
+
+// PayOutcome is the answer of a bill payment; Result is nil when the supplier did not answer
+type PayOutcome struct {
+	Supplier supplier.Supplier
+	Result   *supplier.OrderResult
+	Err      error
+}
+
+type PostpaidRepository struct {
+	DB        *sql.DB
+	suppliers *supplier.Registry
+	balances  *providerbalance.ProviderBalanceRepository
+	config    config.PostpaidConfig
+}
+
+func NewPostpaidRepository(db *sql.DB, suppliers *supplier.Registry, balances *providerbalance.ProviderBalanceRepository, cfg config.PostpaidConfig) *PostpaidRepository {
+	return &PostpaidRepository{
+		DB:        db,
+		suppliers: suppliers,
+		balances:  balances,
+		config:    cfg,
+	}
+}
+
+// QuoteValidity adalah lama hasil inquiry boleh dibayar
+func (repo *PostpaidRepository) QuoteValidity() time.Duration {
+	return time.Duration(repo.config.QuoteValidityMinutes) * time.Minute
+}
+
+// Inquiry asks the supplier for the outstanding bill and stores it as a quote owned by
+// username (empty for guests)
+func (repo *PostpaidRepository) Inquiry(ctx context.Context, productCode, customerNo, username string) (*BillQuote, error) {
+	quote := &BillQuote{
+		ProductCode: productCode,
+		CustomerNo:  customerNo,
+		Username:    username,
+	}
+
+	var status string
+	err := repo.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(provider, ''), service_name, status
+		FROM services
+		WHERE provider_id = $1
+	`, productCode).Scan(&quote.Supplier, &quote.ServiceName, &status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s", ErrProductNotFound, productCode)
+		}
+		return nil, fmt.Errorf("failed to load product %s: %w", productCode, err)
+	}
+	if status != "active" {
+		return nil, fmt.Errorf("%w: %s is not active", ErrProductNotFound, productCode)
+	}
+
+	s, err := repo.suppliers.GetPostpaid(quote.Supplier)
+	if err != nil {
+		return nil, err
+	}
+	quote.Supplier = s.Name()
+	quote.QuoteID = utils.GenerateUniqeID(stringPtr("VAZZ"))
+
+	bill, err := s.Inquiry(ctx, supplier.OrderRequest{
+		RefID:      quote.QuoteID,
+		SKU:        productCode,
+		CustomerNo: customerNo,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInquiryFailed, err)
+	}
+	if bill.Status != supplier.StatusSuccess {
+		return nil, fmt.Errorf("%w: %s", ErrInquiryFailed, bill.Message)
+	}
+
+	// Customer membayar selling_price dari supplier, tidak pernah di bawah harga beli
+	quote.CustomerName = bill.CustomerName
+	quote.PurchasePrice = bill.Price
+	quote.BillAmount = bill.SellingPrice
+	if quote.BillAmount < bill.Price {
+		quote.BillAmount = bill.Price
+	}
+	quote.AdminFee = bill.Admin
+	quote.Detail = bill.Detail
+	quote.Status = QuoteActive
+
+	var detail interface{}
+	if len(bill.Detail) > 0 && string(bill.Detail) != "null" {
+		detail = string(bill.Detail)
+	}
+
+	err = repo.DB.QueryRowContext(ctx, `
+		INSERT INTO bill_quotes (
+			quote_id, supplier, product_code, customer_no, customer_name,
+			bill_amount, purchase_price, admin_fee, detail, status, expires_at, username
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''))
+		RETURNING expires_at, created_at
+	`,
+		quote.QuoteID,
+		quote.Supplier,
+		quote.ProductCode,
+		quote.CustomerNo,
+		quote.CustomerName,
+		quote.BillAmount,
+		quote.PurchasePrice,
+		quote.AdminFee,
+		detail,
+		QuoteActive,
+		time.Now().Add(repo.QuoteValidity()),
+		quote.Username,
+	).Scan(&quote.ExpiresAt, &quote.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save bill quote: %w", err)
+	}
+
+	return quote, nil
+}
+
+func (repo *PostpaidRepository) GetQuote(ctx context.Context, q querier, quoteID string) (*BillQuote, error) {
+	return repo.scanQuote(ctx, q, `WHERE q.quote_id = $1`, quoteID)
+}
+
+// UseQuote locks an active quote and marks it as used by the order with the same id
+func (repo *PostpaidRepository) UseQuote(ctx context.Context, tx *sql.Tx, quoteID string) (*BillQuote, error) {
+	quote, err := repo.scanQuote(ctx, tx, `WHERE q.quote_id = $1 FOR UPDATE OF q`, quoteID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case quote.Status == QuoteUsed:
+		return nil, fmt.Errorf("%w: %s", ErrQuoteUsed, quoteID)
+	case quote.Status == QuoteExpired || time.Now().After(quote.ExpiresAt):
+		return nil, fmt.Errorf("%w: %s", ErrQuoteExpired, quoteID)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE bill_quotes SET status = $1, used_at = NOW() WHERE quote_id = $2
+	`, QuoteUsed, quoteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark bill quote %s as used: %w", quoteID, err)
+	}
+
+	quote.Status = QuoteUsed
+	return quote, nil
+}
+
+func (repo *PostpaidRepository) scanQuote(ctx context.Context, q querier, where string, args ...interface{}) (*BillQuote, error) {
+	var (
+		quote        BillQuote
+		customerName sql.NullString
+		detail       []byte
+	)
+	err := q.QueryRowContext(ctx, `
+		SELECT q.quote_id, q.supplier, q.product_code, COALESCE(s.service_name, ''), q.customer_no,
+			q.customer_name, q.bill_amount, q.purchase_price, q.admin_fee, q.detail,
+			q.status, COALESCE(q.username, ''), q.expires_at, q.created_at
+		FROM bill_quotes q
+		LEFT JOIN services s ON s.provider_id = q.product_code
+		`+where, args...).Scan(
+		&quote.QuoteID, &quote.Supplier, &quote.ProductCode, &quote.ServiceName, &quote.CustomerNo,
+		&customerName, &quote.BillAmount, &quote.PurchasePrice, &quote.AdminFee, &detail,
+		&quote.Status, &quote.Username, &quote.ExpiresAt, &quote.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrQuoteNotFound
+		}
+		return nil, fmt.Errorf("failed to load bill quote: %w", err)
+	}
+
+	quote.CustomerName = customerName.String
+	if len(detail) > 0 {
+		quote.Detail = json.RawMessage(detail)
+	}
+	return &quote, nil
+}
+
+// Pay pays the bill quoted for orderID with the supplier that answered the inquiry
+func (repo *PostpaidRepository) Pay(ctx context.Context, q querier, orderID string) (*PayOutcome, error) {
+	return repo.call(ctx, q, orderID, false)
+}
+
+// PayStatus checks a bill payment that is still pending
+func (repo *PostpaidRepository) PayStatus(ctx context.Context, q querier, orderID string) (*PayOutcome, error) {
+	return repo.call(ctx, q, orderID, true)
+}
+
+func (repo *PostpaidRepository) call(ctx context.Context, q querier, orderID string, status bool) (*PayOutcome, error) {
+	quote, err := repo.GetQuote(ctx, q, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := repo.suppliers.GetPostpaid(quote.Supplier)
+	if err != nil {
+		return nil, err
+	}
+
+	req := supplier.OrderRequest{
+		RefID:      quote.QuoteID,
+		SKU:        quote.ProductCode,
+		CustomerNo: quote.CustomerNo,
+	}
+
+	var result *supplier.OrderResult
+	if status {
+		result, err = s.PayStatus(ctx, req)
+	} else {
+		result, err = s.Pay(ctx, req)
+	}
+
+	outcome := &PayOutcome{Supplier: s}
+	switch {
+	case err != nil:
+		outcome.Err = err
+	case result.Status == "":
+		outcome.Err = fmt.Errorf("unknown status %q from %s", result.RawStatus, s.Name())
+	default:
+		outcome.Result = result
+		repo.balances.Observe(ctx, s.Name(), result, providerbalance.SourceOrder)
+	}
+	return outcome, nil
+}
+
+// ExpireQuotes marks active quotes past their validity window as EXPIRED
+func (repo *PostpaidRepository) ExpireQuotes(ctx context.Context) error {
+	_, err := repo.DB.ExecContext(ctx, `
+		UPDATE bill_quotes SET status = $1
+		WHERE status = $2 AND expires_at <= NOW()
+	`, QuoteExpired, QuoteActive)
+	if err != nil {
+		return fmt.Errorf("failed to expire bill quotes: %w", err)
+	}
+	return nil
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
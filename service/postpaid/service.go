@@ -0,0 +1,33 @@
+package postpaid
+
+import (
+	"context"
+	"strings"
+)
+
+type PostpaidService struct {
+	repo *PostpaidRepository
+}
+
+func NewPostpaidService(repo *PostpaidRepository) *PostpaidService {
+	return &PostpaidService{
+		repo: repo,
+	}
+}
+
+func (s *PostpaidService) Inquiry(ctx context.Context, productCode, customerNo, username string) (*BillQuote, error) {
+	return s.repo.Inquiry(ctx, strings.TrimSpace(productCode), strings.TrimSpace(customerNo), username)
+}
+
+// GetQuote returns a quote to the user who made the inquiry. Quotes of other users and
+// guests are reported as not found so quote ids cannot be probed.
+func (s *PostpaidService) GetQuote(ctx context.Context, quoteID, username string) (*BillQuote, error) {
+	quote, err := s.repo.GetQuote(ctx, s.repo.DB, quoteID)
+	if err != nil {
+		return nil, err
+	}
+	if quote.Username == "" || quote.Username != username {
+		return nil, ErrQuoteNotFound
+	}
+	return quote, nil
+}
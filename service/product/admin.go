@@ -0,0 +1,765 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/wafi04/backendvazzz/service/markup"
+)
+
+// Field yang diubah manual oleh admin dan tidak ditimpa sync. Logo dan kategori produk
+// yang sudah ada tidak pernah ditulis sync, jadi keduanya tidak perlu dikunci.
+const (
+	LockServiceName = "service_name"
+	LockNote        = "note"
+	LockStatus      = "status"
+	LockProfit      = "profit"
+)
+
+var lockableFields = map[string]bool{
+	LockServiceName: true,
+	LockNote:        true,
+	LockStatus:      true,
+	LockProfit:      true,
+}
+
+func isLocked(locked []string, field string) bool {
+	for _, f := range locked {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// InactiveManual menandai produk yang dinonaktifkan admin
+const InactiveManual = "MANUAL"
+
+// Aksi bulk admin
+const (
+	BulkActivate   = "activate"
+	BulkDeactivate = "deactivate"
+	BulkReprice    = "reprice"
+)
+
+var (
+	ErrProductNotFound = errors.New("product not found")
+	ErrInvalidProduct  = errors.New("invalid product")
+)
+
+// AdminProduct is the full row of a product for the admin panel, including purchase price
+type AdminProduct struct {
+	ID             int       `json:"id"`
+	ProviderID     string    `json:"providerId"`
+	Provider       string    `json:"provider"`
+	ServiceName    string    `json:"serviceName"`
+	CategoryID     int       `json:"categoryId"`
+	SubCategoryID  int       `json:"subCategoryId"`
+	Brand          string    `json:"brand"`
+	Note           string    `json:"note"`
+	ProductLogo    *string   `json:"productLogo,omitempty"`
+	Status         string    `json:"status"`
+	InactiveReason *string   `json:"inactiveReason,omitempty"`
+	PricePurchase  int       `json:"pricePurchase"`
+	Price          int       `json:"price"`
+	PriceReseller  int       `json:"priceReseller"`
+	PricePlatinum  int       `json:"pricePlatinum"`
+	PriceSuggest   int       `json:"priceSuggest"`
+	IsSuggest      string    `json:"isSuggest"`
+	Profit         int       `json:"profit"`
+	ProfitReseller int       `json:"profitReseller"`
+	ProfitPlatinum int       `json:"profitPlatinum"`
+	IsProfitFixed  string    `json:"isProfitFixed"`
+	MarkupRuleID   *int      `json:"markupRuleId,omitempty"`
+	IsFlashSale    string    `json:"isFlashSale"`
+	LockedFields   []string  `json:"lockedFields"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+	Availability
+}
+
+func (p *AdminProduct) prices() PriceSnapshot {
+	return PriceSnapshot{Purchase: p.PricePurchase, Price: p.Price, Reseller: p.PriceReseller, Platinum: p.PricePlatinum}
+}
+
+// ProductFilter selects products for the admin list and bulk actions
+type ProductFilter struct {
+	ProviderIDs   []string `json:"providerIds,omitempty"`
+	CategoryID    int      `json:"categoryId,omitempty"`
+	SubCategoryID int      `json:"subCategoryId,omitempty"`
+	Brand         string   `json:"brand,omitempty"`
+	Provider      string   `json:"provider,omitempty"`
+	Status        string   `json:"status,omitempty"`
+	Search        string   `json:"search,omitempty"`
+}
+
+func (f ProductFilter) empty() bool {
+	return len(f.ProviderIDs) == 0 && f.CategoryID == 0 && f.SubCategoryID == 0 &&
+		f.Brand == "" && f.Provider == "" && f.Status == "" && f.Search == ""
+}
+
+// where builds the WHERE clause, placeholder dimulai dari $1
+func (f ProductFilter) where() (string, []interface{}) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if len(f.ProviderIDs) > 0 {
+		add("provider_id = ANY($%d)", pq.Array(f.ProviderIDs))
+	}
+	if f.CategoryID > 0 {
+		add("category_id = $%d", f.CategoryID)
+	}
+	if f.SubCategoryID > 0 {
+		add("sub_category_id = $%d", f.SubCategoryID)
+	}
+	if f.Brand != "" {
+		add("LOWER(brand) = LOWER($%d)", f.Brand)
+	}
+	if f.Provider != "" {
+		add("provider = $%d", f.Provider)
+	}
+	if f.Status != "" {
+		add("status = $%d", f.Status)
+	}
+	if search := strings.TrimSpace(f.Search); search != "" {
+		add("(service_name ILIKE $%[1]d OR provider_id ILIKE $%[1]d)", likePattern(search))
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// ProfitRequest is a manual profit override, dikunci supaya aturan markup tidak menimpanya
+type ProfitRequest struct {
+	Profit         int    `json:"profit" validate:"gte=0"`
+	ProfitReseller int    `json:"profitReseller" validate:"gte=0"`
+	ProfitPlatinum int    `json:"profitPlatinum" validate:"gte=0"`
+	IsProfitFixed  string `json:"isProfitFixed" validate:"required,oneof=active inactive"`
+}
+
+func (req *ProfitRequest) Validate() error {
+	if req.Profit < 0 || req.ProfitReseller < 0 || req.ProfitPlatinum < 0 {
+		return fmt.Errorf("%w: profit must not be negative", ErrInvalidProduct)
+	}
+	if req.IsProfitFixed != "active" && req.IsProfitFixed != "inactive" {
+		return fmt.Errorf("%w: isProfitFixed must be active or inactive", ErrInvalidProduct)
+	}
+	return nil
+}
+
+func (req *ProfitRequest) config() ProfitConfig {
+	return ProfitConfig{
+		Profit:         req.Profit,
+		ProfitReseller: req.ProfitReseller,
+		ProfitPlatinum: req.ProfitPlatinum,
+		IsProfitFixed:  req.IsProfitFixed,
+	}
+}
+
+func validStatus(status string) bool {
+	return status == "active" || status == "inactive"
+}
+
+type CreateProductRequest struct {
+	ProviderID    string         `json:"providerId" validate:"required"`
+	Provider      string         `json:"provider,omitempty"`
+	ServiceName   string         `json:"serviceName" validate:"required"`
+	CategoryID    int            `json:"categoryId" validate:"required,gt=0"`
+	SubCategoryID int            `json:"subCategoryId,omitempty"`
+	Brand         string         `json:"brand,omitempty"`
+	Note          string         `json:"note,omitempty"`
+	ProductLogo   *string        `json:"productLogo,omitempty"`
+	PricePurchase int            `json:"pricePurchase" validate:"required,gt=0"`
+	Status        string         `json:"status,omitempty"`
+	Profit        *ProfitRequest `json:"profit,omitempty"`
+}
+
+func (req *CreateProductRequest) Validate() error {
+	req.ProviderID = strings.TrimSpace(req.ProviderID)
+	req.ServiceName = strings.TrimSpace(req.ServiceName)
+	switch {
+	case req.ProviderID == "":
+		return fmt.Errorf("%w: providerId is required", ErrInvalidProduct)
+	case req.ServiceName == "":
+		return fmt.Errorf("%w: serviceName is required", ErrInvalidProduct)
+	case req.CategoryID <= 0:
+		return fmt.Errorf("%w: categoryId is required", ErrInvalidProduct)
+	case req.SubCategoryID < 0:
+		return fmt.Errorf("%w: invalid subCategoryId", ErrInvalidProduct)
+	case req.PricePurchase <= 0:
+		return fmt.Errorf("%w: pricePurchase must be positive", ErrInvalidProduct)
+	}
+
+	if req.Provider == "" {
+		req.Provider = "digiflazz"
+	}
+	if req.Status == "" {
+		req.Status = "active"
+	}
+	if !validStatus(req.Status) {
+		return fmt.Errorf("%w: status must be active or inactive", ErrInvalidProduct)
+	}
+	if req.Profit != nil {
+		return req.Profit.Validate()
+	}
+	return nil
+}
+
+// UpdateProductRequest is a partial update, hanya field yang dikirim yang diubah dan dikunci
+type UpdateProductRequest struct {
+	ServiceName   *string        `json:"serviceName,omitempty"`
+	ProductLogo   *string        `json:"productLogo,omitempty"`
+	Note          *string        `json:"note,omitempty"`
+	CategoryID    *int           `json:"categoryId,omitempty"`
+	SubCategoryID *int           `json:"subCategoryId,omitempty"`
+	Status        *string        `json:"status,omitempty"`
+	IsSuggest     *string        `json:"isSuggest,omitempty"`
+	PriceSuggest  *int           `json:"priceSuggest,omitempty"`
+	Profit        *ProfitRequest `json:"profit,omitempty"`
+	// Unlock melepas kunci field sehingga sync dan aturan markup berlaku lagi
+	Unlock []string `json:"unlock,omitempty"`
+}
+
+func (req *UpdateProductRequest) Validate() error {
+	if req.ServiceName == nil && req.ProductLogo == nil && req.Note == nil && req.CategoryID == nil &&
+		req.SubCategoryID == nil && req.Status == nil && req.IsSuggest == nil && req.PriceSuggest == nil &&
+		req.Profit == nil && len(req.Unlock) == 0 {
+		return fmt.Errorf("%w: nothing to update", ErrInvalidProduct)
+	}
+
+	if req.ServiceName != nil {
+		name := strings.TrimSpace(*req.ServiceName)
+		if name == "" {
+			return fmt.Errorf("%w: serviceName must not be empty", ErrInvalidProduct)
+		}
+		req.ServiceName = &name
+	}
+	if req.CategoryID != nil && *req.CategoryID <= 0 {
+		return fmt.Errorf("%w: invalid categoryId", ErrInvalidProduct)
+	}
+	if req.SubCategoryID != nil && *req.SubCategoryID < 0 {
+		return fmt.Errorf("%w: invalid subCategoryId", ErrInvalidProduct)
+	}
+	if req.Status != nil && !validStatus(*req.Status) {
+		return fmt.Errorf("%w: status must be active or inactive", ErrInvalidProduct)
+	}
+	if req.IsSuggest != nil && !validStatus(*req.IsSuggest) {
+		return fmt.Errorf("%w: isSuggest must be active or inactive", ErrInvalidProduct)
+	}
+	if req.PriceSuggest != nil && *req.PriceSuggest < 0 {
+		return fmt.Errorf("%w: priceSuggest must not be negative", ErrInvalidProduct)
+	}
+	for _, field := range req.Unlock {
+		if !lockableFields[field] {
+			return fmt.Errorf("%w: %s cannot be unlocked", ErrInvalidProduct, field)
+		}
+	}
+	if req.Profit != nil {
+		return req.Profit.Validate()
+	}
+	return nil
+}
+
+type BulkRequest struct {
+	Action string         `json:"action" validate:"required,oneof=activate deactivate reprice"`
+	Filter ProductFilter  `json:"filter"`
+	Profit *ProfitRequest `json:"profit,omitempty"`
+}
+
+func (req *BulkRequest) Validate() error {
+	switch req.Action {
+	case BulkActivate, BulkDeactivate:
+	case BulkReprice:
+		if req.Profit == nil {
+			return fmt.Errorf("%w: profit is required to reprice", ErrInvalidProduct)
+		}
+		if err := req.Profit.Validate(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidProduct, req.Action)
+	}
+
+	// Filter kosong berarti seluruh katalog, tolak supaya tidak terjadi tanpa sengaja
+	if req.Filter.empty() {
+		return fmt.Errorf("%w: filter is required", ErrInvalidProduct)
+	}
+	if req.Filter.Status != "" && !validStatus(req.Filter.Status) {
+		return fmt.Errorf("%w: status must be active or inactive", ErrInvalidProduct)
+	}
+	return nil
+}
+
+type BulkResult struct {
+	Action  string        `json:"action"`
+	Updated int           `json:"updated"`
+	Changes []PriceChange `json:"changes,omitempty"`
+}
+
+const adminProductColumns = `
+	id, provider_id, COALESCE(provider, ''), service_name, category_id, COALESCE(sub_category_id, 0),
+	COALESCE(brand, ''), COALESCE(note, ''), product_logo, status, inactive_reason,
+	price_purchase, price, price_reseller, price_platinum, price_suggest, is_suggest,
+	profit, profit_reseller, profit_platinum, is_profit_fixed, markup_rule_id, is_flash_sale, locked_fields,
+	start_cut_off, end_cut_off, stock, unlimited_stock, buyer_product_status, created_at, updated_at
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAdminProduct(row rowScanner) (*AdminProduct, error) {
+	var (
+		p     AdminProduct
+		stock Stock
+	)
+	err := row.Scan(
+		&p.ID, &p.ProviderID, &p.Provider, &p.ServiceName, &p.CategoryID, &p.SubCategoryID,
+		&p.Brand, &p.Note, &p.ProductLogo, &p.Status, &p.InactiveReason,
+		&p.PricePurchase, &p.Price, &p.PriceReseller, &p.PricePlatinum, &p.PriceSuggest, &p.IsSuggest,
+		&p.Profit, &p.ProfitReseller, &p.ProfitPlatinum, &p.IsProfitFixed, &p.MarkupRuleID, &p.IsFlashSale,
+		pq.Array(&p.LockedFields),
+		&stock.StartCutOff, &stock.EndCutOff, &stock.Stock, &stock.UnlimitedStock, &stock.BuyerProductStatus,
+		&p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if p.LockedFields == nil {
+		p.LockedFields = []string{}
+	}
+	p.Availability = stock.Check(time.Now())
+	if p.Status != "active" {
+		p.Availability = Availability{Reason: ReasonInactive}
+	}
+	return &p, nil
+}
+
+// AdminList returns one page of products matching the filter with the total count
+func (repo *ProductRepository) AdminList(ctx context.Context, filter ProductFilter, skip, limit int) ([]AdminProduct, int, error) {
+	where, args := filter.where()
+
+	var total int
+	if err := repo.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM services`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count products: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM services%s ORDER BY category_id ASC, price ASC, id ASC LIMIT $%d OFFSET $%d`,
+		adminProductColumns, where, len(args)+1, len(args)+2)
+	rows, err := repo.DB.QueryContext(ctx, query, append(args, limit, skip)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []AdminProduct{}
+	for rows.Next() {
+		p, err := scanAdminProduct(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan product: %w", err)
+		}
+		products = append(products, *p)
+	}
+	return products, total, rows.Err()
+}
+
+func (repo *ProductRepository) AdminGet(ctx context.Context, providerID string) (*AdminProduct, error) {
+	return repo.adminGet(ctx, repo.DB, providerID, false)
+}
+
+func (repo *ProductRepository) adminGet(ctx context.Context, db queryer, providerID string, forUpdate bool) (*AdminProduct, error) {
+	query := `SELECT ` + adminProductColumns + ` FROM services WHERE provider_id = $1`
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
+
+	p, err := scanAdminProduct(db.QueryRowContext(ctx, query, providerID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrProductNotFound, providerID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load product %s: %w", providerID, err)
+	}
+	return p, nil
+}
+
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// checkCategory memastikan kategori dan sub kategori ada sebelum produk dipindahkan
+func (repo *ProductRepository) checkCategory(ctx context.Context, tx *sql.Tx, categoryID, subCategoryID int) error {
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM categories WHERE id = $1)`, categoryID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check category: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("%w: category %d not found", ErrInvalidProduct, categoryID)
+	}
+
+	if subCategoryID == 0 {
+		return nil
+	}
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM sub_categories WHERE id = $1)`, subCategoryID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check sub category: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("%w: sub category %d not found", ErrInvalidProduct, subCategoryID)
+	}
+	return nil
+}
+
+func nullableID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// AdminCreate adds a product by hand. Tanpa profit, aturan markup berlaku seperti produk dari sync.
+func (repo *ProductRepository) AdminCreate(ctx context.Context, req CreateProductRequest) (*AdminProduct, error) {
+	config := getDefaultProfitConfig("")
+	locked := []string{}
+	if req.Profit != nil {
+		config = req.Profit.config()
+		locked = append(locked, LockProfit)
+	} else {
+		rules, err := repo.markups.ActiveRules(ctx)
+		if err != nil {
+			return nil, err
+		}
+		config = applyMarkupRule(rules, markup.Subject{
+			CategoryID:    req.CategoryID,
+			SubCategoryID: req.SubCategoryID,
+			Brand:         req.Brand,
+			SKU:           req.ProviderID,
+			PurchasePrice: req.PricePurchase,
+		}, config)
+	}
+	price, priceReseller, pricePlatinum, purchase := calculatePrices(req.PricePurchase, config)
+
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := repo.checkCategory(ctx, tx, req.CategoryID, req.SubCategoryID); err != nil {
+		return nil, err
+	}
+
+	var inactiveReason interface{}
+	if req.Status == "inactive" {
+		inactiveReason = InactiveManual
+	}
+	var brand interface{}
+	if req.Brand != "" {
+		brand = req.Brand
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO services (
+			service_name, category_id, sub_category_id,
+			price, price_purchase, price_reseller, price_platinum, price_suggest,
+			profit, profit_platinum, profit_reseller, profit_suggest, is_suggest,
+			status, provider_id, provider, note, is_profit_fixed, product_logo, is_flash_sale,
+			brand, markup_rule_id, inactive_reason, deactivated_at, locked_fields,
+			created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, 0, $8, $9, $10, 0, 'inactive',
+			$11, $12, $13, $14, $15, $16, 'inactive',
+			$17, $18, $19, CASE WHEN $20::boolean THEN NOW() END, $21,
+			NOW(), NOW()
+		)
+		ON CONFLICT (provider_id) DO NOTHING
+	`,
+		req.ServiceName, req.CategoryID, nullableID(req.SubCategoryID),
+		price, purchase, priceReseller, pricePlatinum,
+		config.Profit, config.ProfitPlatinum, config.ProfitReseller,
+		req.Status, req.ProviderID, req.Provider, req.Note, config.IsProfitFixed, req.ProductLogo,
+		brand, config.RuleID, inactiveReason, inactiveReason != nil, pq.Array(locked),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create product: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("%w: product %s already exists", ErrInvalidProduct, req.ProviderID)
+	}
+
+	change := PriceChange{
+		ProviderID:  req.ProviderID,
+		ServiceName: req.ServiceName,
+		Type:        PriceNew,
+		New:         PriceSnapshot{Purchase: purchase, Price: price, Reseller: priceReseller, Platinum: pricePlatinum},
+	}
+	if err := repo.recordPriceChanges(ctx, tx, []PriceChange{change}, 0); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return repo.AdminGet(ctx, req.ProviderID)
+}
+
+// AdminUpdate applies a partial update and locks every changed field against the next sync
+func (repo *ProductRepository) AdminUpdate(ctx context.Context, providerID string, req UpdateProductRequest) (*AdminProduct, error) {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := repo.adminGet(ctx, tx, providerID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	setParts := []string{}
+	args := []interface{}{}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		setParts = append(setParts, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	locks := map[string]bool{}
+	for _, field := range current.LockedFields {
+		locks[field] = true
+	}
+
+	if req.ServiceName != nil {
+		set("service_name", *req.ServiceName)
+		locks[LockServiceName] = true
+	}
+	if req.ProductLogo != nil {
+		// String kosong menghapus logo
+		var logo interface{}
+		if *req.ProductLogo != "" {
+			logo = *req.ProductLogo
+		}
+		set("product_logo", logo)
+	}
+	if req.Note != nil {
+		set("note", *req.Note)
+		locks[LockNote] = true
+	}
+	if req.CategoryID != nil || req.SubCategoryID != nil {
+		categoryID, subCategoryID := current.CategoryID, current.SubCategoryID
+		if req.CategoryID != nil {
+			categoryID = *req.CategoryID
+		}
+		if req.SubCategoryID != nil {
+			subCategoryID = *req.SubCategoryID
+		}
+		if err := repo.checkCategory(ctx, tx, categoryID, subCategoryID); err != nil {
+			return nil, err
+		}
+		set("category_id", categoryID)
+		set("sub_category_id", nullableID(subCategoryID))
+	}
+	if req.Status != nil {
+		set("status", *req.Status)
+		if *req.Status == "inactive" {
+			set("inactive_reason", InactiveManual)
+			setParts = append(setParts, "deactivated_at = COALESCE(deactivated_at, NOW())")
+		} else {
+			setParts = append(setParts, "inactive_reason = NULL", "deactivated_at = NULL")
+		}
+		locks[LockStatus] = true
+	}
+	if req.IsSuggest != nil {
+		set("is_suggest", *req.IsSuggest)
+	}
+	if req.PriceSuggest != nil {
+		set("price_suggest", *req.PriceSuggest)
+	}
+
+	var change *PriceChange
+	if req.Profit != nil {
+		config := req.Profit.config()
+		price, priceReseller, pricePlatinum, purchase := calculatePrices(current.PricePurchase, config)
+		set("price", price)
+		set("price_reseller", priceReseller)
+		set("price_platinum", pricePlatinum)
+		set("profit", config.Profit)
+		set("profit_reseller", config.ProfitReseller)
+		set("profit_platinum", config.ProfitPlatinum)
+		set("is_profit_fixed", config.IsProfitFixed)
+		setParts = append(setParts, "markup_rule_id = NULL")
+		locks[LockProfit] = true
+
+		old := current.prices()
+		change = &PriceChange{
+			ProviderID:  providerID,
+			ServiceName: current.ServiceName,
+			Old:         &old,
+			New:         PriceSnapshot{Purchase: purchase, Price: price, Reseller: priceReseller, Platinum: pricePlatinum},
+		}
+		change.Type = change.ChangeType()
+	}
+
+	for _, field := range req.Unlock {
+		delete(locks, field)
+	}
+	locked := make([]string, 0, len(locks))
+	for field := range locks {
+		locked = append(locked, field)
+	}
+	sort.Strings(locked)
+	set("locked_fields", pq.Array(locked))
+
+	setParts = append(setParts, "updated_at = NOW()")
+	args = append(args, providerID)
+	query := fmt.Sprintf(`UPDATE services SET %s WHERE provider_id = $%d`, strings.Join(setParts, ", "), len(args))
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to update product %s: %w", providerID, err)
+	}
+
+	if change != nil {
+		if err := repo.recordPriceChanges(ctx, tx, []PriceChange{*change}, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return repo.AdminGet(ctx, providerID)
+}
+
+// AdminDelete removes a product. Produk dari supplier akan muncul lagi di sync berikutnya,
+// jadi produk yang masih ada di price list sebaiknya dinonaktifkan saja.
+func (repo *ProductRepository) AdminDelete(ctx context.Context, providerID string) error {
+	result, err := repo.DB.ExecContext(ctx, `DELETE FROM services WHERE provider_id = $1`, providerID)
+	if err != nil {
+		return fmt.Errorf("failed to delete product %s: %w", providerID, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: %s", ErrProductNotFound, providerID)
+	}
+	return nil
+}
+
+// Bulk activates, deactivates or reprices every product matching the filter in one transaction
+func (repo *ProductRepository) Bulk(ctx context.Context, req BulkRequest) (*BulkResult, error) {
+	where, args := req.Filter.where()
+
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &BulkResult{Action: req.Action}
+	switch req.Action {
+	case BulkActivate, BulkDeactivate:
+		status, reason := "active", interface{}(nil)
+		if req.Action == BulkDeactivate {
+			status, reason = "inactive", InactiveManual
+		}
+		n := len(args)
+		query := fmt.Sprintf(`
+			UPDATE services
+			SET status = $%[1]d,
+				inactive_reason = $%[2]d,
+				deactivated_at = CASE WHEN $%[3]d::boolean THEN COALESCE(deactivated_at, NOW()) END,
+				locked_fields = CASE WHEN $%[4]d = ANY(locked_fields) THEN locked_fields
+					ELSE array_append(locked_fields, $%[4]d) END,
+				updated_at = NOW()
+			%[5]s
+		`, n+1, n+2, n+3, n+4, where)
+		res, err := tx.ExecContext(ctx, query, append(args, status, reason, reason != nil, LockStatus)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to %s products: %w", req.Action, err)
+		}
+		updated, _ := res.RowsAffected()
+		result.Updated = int(updated)
+
+	case BulkReprice:
+		changes, err := repo.bulkReprice(ctx, tx, where, args, req.Profit.config())
+		if err != nil {
+			return nil, err
+		}
+		result.Updated = len(changes)
+		result.Changes = changes
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// bulkReprice sets the profit of the matching products and recalculates their prices
+// from the stored purchase price; the profit is then fixed so markup rules no longer apply
+func (repo *ProductRepository) bulkReprice(ctx context.Context, tx *sql.Tx, where string, args []interface{}, config ProfitConfig) ([]PriceChange, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT provider_id, service_name, price_purchase, price, price_reseller, price_platinum
+		FROM services`+where+`
+		ORDER BY provider_id ASC
+		FOR UPDATE
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query products: %w", err)
+	}
+
+	changes := []PriceChange{}
+	for rows.Next() {
+		var (
+			change PriceChange
+			old    PriceSnapshot
+		)
+		if err := rows.Scan(&change.ProviderID, &change.ServiceName, &old.Purchase, &old.Price, &old.Reseller, &old.Platinum); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		price, priceReseller, pricePlatinum, purchase := calculatePrices(old.Purchase, config)
+		change.Old = &old
+		change.New = PriceSnapshot{Purchase: purchase, Price: price, Reseller: priceReseller, Platinum: pricePlatinum}
+		change.Type = change.ChangeType()
+		changes = append(changes, change)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating products: %w", err)
+	}
+
+	for _, change := range changes {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE services
+			SET price = $1, price_reseller = $2, price_platinum = $3,
+				profit = $4, profit_reseller = $5, profit_platinum = $6, is_profit_fixed = $7,
+				markup_rule_id = NULL,
+				locked_fields = CASE WHEN $8 = ANY(locked_fields) THEN locked_fields
+					ELSE array_append(locked_fields, $8) END,
+				updated_at = NOW()
+			WHERE provider_id = $9
+		`,
+			change.New.Price, change.New.Reseller, change.New.Platinum,
+			config.Profit, config.ProfitReseller, config.ProfitPlatinum, config.IsProfitFixed,
+			LockProfit, change.ProviderID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reprice %s: %w", change.ProviderID, err)
+		}
+	}
+
+	if err := repo.recordPriceChanges(ctx, tx, changes, 0); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
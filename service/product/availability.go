@@ -0,0 +1,116 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Alasan produk sementara tidak bisa dipesan
+const (
+	ReasonCutOff     = "CUT_OFF"
+	ReasonOutOfStock = "OUT_OF_STOCK"
+	ReasonDisabled   = "DISABLED"
+	ReasonInactive   = "INACTIVE"
+)
+
+var ErrProductUnavailable = errors.New("product is temporarily unavailable")
+
+// cutOffLocation adalah zona waktu jam cut-off Digiflazz
+var cutOffLocation = loadCutOffLocation()
+
+func loadCutOffLocation() *time.Location {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		return time.FixedZone("WIB", 7*60*60)
+	}
+	return loc
+}
+
+// Availability is the orderability of a product at a given moment
+type Availability struct {
+	Available bool       `json:"available"`
+	Reason    string     `json:"unavailableReason,omitempty"`
+	ReopenAt  *time.Time `json:"reopenAt,omitempty"`
+}
+
+// Stock holds the supplier fields that decide whether a product can be ordered
+type Stock struct {
+	StartCutOff        string
+	EndCutOff          string
+	Stock              int
+	UnlimitedStock     bool
+	BuyerProductStatus bool
+}
+
+// Check returns the availability at now. Reopen time is only known for the cut-off window.
+func (s Stock) Check(now time.Time) Availability {
+	if !s.BuyerProductStatus {
+		return Availability{Reason: ReasonDisabled}
+	}
+	if !s.UnlimitedStock && s.Stock <= 0 {
+		return Availability{Reason: ReasonOutOfStock}
+	}
+	if reopen, ok := inCutOff(now, s.StartCutOff, s.EndCutOff); ok {
+		return Availability{Reason: ReasonCutOff, ReopenAt: &reopen}
+	}
+	return Availability{Available: true}
+}
+
+// Err returns ErrProductUnavailable wrapped with the reason, or nil when available
+func (a Availability) Err(productCode string) error {
+	if a.Available {
+		return nil
+	}
+	if a.ReopenAt != nil {
+		return fmt.Errorf("%w: product code '%s' (%s, buka kembali %s WIB)", ErrProductUnavailable, productCode, a.Reason, a.ReopenAt.Format("15:04"))
+	}
+	return fmt.Errorf("%w: product code '%s' (%s)", ErrProductUnavailable, productCode, a.Reason)
+}
+
+// inCutOff reports whether now falls inside the daily window [start, end) and when it ends.
+// The window may cross midnight, e.g. 23:45 - 00:15. start == end means no cut-off.
+func inCutOff(now time.Time, start, end string) (time.Time, bool) {
+	startMin, okStart := parseClock(start)
+	endMin, okEnd := parseClock(end)
+	if !okStart || !okEnd || startMin == endMin {
+		return time.Time{}, false
+	}
+
+	local := now.In(cutOffLocation)
+	nowMin := local.Hour()*60 + local.Minute()
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, cutOffLocation)
+
+	if startMin < endMin {
+		if nowMin >= startMin && nowMin < endMin {
+			return midnight.Add(time.Duration(endMin) * time.Minute), true
+		}
+		return time.Time{}, false
+	}
+
+	switch {
+	case nowMin >= startMin:
+		return midnight.AddDate(0, 0, 1).Add(time.Duration(endMin) * time.Minute), true
+	case nowMin < endMin:
+		return midnight.Add(time.Duration(endMin) * time.Minute), true
+	}
+	return time.Time{}, false
+}
+
+func parseClock(value string) (int, bool) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) < 2 {
+		return 0, false
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, false
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, false
+	}
+	return hour*60 + minute, true
+}
@@ -1,8 +1,10 @@
 package product
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/wafi04/backendvazzz/pkg/utils"
@@ -62,9 +64,48 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 		}
 	}
 
+	query := CatalogQuery{
+		CategoryID:    categoryId,
+		SubCategoryID: subCategoryId,
+		Search:        c.Query("search"),
+		Brand:         c.Query("brand"),
+		Type:          c.Query("type"),
+		Sort:          c.Query("sort"),
+		Role:          userRole,
+	}
+	for name, target := range map[string]*int{"minPrice": &query.MinPrice, "maxPrice": &query.MaxPrice} {
+		if value := c.Query(name); value != "" {
+			if *target, err = strconv.Atoi(value); err != nil {
+				utils.ErrorResponse(c, http.StatusBadRequest, "Invalid "+name+" format", err.Error())
+				return
+			}
+		}
+	}
+	for name, target := range map[string]*bool{"flashSale": &query.FlashSale, "inStock": &query.InStock} {
+		if value := c.Query(name); value != "" {
+			if *target, err = strconv.ParseBool(value); err != nil {
+				utils.ErrorResponse(c, http.StatusBadRequest, "Invalid "+name+" format", err.Error())
+				return
+			}
+		}
+	}
+
+	// Tanpa page/limit semua produk dikembalikan seperti sebelumnya
+	paginated := c.Query("page") != "" || c.Query("limit") != ""
+	var pagination utils.PaginationResult
+	if paginated {
+		page, limit := c.DefaultQuery("page", "1"), c.DefaultQuery("limit", "10")
+		pagination = utils.CalculatePagination(&page, &limit)
+		query.Skip, query.Limit = pagination.Skip, pagination.Take
+	}
+
 	// Get products from repository
-	products, err := h.productService.GetAll(categoryId, subCategoryId, userRole)
+	products, total, err := h.productService.GetAll(c.Request.Context(), query)
 	if err != nil {
+		if errors.Is(err, ErrInvalidCatalogQuery) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid product query", err.Error())
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"message": "Failed to fetch products",
@@ -73,5 +114,233 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 		return
 	}
 
+	if paginated {
+		response := utils.CreatePaginatedResponse(products, pagination.CurrentPage, pagination.ItemsPerPage, total)
+		utils.SuccessResponse(c, http.StatusOK, "Product Retreived Successfully", response)
+		return
+	}
 	utils.SuccessResponse(c, http.StatusOK, "Product Retreived Successfully", products)
 }
+
+// PriceHistory returns the price changes of a product for charting, from/to as YYYY-MM-DD
+func (h *ProductHandler) PriceHistory(c *gin.Context) {
+	var from, to time.Time
+	var err error
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err = time.ParseInLocation("2006-01-02", fromStr, time.Local)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid from date, use YYYY-MM-DD", err.Error())
+			return
+		}
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		to, err = time.ParseInLocation("2006-01-02", toStr, time.Local)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid to date, use YYYY-MM-DD", err.Error())
+			return
+		}
+		// Sampai akhir hari
+		to = to.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	history, err := h.productService.PriceHistory(c.Request.Context(), c.Param("providerId"), from, to)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch price history", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Price history retrieved successfully", history)
+}
+
+// PreviewRecalculate shows the price diff of the current markup rules without writing it
+func (h *ProductHandler) PreviewRecalculate(c *gin.Context) {
+	report, err := h.productService.RecalculatePrices(c.Request.Context(), false)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to preview price recalculation", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Price recalculation preview", report)
+}
+
+func (h *ProductHandler) ApplyRecalculate(c *gin.Context) {
+	report, err := h.productService.RecalculatePrices(c.Request.Context(), true)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to recalculate prices", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Prices recalculated successfully", report)
+}
+
+// HeldPriceChanges lists supplier price changes held by the sync price guard, ?status=PENDING by default
+func (h *ProductHandler) HeldPriceChanges(c *gin.Context) {
+	changes, err := h.productService.HeldPriceChanges(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch held price changes", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Held price changes retrieved successfully", changes)
+}
+
+func (h *ProductHandler) ApprovePriceChange(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid price change id", err.Error())
+		return
+	}
+
+	change, err := h.productService.ApprovePriceChange(c.Request.Context(), id, c.GetString("username"))
+	if err != nil {
+		h.writeHeldError(c, "Failed to approve price change", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Price change approved", change)
+}
+
+func (h *ProductHandler) RejectPriceChange(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid price change id", err.Error())
+		return
+	}
+
+	if err := h.productService.RejectPriceChange(c.Request.Context(), id, c.GetString("username")); err != nil {
+		h.writeHeldError(c, "Failed to reject price change", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Price change rejected", nil)
+}
+
+func (h *ProductHandler) writeHeldError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, ErrHeldChangeNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, message, err.Error())
+	case errors.Is(err, ErrHeldChangeReviewed):
+		utils.ErrorResponse(c, http.StatusConflict, message, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
+// AdminList lists every product with purchase price and locks, filter lewat query string
+func (h *ProductHandler) AdminList(c *gin.Context) {
+	page := c.DefaultQuery("page", "1")
+	limit := c.DefaultQuery("limit", "10")
+	paginationResult := utils.CalculatePagination(&page, &limit)
+
+	filter := ProductFilter{
+		Brand:    c.Query("brand"),
+		Provider: c.Query("provider"),
+		Status:   c.Query("status"),
+		Search:   c.Query("search"),
+	}
+	if categoryID := c.Query("categoryId"); categoryID != "" {
+		id, err := strconv.Atoi(categoryID)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid categoryId", err.Error())
+			return
+		}
+		filter.CategoryID = id
+	}
+	if subCategoryID := c.Query("subCategoryId"); subCategoryID != "" {
+		id, err := strconv.Atoi(subCategoryID)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid subCategoryId", err.Error())
+			return
+		}
+		filter.SubCategoryID = id
+	}
+
+	products, total, err := h.productService.AdminList(c.Request.Context(), filter, paginationResult.Skip, paginationResult.Take)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch products", err.Error())
+		return
+	}
+
+	response := utils.CreatePaginatedResponse(products, paginationResult.CurrentPage, paginationResult.ItemsPerPage, total)
+	utils.SuccessResponse(c, http.StatusOK, "Products retrieved successfully", response)
+}
+
+func (h *ProductHandler) AdminGet(c *gin.Context) {
+	product, err := h.productService.AdminGet(c.Request.Context(), c.Param("providerId"))
+	if err != nil {
+		h.writeProductError(c, "Failed to fetch product", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Product retrieved successfully", product)
+}
+
+func (h *ProductHandler) AdminCreate(c *gin.Context) {
+	var req CreateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	product, err := h.productService.AdminCreate(c.Request.Context(), req)
+	if err != nil {
+		h.writeProductError(c, "Failed to create product", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Product created successfully", product)
+}
+
+func (h *ProductHandler) AdminUpdate(c *gin.Context) {
+	var req UpdateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	product, err := h.productService.AdminUpdate(c.Request.Context(), c.Param("providerId"), req)
+	if err != nil {
+		h.writeProductError(c, "Failed to update product", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Product updated successfully", product)
+}
+
+func (h *ProductHandler) AdminDelete(c *gin.Context) {
+	if err := h.productService.AdminDelete(c.Request.Context(), c.Param("providerId")); err != nil {
+		h.writeProductError(c, "Failed to delete product", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Product deleted successfully", nil)
+}
+
+// Bulk activates, deactivates or reprices the products matching the filter
+func (h *ProductHandler) Bulk(c *gin.Context) {
+	var req BulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	result, err := h.productService.Bulk(c.Request.Context(), req)
+	if err != nil {
+		h.writeProductError(c, "Failed to apply bulk action", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Bulk action applied successfully", result)
+}
+
+func (h *ProductHandler) writeProductError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidProduct):
+		utils.ErrorResponse(c, http.StatusBadRequest, message, err.Error())
+	case errors.Is(err, ErrProductNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, message, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, message, err.Error())
+	}
+}
@@ -0,0 +1,306 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/wafi04/backendvazzz/service/markup"
+)
+
+// InactiveBelowCost menandai produk yang dinonaktifkan karena harga jualnya di bawah harga beli
+const InactiveBelowCost = "BELOW_COST"
+
+// Status pending_price_changes
+const (
+	HeldPending    = "PENDING"
+	HeldApproved   = "APPROVED"
+	HeldRejected   = "REJECTED"
+	HeldSuperseded = "SUPERSEDED"
+)
+
+var (
+	ErrHeldChangeNotFound = errors.New("held price change not found")
+	ErrHeldChangeReviewed = errors.New("held price change already reviewed")
+)
+
+// PriceGuard decides which supplier price changes are applied during sync
+type PriceGuard struct {
+	// MaxChangePercent menahan perubahan harga beli di atas persen ini, 0 mematikan guard
+	MaxChangePercent int
+	// DisableBelowCost menonaktifkan SKU yang harga jualnya akan di bawah harga beli
+	DisableBelowCost bool
+}
+
+// holds reports whether the purchase price moved more than the allowed percentage
+func (g PriceGuard) holds(oldPurchase, newPurchase int) (bool, float64) {
+	if g.MaxChangePercent <= 0 || oldPurchase <= 0 || oldPurchase == newPurchase {
+		return false, 0
+	}
+	percent := math.Abs(float64(newPurchase-oldPurchase)) * 100 / float64(oldPurchase)
+	return percent > float64(g.MaxChangePercent), math.Round(percent*100) / 100
+}
+
+// belowCost reports whether any price tier is under the supplier purchase price
+func belowCost(prices PriceSnapshot, purchase int) bool {
+	return prices.Price < purchase || prices.Reseller < purchase || prices.Platinum < purchase
+}
+
+// HeldPriceChange is a supplier price change waiting for an admin decision
+type HeldPriceChange struct {
+	ID            int        `json:"id"`
+	ProviderID    string     `json:"providerId"`
+	ServiceName   string     `json:"serviceName"`
+	SyncJobID     *int       `json:"syncJobId,omitempty"`
+	OldPurchase   int        `json:"oldPricePurchase"`
+	NewPurchase   int        `json:"newPricePurchase"`
+	ChangePercent float64    `json:"changePercent"`
+	Status        string     `json:"status"`
+	ReviewedBy    *string    `json:"reviewedBy,omitempty"`
+	ReviewedAt    *time.Time `json:"reviewedAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// holdPriceChanges queues the held changes of a batch. A price that was already rejected
+// is not queued again while the supplier keeps sending the same price.
+func (repo *ProductRepository) holdPriceChanges(ctx context.Context, tx *sql.Tx, held []HeldPriceChange, syncJobID int) error {
+	if len(held) == 0 {
+		return nil
+	}
+
+	var jobID interface{}
+	if syncJobID > 0 {
+		jobID = syncJobID
+	}
+
+	skus := make([]string, len(held))
+	oldPrices := make([]int64, len(held))
+	newPrices := make([]int64, len(held))
+	percents := make([]float64, len(held))
+	for i, h := range held {
+		skus[i], oldPrices[i], newPrices[i], percents[i] = h.ProviderID, int64(h.OldPurchase), int64(h.NewPurchase), h.ChangePercent
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO pending_price_changes (provider_id, sync_job_id, old_price_purchase, new_price_purchase, change_percent)
+		SELECT p.provider_id, $1, p.old_price, p.new_price, p.change_percent
+		FROM UNNEST($2::text[], $3::int[], $4::int[], $5::numeric[]) AS p(provider_id, old_price, new_price, change_percent)
+		WHERE NOT EXISTS (
+			SELECT 1 FROM pending_price_changes r
+			WHERE r.provider_id = p.provider_id AND r.status = $6 AND r.new_price_purchase = p.new_price
+		)
+		ON CONFLICT (provider_id) WHERE status = 'PENDING' DO UPDATE SET
+			sync_job_id = EXCLUDED.sync_job_id,
+			old_price_purchase = EXCLUDED.old_price_purchase,
+			new_price_purchase = EXCLUDED.new_price_purchase,
+			change_percent = EXCLUDED.change_percent,
+			updated_at = NOW()
+	`, jobID, pq.Array(skus), pq.Array(oldPrices), pq.Array(newPrices), pq.Array(percents), HeldRejected)
+	if err != nil {
+		return fmt.Errorf("failed to hold price changes: %w", err)
+	}
+	return nil
+}
+
+// supersedeHeld closes pending changes of products whose price is back within the limit
+func (repo *ProductRepository) supersedeHeld(ctx context.Context, tx *sql.Tx, skus []string) error {
+	if len(skus) == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `
+		UPDATE pending_price_changes SET status = $1, updated_at = NOW()
+		WHERE status = $2 AND provider_id = ANY($3)
+	`, HeldSuperseded, HeldPending, pq.Array(skus))
+	if err != nil {
+		return fmt.Errorf("failed to supersede held price changes: %w", err)
+	}
+	return nil
+}
+
+const heldColumns = `h.id, h.provider_id, COALESCE(s.service_name, ''), h.sync_job_id, h.old_price_purchase,
+	h.new_price_purchase, h.change_percent, h.status, h.reviewed_by, h.reviewed_at, h.created_at, h.updated_at`
+
+func scanHeld(row interface{ Scan(...interface{}) error }) (*HeldPriceChange, error) {
+	var h HeldPriceChange
+	err := row.Scan(
+		&h.ID, &h.ProviderID, &h.ServiceName, &h.SyncJobID, &h.OldPurchase,
+		&h.NewPurchase, &h.ChangePercent, &h.Status, &h.ReviewedBy, &h.ReviewedAt, &h.CreatedAt, &h.UpdatedAt,
+	)
+	return &h, err
+}
+
+// HeldPriceChanges lists held changes with the given status, newest first
+func (repo *ProductRepository) HeldPriceChanges(ctx context.Context, status string) ([]HeldPriceChange, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT `+heldColumns+`
+		FROM pending_price_changes h
+		LEFT JOIN services s ON s.provider_id = h.provider_id
+		WHERE h.status = $1
+		ORDER BY h.created_at DESC, h.id DESC
+	`, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query held price changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []HeldPriceChange{}
+	for rows.Next() {
+		h, err := scanHeld(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan held price change: %w", err)
+		}
+		changes = append(changes, *h)
+	}
+	return changes, rows.Err()
+}
+
+// lockHeld loads a pending change for review
+func (repo *ProductRepository) lockHeld(ctx context.Context, tx *sql.Tx, id int) (*HeldPriceChange, error) {
+	h, err := scanHeld(tx.QueryRowContext(ctx, `
+		SELECT `+heldColumns+`
+		FROM pending_price_changes h
+		LEFT JOIN services s ON s.provider_id = h.provider_id
+		WHERE h.id = $1
+		FOR UPDATE OF h
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrHeldChangeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load held price change: %w", err)
+	}
+	if h.Status != HeldPending {
+		return nil, fmt.Errorf("%w: %s", ErrHeldChangeReviewed, h.Status)
+	}
+	return h, nil
+}
+
+func (repo *ProductRepository) reviewHeld(ctx context.Context, tx *sql.Tx, id int, status, reviewer string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE pending_price_changes
+		SET status = $1, reviewed_by = $2, reviewed_at = NOW(), updated_at = NOW()
+		WHERE id = $3
+	`, status, reviewer, id)
+	if err != nil {
+		return fmt.Errorf("failed to review held price change: %w", err)
+	}
+	return nil
+}
+
+// ApprovePriceChange applies the held purchase price with the current markup and records it in the history
+func (repo *ProductRepository) ApprovePriceChange(ctx context.Context, id int, reviewer string) (*PriceChange, error) {
+	rules, err := repo.markups.ActiveRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	held, err := repo.lockHeld(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		old     PriceSnapshot
+		stored  ProfitConfig
+		subject markup.Subject
+		locked  []string
+	)
+	err = tx.QueryRowContext(ctx, `
+		SELECT service_name, category_id, COALESCE(sub_category_id, 0), COALESCE(brand, ''),
+			price_purchase, price, price_reseller, price_platinum,
+			profit, profit_reseller, profit_platinum, is_profit_fixed, locked_fields
+		FROM services
+		WHERE provider_id = $1
+		FOR UPDATE
+	`, held.ProviderID).Scan(
+		&held.ServiceName, &subject.CategoryID, &subject.SubCategoryID, &subject.Brand,
+		&old.Purchase, &old.Price, &old.Reseller, &old.Platinum,
+		&stored.Profit, &stored.ProfitReseller, &stored.ProfitPlatinum, &stored.IsProfitFixed,
+		pq.Array(&locked),
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: product %s no longer exists", ErrHeldChangeNotFound, held.ProviderID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load product %s: %w", held.ProviderID, err)
+	}
+
+	subject.SKU = held.ProviderID
+	subject.PurchasePrice = held.NewPurchase
+	config := stored
+	if !isLocked(locked, LockProfit) {
+		config = applyMarkupRule(rules, subject, stored)
+	}
+	price, priceReseller, pricePlatinum, purchase := calculatePrices(held.NewPurchase, config)
+
+	// Produk yang dimatikan karena di bawah harga beli aktif lagi dengan harga baru
+	_, err = tx.ExecContext(ctx, `
+		UPDATE services
+		SET price_purchase = $1, price = $2, price_reseller = $3, price_platinum = $4,
+			profit = $5, profit_reseller = $6, profit_platinum = $7, is_profit_fixed = $8, markup_rule_id = $9,
+			status = CASE WHEN inactive_reason = $10 THEN 'active' ELSE status END,
+			deactivated_at = CASE WHEN inactive_reason = $10 THEN NULL ELSE deactivated_at END,
+			inactive_reason = CASE WHEN inactive_reason = $10 THEN NULL ELSE inactive_reason END,
+			updated_at = NOW()
+		WHERE provider_id = $11
+	`,
+		purchase, price, priceReseller, pricePlatinum,
+		config.Profit, config.ProfitReseller, config.ProfitPlatinum, config.IsProfitFixed, config.RuleID,
+		InactiveBelowCost, held.ProviderID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply held price of %s: %w", held.ProviderID, err)
+	}
+
+	if err := repo.reviewHeld(ctx, tx, id, HeldApproved, reviewer); err != nil {
+		return nil, err
+	}
+
+	change := PriceChange{
+		ProviderID:  held.ProviderID,
+		ServiceName: held.ServiceName,
+		Old:         &old,
+		New:         PriceSnapshot{Purchase: purchase, Price: price, Reseller: priceReseller, Platinum: pricePlatinum},
+	}
+	change.Type = change.ChangeType()
+	if err := repo.recordPriceChanges(ctx, tx, []PriceChange{change}, 0); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &change, nil
+}
+
+// RejectPriceChange keeps the current price; harga yang sama dari supplier tidak ditahan ulang
+func (repo *ProductRepository) RejectPriceChange(ctx context.Context, id int, reviewer string) error {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := repo.lockHeld(ctx, tx, id); err != nil {
+		return err
+	}
+	if err := repo.reviewHeld(ctx, tx, id, HeldRejected, reviewer); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Jenis perubahan harga di service_price_history
+const (
+	PriceNew       = "NEW"
+	PriceIncreased = "INCREASED"
+	PriceDecreased = "DECREASED"
+)
+
+type PriceSnapshot struct {
+	Purchase int `json:"pricePurchase"`
+	Price    int `json:"price"`
+	Reseller int `json:"priceReseller"`
+	Platinum int `json:"pricePlatinum"`
+}
+
+// PriceChange is the result of writing a product from the price list; Old is nil for new products
+type PriceChange struct {
+	ProviderID  string         `json:"providerId"`
+	ServiceName string         `json:"serviceName"`
+	Type        string         `json:"changeType"`
+	Old         *PriceSnapshot `json:"old,omitempty"`
+	New         PriceSnapshot  `json:"new"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	// Reactivated is set when a VANISHED product came back in the price list
+	Reactivated bool `json:"-"`
+	// Held is set when the new purchase price was held for approval, BelowCost when
+	// the product was disabled because it would sell under the purchase price
+	Held      bool `json:"-"`
+	BelowCost bool `json:"-"`
+}
+
+// Changed reports whether the purchase or any selling price differs from before
+func (c *PriceChange) Changed() bool {
+	return c.Old == nil || *c.Old != c.New
+}
+
+// ChangeType classifies the change by purchase price, then by selling price
+func (c *PriceChange) ChangeType() string {
+	switch {
+	case c.Old == nil:
+		return PriceNew
+	case c.New.Purchase > c.Old.Purchase:
+		return PriceIncreased
+	case c.New.Purchase < c.Old.Purchase:
+		return PriceDecreased
+	case c.New.Price < c.Old.Price:
+		return PriceDecreased
+	default:
+		return PriceIncreased
+	}
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// RecordPriceChange writes a history row when the price changed; syncJobID 0 means outside a sync
+func (repo *ProductRepository) RecordPriceChange(ctx context.Context, change *PriceChange, syncJobID int) error {
+	if change == nil {
+		return nil
+	}
+	return repo.recordPriceChanges(ctx, repo.DB, []PriceChange{*change}, syncJobID)
+}
+
+// recordPriceChanges writes the changed prices of a batch in a single INSERT
+func (repo *ProductRepository) recordPriceChanges(ctx context.Context, db execer, changes []PriceChange, syncJobID int) error {
+	var jobID interface{}
+	if syncJobID > 0 {
+		jobID = syncJobID
+	}
+
+	var (
+		values []string
+		args   []interface{}
+	)
+	for _, change := range changes {
+		if !change.Changed() {
+			continue
+		}
+
+		var oldPurchase, oldPrice, oldReseller, oldPlatinum interface{}
+		if change.Old != nil {
+			oldPurchase, oldPrice = change.Old.Purchase, change.Old.Price
+			oldReseller, oldPlatinum = change.Old.Reseller, change.Old.Platinum
+		}
+
+		n := len(args)
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10, n+11))
+		args = append(args,
+			change.ProviderID, jobID, change.ChangeType(),
+			oldPurchase, change.New.Purchase, oldPrice, change.New.Price,
+			oldReseller, change.New.Reseller, oldPlatinum, change.New.Platinum,
+		)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO service_price_history (
+			provider_id, sync_job_id, change_type,
+			old_price_purchase, new_price_purchase, old_price, new_price,
+			old_price_reseller, new_price_reseller, old_price_platinum, new_price_platinum
+		) VALUES `+strings.Join(values, ", "), args...)
+	if err != nil {
+		return fmt.Errorf("failed to record price history: %w", err)
+	}
+	return nil
+}
+
+// PriceChanges returns the history rows matching where, oldest first
+func (repo *ProductRepository) PriceChanges(ctx context.Context, where string, args ...interface{}) ([]PriceChange, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT h.provider_id, COALESCE(s.service_name, ''), h.change_type,
+			h.old_price_purchase, h.new_price_purchase, h.old_price, h.new_price,
+			h.old_price_reseller, h.new_price_reseller, h.old_price_platinum, h.new_price_platinum,
+			h.created_at
+		FROM service_price_history h
+		LEFT JOIN services s ON s.provider_id = h.provider_id
+		`+where+`
+		ORDER BY h.created_at ASC, h.id ASC
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query price history: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []PriceChange{}
+	for rows.Next() {
+		var (
+			change                                          PriceChange
+			oldPurchase, oldPrice, oldReseller, oldPlatinum *int
+		)
+		if err := rows.Scan(
+			&change.ProviderID, &change.ServiceName, &change.Type,
+			&oldPurchase, &change.New.Purchase, &oldPrice, &change.New.Price,
+			&oldReseller, &change.New.Reseller, &oldPlatinum, &change.New.Platinum,
+			&change.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan price history: %w", err)
+		}
+
+		if oldPurchase != nil {
+			change.Old = &PriceSnapshot{Purchase: *oldPurchase}
+			if oldPrice != nil {
+				change.Old.Price = *oldPrice
+			}
+			if oldReseller != nil {
+				change.Old.Reseller = *oldReseller
+			}
+			if oldPlatinum != nil {
+				change.Old.Platinum = *oldPlatinum
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// PriceHistory returns the price changes of one product between from and to
+func (repo *ProductRepository) PriceHistory(ctx context.Context, providerID string, from, to time.Time) ([]PriceChange, error) {
+	return repo.PriceChanges(ctx, `WHERE h.provider_id = $1 AND h.created_at BETWEEN $2 AND $3`, providerID, from, to)
+}
@@ -0,0 +1,131 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/lib/pq"
+	"github.com/wafi04/backendvazzz/service/markup"
+)
+
+// RecalculateReport lists the products whose prices change under the current markup rules
+type RecalculateReport struct {
+	Total   int           `json:"total"`
+	Changed int           `json:"changed"`
+	Applied bool          `json:"applied"`
+	Changes []PriceChange `json:"changes"`
+}
+
+type recalculated struct {
+	change PriceChange
+	config ProfitConfig
+}
+
+// RecalculatePrices re-evaluates the markup rules for every product from its stored purchase
+// price. Without apply it only builds a preview and writes nothing.
+func (repo *ProductRepository) RecalculatePrices(ctx context.Context, apply bool) (*RecalculateReport, error) {
+	// Aturan dibaca langsung, bukan dari cache, supaya preview sesuai aturan terbaru
+	rules, err := repo.markups.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT provider_id, service_name, category_id, COALESCE(sub_category_id, 0), COALESCE(brand, ''),
+			price_purchase, price, price_reseller, price_platinum,
+			profit, profit_reseller, profit_platinum, is_profit_fixed, locked_fields
+		FROM services
+		ORDER BY provider_id ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query products: %w", err)
+	}
+	defer rows.Close()
+
+	report := &RecalculateReport{Changes: []PriceChange{}}
+	var changed []recalculated
+
+	for rows.Next() {
+		var (
+			change  PriceChange
+			old     PriceSnapshot
+			subject markup.Subject
+			stored  ProfitConfig
+			locked  []string
+		)
+		if err := rows.Scan(
+			&change.ProviderID, &change.ServiceName, &subject.CategoryID, &subject.SubCategoryID, &subject.Brand,
+			&old.Purchase, &old.Price, &old.Reseller, &old.Platinum,
+			&stored.Profit, &stored.ProfitReseller, &stored.ProfitPlatinum, &stored.IsProfitFixed,
+			pq.Array(&locked),
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		report.Total++
+
+		// Profit yang diatur manual oleh admin tidak mengikuti aturan markup
+		if isLocked(locked, LockProfit) {
+			continue
+		}
+
+		subject.SKU = change.ProviderID
+		subject.PurchasePrice = old.Purchase
+		config := applyMarkupRule(rules, subject, stored)
+
+		price, priceReseller, pricePlatinum, _ := calculatePrices(old.Purchase, config)
+		change.Old = &old
+		change.New = PriceSnapshot{Purchase: old.Purchase, Price: price, Reseller: priceReseller, Platinum: pricePlatinum}
+		if !change.Changed() {
+			continue
+		}
+
+		change.Type = change.ChangeType()
+		changed = append(changed, recalculated{change: change, config: config})
+		report.Changes = append(report.Changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating products: %w", err)
+	}
+	report.Changed = len(changed)
+
+	if !apply || len(changed) == 0 {
+		return report, nil
+	}
+
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, item := range changed {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE services
+			SET price = $1, price_reseller = $2, price_platinum = $3,
+				profit = $4, profit_reseller = $5, profit_platinum = $6, is_profit_fixed = $7,
+				markup_rule_id = $8, updated_at = NOW()
+			WHERE provider_id = $9
+		`,
+			item.change.New.Price, item.change.New.Reseller, item.change.New.Platinum,
+			item.config.Profit, item.config.ProfitReseller, item.config.ProfitPlatinum, item.config.IsProfitFixed,
+			item.config.RuleID, item.change.ProviderID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update prices of %s: %w", item.change.ProviderID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	report.Applied = true
+
+	for _, item := range changed {
+		if err := repo.RecordPriceChange(ctx, &item.change, 0); err != nil {
+			log.Printf("Failed to record price history: %v", err)
+		}
+	}
+
+	return report, nil
+}
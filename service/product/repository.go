@@ -3,31 +3,79 @@ package product
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
 	"time"
 
-	"github.com/wafi04/backendvazzz/pkg/lib"
 	"github.com/wafi04/backendvazzz/pkg/model"
+	"github.com/wafi04/backendvazzz/service/categorymapping"
+	"github.com/wafi04/backendvazzz/service/markup"
 )
 
 type ProductRepository struct {
-	DB *sql.DB
+	DB       *sql.DB
+	mappings *categorymapping.CategoryMappingRepository
+	markups  *markup.MarkupRepository
 }
 
 func NewProductRepository(db *sql.DB) *ProductRepository {
 	return &ProductRepository{
-		DB: db,
+		DB:       db,
+		mappings: categorymapping.NewCategoryMappingRepository(db),
+		markups:  markup.NewMarkupRepository(db),
 	}
 }
 
-// ProfitConfig holds the default profit configuration
+// ProfitConfig holds the profit configuration of a product
 type ProfitConfig struct {
 	Profit         int
 	ProfitReseller int
 	ProfitPlatinum int
 	IsProfitFixed  string
+	// MinMargin dan Rounding hanya berasal dari aturan markup
+	MinMargin int
+	Rounding  int
+	RuleID    *int
+}
+
+func (config ProfitConfig) toMarkup() markup.Config {
+	mode := markup.ModePercent
+	if config.IsProfitFixed == "active" {
+		mode = markup.ModeFixed
+	}
+	return markup.Config{
+		Mode:           mode,
+		Profit:         config.Profit,
+		ProfitReseller: config.ProfitReseller,
+		ProfitPlatinum: config.ProfitPlatinum,
+		MinMargin:      config.MinMargin,
+		Rounding:       config.Rounding,
+	}
+}
+
+// applyMarkupRule returns the config of the first matching rule, or fallback when none matches
+func applyMarkupRule(rules []markup.Rule, subject markup.Subject, fallback ProfitConfig) ProfitConfig {
+	rule := markup.Select(rules, subject)
+	if rule == nil {
+		return fallback
+	}
+
+	isFixed := "inactive"
+	if rule.Config.Mode == markup.ModeFixed {
+		isFixed = "active"
+	}
+	ruleID := rule.ID
+	return ProfitConfig{
+		Profit:         rule.Config.Profit,
+		ProfitReseller: rule.Config.ProfitReseller,
+		ProfitPlatinum: rule.Config.ProfitPlatinum,
+		IsProfitFixed:  isFixed,
+		MinMargin:      rule.Config.MinMargin,
+		Rounding:       rule.Config.Rounding,
+		RuleID:         &ruleID,
+	}
 }
 
 // getDefaultProfitConfig returns default profit configuration based on category
@@ -57,192 +105,13 @@ func extractProviderFromSKU(buyerSkuCode string) string {
 	return buyerSkuCode
 }
 
-func (repo *ProductRepository) getCategoryAndSubCategory(ctx context.Context, categoryName, brand string) (int, int, error) {
-	var categoryID sql.NullInt64
-
-	queryCat := `SELECT id FROM categories WHERE LOWER(brand) = LOWER($1)`
-	err := repo.DB.QueryRowContext(ctx, queryCat, brand).Scan(&categoryID)
-	if err != nil {
-		queryCatName := `SELECT id FROM categories WHERE LOWER(name) = LOWER($1)`
-		err = repo.DB.QueryRowContext(ctx, queryCatName, categoryName).Scan(&categoryID)
-		if err != nil {
-			return 0, 0, err
-		}
-	}
-	return int(categoryID.Int64), 1, nil
-}
-
 func calculatePrices(basePrice int, config ProfitConfig) (int, int, int, int) {
-	priceFromDigi := basePrice
-
-	var priceReseller, pricePlatinum, price int
-
-	if config.IsProfitFixed == "active" {
-		// Fixed profit
-		priceReseller = priceFromDigi + config.ProfitReseller
-		pricePlatinum = priceFromDigi + config.ProfitPlatinum
-		price = priceFromDigi + config.Profit
-	} else {
-		priceReseller = priceFromDigi + ((priceFromDigi * config.ProfitReseller) / 100)
-		pricePlatinum = priceFromDigi + ((priceFromDigi * config.ProfitPlatinum) / 100)
-		price = priceFromDigi + ((priceFromDigi * config.Profit) / 100)
-	}
-
-	return price, priceReseller, pricePlatinum, priceFromDigi
+	prices := config.toMarkup().Apply(basePrice)
+	return prices.Price, prices.Reseller, prices.Platinum, prices.Purchase
 }
 
-func (repo *ProductRepository) Create(ctx context.Context, req lib.ProductData) error {
-
-	categoryID, subCategoryID, err := repo.getCategoryAndSubCategory(ctx, req.Category, req.Brand)
-	if err != nil {
-		return err
-	}
-
-	profitConfig := getDefaultProfitConfig(req.Category)
-
-	price, priceReseller, pricePlatinum, priceFromDigi := calculatePrices(req.Price, profitConfig)
-
-	query := `
-		INSERT INTO services (
-			service_name,
-			category_id,
-			sub_category_id,
-			price,
-			price_purchase,
-			price_reseller,
-			price_platinum,
-			price_suggest,
-			profit,
-			profit_platinum,
-			profit_reseller,
-			profit_suggest,
-			is_suggest,
-			status,
-			provider_id,
-			provider,
-			note,
-			is_profit_fixed,
-			product_logo,
-			is_flash_sale,
-			created_at,
-			updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, NOW(), NOW())
-	`
-
-	// Determine status based on seller product status
-	status := "inactive"
-	if req.SellerProductStatus {
-		status = "active"
-	}
-
-	// Use description as note
-	note := req.Desc
-	if note == "" {
-		note = ""
-	}
-
-	// Handle NULL sub_category_id
-	var subCategoryParam interface{}
-	if subCategoryID == 0 {
-		subCategoryParam = nil
-	} else {
-		subCategoryParam = subCategoryID
-	}
-
-	_, err = repo.DB.ExecContext(ctx, query,
-		req.ProductName,             // $1 - service_name
-		categoryID,                  // $2 - category_id
-		subCategoryParam,            // $3 - sub_category_id
-		price,                       // $4 - price
-		priceFromDigi,               // $5 - price_from_digi
-		priceReseller,               // $6 - price_reseller
-		pricePlatinum,               // $7 - price_platinum
-		0,                           // $8 - price_suggest
-		profitConfig.Profit,         // $9 - profit
-		profitConfig.ProfitPlatinum, // $10 - profit_platinum
-		profitConfig.ProfitReseller, // $11 - profit_reseller
-		0,                           // $12 - profit_suggest
-		"inactive",                  // $13 - is_suggest
-		status,                      // $14 - status
-		req.BuyerSkuCode,            // $15 - provider_id
-		"digiflazz",                 // $16 - provider
-		note,                        // $17 - note
-		profitConfig.IsProfitFixed,  // $18 - is_profit_fixed
-		nil,                         // $19 - product_logo
-		"inactive",                  // $20 - is_flash_sale
-	)
-
-	return err
-}
-
-func (repo *ProductRepository) UpdatePrice(ctx context.Context, req *lib.ProductData) error {
-	var existingProfit, existingProfitReseller, existingProfitPlatinum int
-	var isProfitFixed string
-
-	queryExisting := `
-		SELECT profit, profit_reseller, profit_platinum, is_profit_fixed 
-		FROM services 
-		WHERE provider_id = $1
-	`
-
-	err := repo.DB.QueryRowContext(ctx, queryExisting, req.BuyerSkuCode).Scan(
-		&existingProfit, &existingProfitReseller, &existingProfitPlatinum, &isProfitFixed,
-	)
-	if err != nil {
-		// If product doesn't exist, fallback to default config
-		profitConfig := getDefaultProfitConfig(req.Category)
-		existingProfit = profitConfig.Profit
-		existingProfitReseller = profitConfig.ProfitReseller
-		existingProfitPlatinum = profitConfig.ProfitPlatinum
-		isProfitFixed = profitConfig.IsProfitFixed
-	}
-
-	// Use existing profit configuration
-	config := ProfitConfig{
-		Profit:         existingProfit,
-		ProfitReseller: existingProfitReseller,
-		ProfitPlatinum: existingProfitPlatinum,
-		IsProfitFixed:  isProfitFixed,
-	}
-
-	// Calculate new prices with existing profit configuration
-	price, priceReseller, pricePlatinum, priceFromDigi := calculatePrices(req.Price, config)
-
-	status := "inactive"
-	if req.SellerProductStatus {
-		status = "active"
-	}
-
-	// Update query - only update price-related fields and status
-	updateQuery := `
-		UPDATE services 
-		SET 
-			price = $1,
-			price_purchase = $2,
-			price_reseller = $3,
-			price_platinum = $4,
-			status = $5,
-			updated_at = $6
-		WHERE provider_id = $7
-	`
-
-	_, err = repo.DB.ExecContext(ctx, updateQuery,
-		price,            // $1
-		priceFromDigi,    // $2
-		priceReseller,    // $3
-		pricePlatinum,    // $4
-		status,           // $5
-		time.Now(),       // $6
-		req.BuyerSkuCode, // $7
-	)
-
-	if err != nil {
-		return err
-	}
-
-	println("DEBUG: Updated prices for product:", req.ProductName, "SKU:", req.BuyerSkuCode)
-	return nil
-}
+// InactiveVanished menandai produk yang dinonaktifkan karena hilang dari price list
+const InactiveVanished = "VANISHED"
 
 func (repo *ProductRepository) GetExistingProductCount(ctx context.Context) (int, error) {
 	var count int
@@ -264,14 +133,83 @@ type ProductWithUserPrice struct {
 	IsFlashSale   string    `json:"isFlashSale"`
 	CreatedAt     time.Time `json:"createdAt"`
 	UpdatedAt     time.Time `json:"updatedAt"`
+	Availability
+}
+
+// Urutan katalog
+const (
+	SortPriceAsc  = "price_asc"
+	SortPriceDesc = "price_desc"
+	SortNameAsc   = "name_asc"
+	SortNameDesc  = "name_desc"
+	SortNewest    = "newest"
+)
+
+var ErrInvalidCatalogQuery = errors.New("invalid catalog query")
+
+// CatalogQuery filters the storefront catalog. Harga dan urutan harga mengikuti harga role.
+type CatalogQuery struct {
+	CategoryID    int
+	SubCategoryID int
+	Search        string
+	Brand         string
+	Type          string
+	MinPrice      int
+	MaxPrice      int
+	FlashSale     bool
+	// InStock hanya melihat status dan stok supplier, bukan jam cut-off
+	InStock bool
+	Sort    string
+	Role    string
+	// Limit 0 berarti semua produk tanpa paging
+	Skip  int
+	Limit int
+}
+
+func (q *CatalogQuery) Validate() error {
+	switch q.Sort {
+	case "":
+		q.Sort = SortPriceAsc
+	case SortPriceAsc, SortPriceDesc, SortNameAsc, SortNameDesc, SortNewest:
+	default:
+		return fmt.Errorf("%w: unknown sort %q", ErrInvalidCatalogQuery, q.Sort)
+	}
+	if q.MinPrice < 0 || q.MaxPrice < 0 {
+		return fmt.Errorf("%w: price range must not be negative", ErrInvalidCatalogQuery)
+	}
+	if q.MaxPrice > 0 && q.MinPrice > q.MaxPrice {
+		return fmt.Errorf("%w: minPrice is greater than maxPrice", ErrInvalidCatalogQuery)
+	}
+	return nil
+}
+
+// priceColumn is the column holding the price the role pays, sama dengan calculateUserPriceAndProfit
+func priceColumn(role string) string {
+	switch strings.ToUpper(role) {
+	case "ADMIN":
+		return "price_purchase"
+	case "PLATINUM":
+		return "price_platinum"
+	case "RESELLER":
+		return "price_reseller"
+	default:
+		return "price"
+	}
 }
 
-func (repo *ProductRepository) GetAll(categoryId, subCategoryID int, role string) ([]ProductWithUserPrice, error) {
+// likePattern escapes the LIKE wildcards of user input and wraps it for a contains match
+func likePattern(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+	return "%" + value + "%"
+}
+
+// GetAll returns the active products matching the query with the total count before paging
+func (repo *ProductRepository) GetAll(ctx context.Context, q CatalogQuery) ([]ProductWithUserPrice, int, error) {
 	baseQuery := `
 	SELECT 
 		service_name,
 		category_id,
-		sub_category_id,
+		COALESCE(sub_category_id, 0),
 		price,
 		price_purchase,
 		price_reseller,
@@ -285,6 +223,11 @@ func (repo *ProductRepository) GetAll(categoryId, subCategoryID int, role string
 		provider_id,
 		product_logo,
 		is_flash_sale,
+		start_cut_off,
+		end_cut_off,
+		stock,
+		unlimited_stock,
+		buyer_product_status,
 		created_at,
 		updated_at
 	FROM services
@@ -292,38 +235,81 @@ func (repo *ProductRepository) GetAll(categoryId, subCategoryID int, role string
 
 	var conditions []string
 	var args []interface{}
-	argIndex := 1
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	// Hanya ambil yang status = active
+	conditions = append(conditions, "status = 'active'")
+
+	if q.CategoryID > 0 {
+		add("category_id = $%d", q.CategoryID)
+	}
+	if q.SubCategoryID > 0 {
+		add("sub_category_id = $%d", q.SubCategoryID)
+	}
+	if search := strings.TrimSpace(q.Search); search != "" {
+		add("(service_name ILIKE $%[1]d OR provider_id ILIKE $%[1]d)", likePattern(search))
+	}
+	if brand := strings.TrimSpace(q.Brand); brand != "" {
+		add("LOWER(brand) = LOWER($%d)", brand)
+	}
+	if productType := strings.TrimSpace(q.Type); productType != "" {
+		add("LOWER(product_type) = LOWER($%d)", productType)
+	}
 
-	if categoryId > 0 {
-		conditions = append(conditions, fmt.Sprintf("category_id = $%d", argIndex))
-		args = append(args, categoryId)
-		argIndex++
+	price := priceColumn(q.Role)
+	if q.MinPrice > 0 {
+		add(price+" >= $%d", q.MinPrice)
+	}
+	if q.MaxPrice > 0 {
+		add(price+" <= $%d", q.MaxPrice)
+	}
+	if q.FlashSale {
+		conditions = append(conditions, "is_flash_sale = 'active'")
+	}
+	if q.InStock {
+		conditions = append(conditions, "buyer_product_status AND (unlimited_stock OR stock > 0)")
 	}
 
-	if subCategoryID > 0 {
-		conditions = append(conditions, fmt.Sprintf("sub_category_id = $%d", argIndex))
-		args = append(args, subCategoryID)
-		argIndex++
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	total := -1
+	if q.Limit > 0 {
+		if err := repo.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM services"+where, args...).Scan(&total); err != nil {
+			return nil, 0, fmt.Errorf("failed to count products: %w", err)
+		}
 	}
 
-	// Hanya ambil yang status = active
-	conditions = append(conditions, "status = 'active'")
+	// id sebagai tie-breaker supaya paging stabil
+	orderBy := map[string]string{
+		SortPriceAsc:  price + " ASC, id ASC",
+		SortPriceDesc: price + " DESC, id ASC",
+		SortNameAsc:   "service_name ASC, id ASC",
+		SortNameDesc:  "service_name DESC, id ASC",
+		SortNewest:    "created_at DESC, id DESC",
+	}[q.Sort]
+	if orderBy == "" {
+		orderBy = price + " ASC, id ASC"
+	}
 
 	// Final query
-	query := baseQuery
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
+	query := baseQuery + where + " ORDER BY " + orderBy
+	if q.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+		args = append(args, q.Limit, q.Skip)
 	}
-	query += " ORDER BY price ASC"
 
 	// Query ke DB
-	rows, err := repo.DB.Query(query, args...)
+	rows, err := repo.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %w", err)
+		return nil, 0, fmt.Errorf("failed to execute query: %w", err)
 	}
 	defer rows.Close()
 
-	var products []ProductWithUserPrice
+	products := []ProductWithUserPrice{}
+	now := time.Now()
 
 	for rows.Next() {
 		var (
@@ -338,6 +324,7 @@ func (repo *ProductRepository) GetAll(categoryId, subCategoryID int, role string
 			providerId                             string
 			productLogo                            *string
 			isFlashSale                            string
+			stock                                  Stock
 			createdAt, updatedAt                   time.Time
 		)
 
@@ -358,11 +345,16 @@ func (repo *ProductRepository) GetAll(categoryId, subCategoryID int, role string
 			&providerId,
 			&productLogo,
 			&isFlashSale,
+			&stock.StartCutOff,
+			&stock.EndCutOff,
+			&stock.Stock,
+			&stock.UnlimitedStock,
+			&stock.BuyerProductStatus,
 			&createdAt,
 			&updatedAt,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+			return nil, 0, fmt.Errorf("failed to scan row: %w", err)
 		}
 
 		// Hitung user price berdasarkan role
@@ -376,7 +368,7 @@ func (repo *ProductRepository) GetAll(categoryId, subCategoryID int, role string
 			ProfitReseller: profitReseller,
 		}
 
-		userPrice, userProfit := repo.calculateUserPriceAndProfit(product, role)
+		userPrice, userProfit := repo.calculateUserPriceAndProfit(product, q.Role)
 
 		var suggestPrice *int
 		if isSuggest == "active" {
@@ -394,16 +386,20 @@ func (repo *ProductRepository) GetAll(categoryId, subCategoryID int, role string
 			ProvideId:     providerId,
 			ProductLogo:   productLogo,
 			IsFlashSale:   isFlashSale,
+			Availability:  stock.Check(now),
 			CreatedAt:     createdAt,
 			UpdatedAt:     updatedAt,
 		})
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
+		return nil, 0, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	return products, nil
+	if total < 0 {
+		total = len(products)
+	}
+	return products, total, nil
 }
 
 func (repo *ProductRepository) calculateUserPriceAndProfit(product model.Services, role string) (int, int) {
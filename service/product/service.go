@@ -1,5 +1,11 @@
 package product
 
+import (
+	"context"
+	"strings"
+	"time"
+)
+
 type ProductService struct {
 	productRepo *ProductRepository
 }
@@ -10,6 +16,74 @@ func NewProductService(repo *ProductRepository) *ProductService {
 	}
 }
 
-func (ser *ProductService) GetAll(categoryId int, subCategoryID int, role string) ([]ProductWithUserPrice, error) {
-	return ser.productRepo.GetAll(categoryId, subCategoryID, role)
+func (ser *ProductService) GetAll(ctx context.Context, query CatalogQuery) ([]ProductWithUserPrice, int, error) {
+	if err := query.Validate(); err != nil {
+		return nil, 0, err
+	}
+	return ser.productRepo.GetAll(ctx, query)
+}
+
+// PriceHistory defaults to the last 30 days when the range is not given
+func (ser *ProductService) PriceHistory(ctx context.Context, providerID string, from, to time.Time) ([]PriceChange, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -30)
+	}
+	return ser.productRepo.PriceHistory(ctx, providerID, from, to)
+}
+
+// RecalculatePrices previews the price changes from the markup rules, or applies them
+func (ser *ProductService) RecalculatePrices(ctx context.Context, apply bool) (*RecalculateReport, error) {
+	return ser.productRepo.RecalculatePrices(ctx, apply)
+}
+
+// HeldPriceChanges lists held supplier price changes, pending ones by default
+func (ser *ProductService) HeldPriceChanges(ctx context.Context, status string) ([]HeldPriceChange, error) {
+	if status == "" {
+		status = HeldPending
+	}
+	return ser.productRepo.HeldPriceChanges(ctx, strings.ToUpper(status))
+}
+
+func (ser *ProductService) ApprovePriceChange(ctx context.Context, id int, reviewer string) (*PriceChange, error) {
+	return ser.productRepo.ApprovePriceChange(ctx, id, reviewer)
+}
+
+func (ser *ProductService) RejectPriceChange(ctx context.Context, id int, reviewer string) error {
+	return ser.productRepo.RejectPriceChange(ctx, id, reviewer)
+}
+
+func (ser *ProductService) AdminList(ctx context.Context, filter ProductFilter, skip, limit int) ([]AdminProduct, int, error) {
+	return ser.productRepo.AdminList(ctx, filter, skip, limit)
+}
+
+func (ser *ProductService) AdminGet(ctx context.Context, providerID string) (*AdminProduct, error) {
+	return ser.productRepo.AdminGet(ctx, providerID)
+}
+
+func (ser *ProductService) AdminCreate(ctx context.Context, req CreateProductRequest) (*AdminProduct, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return ser.productRepo.AdminCreate(ctx, req)
+}
+
+func (ser *ProductService) AdminUpdate(ctx context.Context, providerID string, req UpdateProductRequest) (*AdminProduct, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return ser.productRepo.AdminUpdate(ctx, providerID, req)
+}
+
+func (ser *ProductService) AdminDelete(ctx context.Context, providerID string) error {
+	return ser.productRepo.AdminDelete(ctx, providerID)
+}
+
+func (ser *ProductService) Bulk(ctx context.Context, req BulkRequest) (*BulkResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return ser.productRepo.Bulk(ctx, req)
 }
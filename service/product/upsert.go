@@ -0,0 +1,326 @@
+package product
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/wafi04/backendvazzz/pkg/lib"
+	"github.com/wafi04/backendvazzz/service/categorymapping"
+	"github.com/wafi04/backendvazzz/service/markup"
+)
+
+// SyncSession holds the lookups shared by every batch of one sync run
+type SyncSession struct {
+	resolver *categorymapping.Resolver
+	rules    []markup.Rule
+	guard    PriceGuard
+}
+
+// NewSyncSession loads the category mapping and markup rules once for a sync run
+func (repo *ProductRepository) NewSyncSession(ctx context.Context, guard PriceGuard) (*SyncSession, error) {
+	resolver, err := repo.mappings.NewResolver(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := repo.markups.ActiveRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &SyncSession{resolver: resolver, rules: rules, guard: guard}, nil
+}
+
+// UpsertError is a product of the batch that was not written
+type UpsertError struct {
+	SKU         string
+	ProductName string
+	Err         error
+}
+
+// UpsertResult lists one change per written product; Old is nil for new products
+type UpsertResult struct {
+	Changes []PriceChange
+	Errors  []UpsertError
+}
+
+type existingProduct struct {
+	config         ProfitConfig
+	prices         PriceSnapshot
+	inactiveReason sql.NullString
+	categoryID     int
+	subCategoryID  int
+	locked         []string
+}
+
+type upsertRow struct {
+	product        *lib.ProductData
+	categoryID     int
+	subCategoryID  int
+	config         ProfitConfig
+	prices         PriceSnapshot
+	status         string
+	inactiveReason *string
+	held           *HeldPriceChange
+}
+
+// upsertColumns urutannya harus sama dengan args di UpsertBatch
+const upsertColumns = 29
+
+// UpsertBatch writes a batch of price list products with one multi-row INSERT ... ON CONFLICT
+// and records their price history in the same transaction. Existing products only get their
+// price, status, cut-off, stock and profit updated; category, name and logo are left alone.
+func (repo *ProductRepository) UpsertBatch(ctx context.Context, session *SyncSession, products []*lib.ProductData, syncJobID int) (*UpsertResult, error) {
+	result := &UpsertResult{}
+
+	// SKU yang sama dua kali di satu INSERT ... ON CONFLICT ditolak Postgres
+	seen := make(map[string]bool, len(products))
+	batch := make([]*lib.ProductData, 0, len(products))
+	for _, p := range products {
+		switch {
+		case p == nil || p.BuyerSkuCode == "":
+			result.Errors = append(result.Errors, UpsertError{Err: fmt.Errorf("product without SKU")})
+		case seen[p.BuyerSkuCode]:
+			result.Errors = append(result.Errors, UpsertError{
+				SKU:         p.BuyerSkuCode,
+				ProductName: p.ProductName,
+				Err:         fmt.Errorf("duplicate SKU in price list"),
+			})
+		default:
+			seen[p.BuyerSkuCode] = true
+			batch = append(batch, p)
+		}
+	}
+	if len(batch) == 0 {
+		return result, nil
+	}
+
+	existing, err := repo.existingProducts(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]upsertRow, 0, len(batch))
+	for _, p := range batch {
+		row := upsertRow{product: p, status: "inactive"}
+		if p.SellerProductStatus {
+			row.status = "active"
+		}
+
+		// Aturan markup yang cocok menang, selain itu pakai profit yang tersimpan di produk
+		fallback := getDefaultProfitConfig(p.Category)
+		profitLocked := false
+		if old, ok := existing[p.BuyerSkuCode]; ok {
+			row.categoryID, row.subCategoryID = old.categoryID, old.subCategoryID
+			fallback = old.config
+			profitLocked = isLocked(old.locked, LockProfit)
+		} else {
+			target, err := session.resolver.Resolve(ctx, *p)
+			if err != nil {
+				result.Errors = append(result.Errors, UpsertError{SKU: p.BuyerSkuCode, ProductName: p.ProductName, Err: err})
+				continue
+			}
+			row.categoryID, row.subCategoryID = target.CategoryID, target.SubCategoryID
+		}
+
+		// Profit yang diatur manual oleh admin tidak ditimpa aturan markup
+		row.config = fallback
+		if !profitLocked {
+			row.config = applyMarkupRule(session.rules, markup.Subject{
+				CategoryID:    row.categoryID,
+				SubCategoryID: row.subCategoryID,
+				Brand:         p.Brand,
+				SKU:           p.BuyerSkuCode,
+				PurchasePrice: p.Price,
+			}, fallback)
+		}
+		row.prices.Price, row.prices.Reseller, row.prices.Platinum, row.prices.Purchase = calculatePrices(p.Price, row.config)
+
+		// Lonjakan harga beli yang tidak wajar ditahan, produk tetap memakai harga lama
+		if old, ok := existing[p.BuyerSkuCode]; ok {
+			if hold, percent := session.guard.holds(old.prices.Purchase, p.Price); hold {
+				row.config, row.prices = old.config, old.prices
+				row.held = &HeldPriceChange{
+					ProviderID:    p.BuyerSkuCode,
+					OldPurchase:   old.prices.Purchase,
+					NewPurchase:   p.Price,
+					ChangePercent: percent,
+				}
+			}
+		}
+
+		if session.guard.DisableBelowCost && belowCost(row.prices, p.Price) {
+			reason := InactiveBelowCost
+			row.status, row.inactiveReason = "inactive", &reason
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return result, nil
+	}
+
+	values := make([]string, 0, len(rows))
+	args := make([]interface{}, 0, len(rows)*upsertColumns)
+	var (
+		held    []HeldPriceChange
+		applied []string
+	)
+	for i, row := range rows {
+		p := row.product
+
+		// Handle NULL sub_category_id
+		var subCategoryParam interface{}
+		if row.subCategoryID != 0 {
+			subCategoryParam = row.subCategoryID
+		}
+
+		placeholders := make([]string, upsertColumns)
+		for j := range placeholders {
+			placeholders[j] = fmt.Sprintf("$%d", i*upsertColumns+j+1)
+		}
+		values = append(values, "("+strings.Join(placeholders, ", ")+", NOW(), NOW())")
+		args = append(args,
+			p.ProductName, row.categoryID, subCategoryParam,
+			row.prices.Price, row.prices.Purchase, row.prices.Reseller, row.prices.Platinum, 0,
+			row.config.Profit, row.config.ProfitPlatinum, row.config.ProfitReseller, 0, "inactive",
+			row.status, p.BuyerSkuCode, "digiflazz", p.Desc, row.config.IsProfitFixed, nil, "inactive",
+			p.StartCutOff, p.EndCutOff, p.Stock, p.UnlimitedStock, p.BuyerProductStatus,
+			p.Brand, row.config.RuleID, row.inactiveReason, p.Type,
+		)
+
+		change := PriceChange{
+			ProviderID:  p.BuyerSkuCode,
+			ServiceName: p.ProductName,
+			New:         row.prices,
+			Held:        row.held != nil,
+			BelowCost:   row.inactiveReason != nil,
+		}
+		if old, ok := existing[p.BuyerSkuCode]; ok {
+			prices := old.prices
+			change.Old = &prices
+			change.Reactivated = old.inactiveReason.String == InactiveVanished && row.status == "active"
+
+			if row.held != nil {
+				held = append(held, *row.held)
+			} else {
+				applied = append(applied, p.BuyerSkuCode)
+			}
+		}
+		result.Changes = append(result.Changes, change)
+	}
+
+	// Produk yang muncul lagi di price list tidak lagi dianggap VANISHED, dan produk yang
+	// harganya sudah tidak di bawah harga beli tidak lagi dianggap BELOW_COST. Status yang
+	// dikunci admin dipertahankan, kecuali produk harus dimatikan karena di bawah harga beli.
+	// Nama dan catatan ikut diperbarui dari price list selama tidak dikunci admin.
+	query := `
+		INSERT INTO services (
+			service_name, category_id, sub_category_id,
+			price, price_purchase, price_reseller, price_platinum, price_suggest,
+			profit, profit_platinum, profit_reseller, profit_suggest, is_suggest,
+			status, provider_id, provider, note, is_profit_fixed, product_logo, is_flash_sale,
+			start_cut_off, end_cut_off, stock, unlimited_stock, buyer_product_status,
+			brand, markup_rule_id, inactive_reason, product_type,
+			created_at, updated_at
+		) VALUES ` + strings.Join(values, ", ") + `
+		ON CONFLICT (provider_id) DO UPDATE SET
+			service_name = CASE
+				WHEN '` + LockServiceName + `' = ANY(services.locked_fields) THEN services.service_name
+				ELSE EXCLUDED.service_name END,
+			note = CASE
+				WHEN '` + LockNote + `' = ANY(services.locked_fields) THEN services.note
+				ELSE EXCLUDED.note END,
+			price = EXCLUDED.price,
+			price_purchase = EXCLUDED.price_purchase,
+			price_reseller = EXCLUDED.price_reseller,
+			price_platinum = EXCLUDED.price_platinum,
+			status = CASE
+				WHEN EXCLUDED.inactive_reason IS NULL AND '` + LockStatus + `' = ANY(services.locked_fields) THEN services.status
+				ELSE EXCLUDED.status END,
+			updated_at = NOW(),
+			start_cut_off = EXCLUDED.start_cut_off,
+			end_cut_off = EXCLUDED.end_cut_off,
+			stock = EXCLUDED.stock,
+			unlimited_stock = EXCLUDED.unlimited_stock,
+			buyer_product_status = EXCLUDED.buyer_product_status,
+			inactive_reason = CASE
+				WHEN EXCLUDED.inactive_reason IS NOT NULL THEN EXCLUDED.inactive_reason
+				WHEN '` + LockStatus + `' = ANY(services.locked_fields) THEN services.inactive_reason
+				WHEN services.inactive_reason IN ('` + InactiveVanished + `', '` + InactiveBelowCost + `') THEN NULL
+				ELSE services.inactive_reason END,
+			deactivated_at = CASE
+				WHEN EXCLUDED.inactive_reason IS NOT NULL THEN COALESCE(services.deactivated_at, NOW())
+				WHEN '` + LockStatus + `' = ANY(services.locked_fields) THEN services.deactivated_at
+				WHEN services.inactive_reason IN ('` + InactiveVanished + `', '` + InactiveBelowCost + `') THEN NULL
+				ELSE services.deactivated_at END,
+			profit = EXCLUDED.profit,
+			profit_reseller = EXCLUDED.profit_reseller,
+			profit_platinum = EXCLUDED.profit_platinum,
+			is_profit_fixed = EXCLUDED.is_profit_fixed,
+			brand = EXCLUDED.brand,
+			product_type = EXCLUDED.product_type,
+			markup_rule_id = EXCLUDED.markup_rule_id
+	`
+
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to upsert products: %w", err)
+	}
+	if err := repo.recordPriceChanges(ctx, tx, result.Changes, syncJobID); err != nil {
+		return nil, err
+	}
+	if err := repo.holdPriceChanges(ctx, tx, held, syncJobID); err != nil {
+		return nil, err
+	}
+	if err := repo.supersedeHeld(ctx, tx, applied); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// existingProducts loads the stored profit, prices and category of the batch in one query
+func (repo *ProductRepository) existingProducts(ctx context.Context, products []*lib.ProductData) (map[string]existingProduct, error) {
+	skus := make([]string, len(products))
+	for i, p := range products {
+		skus[i] = p.BuyerSkuCode
+	}
+
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT provider_id, profit, profit_reseller, profit_platinum, is_profit_fixed,
+			markup_rule_id, price_purchase, price, price_reseller, price_platinum, inactive_reason,
+			category_id, COALESCE(sub_category_id, 0), locked_fields
+		FROM services
+		WHERE provider_id = ANY($1)
+	`, pq.Array(skus))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query existing products: %w", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]existingProduct, len(products))
+	for rows.Next() {
+		var (
+			providerID string
+			p          existingProduct
+		)
+		if err := rows.Scan(
+			&providerID, &p.config.Profit, &p.config.ProfitReseller, &p.config.ProfitPlatinum, &p.config.IsProfitFixed,
+			&p.config.RuleID, &p.prices.Purchase, &p.prices.Price, &p.prices.Reseller, &p.prices.Platinum, &p.inactiveReason,
+			&p.categoryID, &p.subCategoryID, pq.Array(&p.locked),
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan existing product: %w", err)
+		}
+		existing[providerID] = p
+	}
+	return existing, rows.Err()
+}
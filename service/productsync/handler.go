@@ -0,0 +1,116 @@
+package productsync
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wafi04/backendvazzz/pkg/utils"
+)
+
+type ProductSyncHandler struct {
+	service *ProductSyncService
+}
+
+func NewProductSyncHandler(service *ProductSyncService) *ProductSyncHandler {
+	return &ProductSyncHandler{
+		service: service,
+	}
+}
+
+func (h *ProductSyncHandler) Start(c *gin.Context) {
+	job, err := h.service.Start(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, ErrSyncInProgress) {
+			utils.ErrorResponse(c, http.StatusConflict, "Sync already in progress", err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to start sync", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusAccepted, "Sync started", job)
+}
+
+func (h *ProductSyncHandler) List(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	jobs, err := h.service.List(c.Request.Context(), limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch sync jobs", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sync jobs retrieved successfully", jobs)
+}
+
+func (h *ProductSyncHandler) Get(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid job id", err.Error())
+		return
+	}
+
+	job, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, ErrJobNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Sync job not found", err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch sync job", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sync job retrieved successfully", job)
+}
+
+func (h *ProductSyncHandler) Report(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid job id", err.Error())
+		return
+	}
+
+	report, err := h.service.Report(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, ErrJobNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "Sync job not found", err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to build sync report", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sync report retrieved successfully", report)
+}
+
+func (h *ProductSyncHandler) GetSchedule(c *gin.Context) {
+	schedule, err := h.service.GetSchedule(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch sync schedule", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sync schedule retrieved successfully", schedule)
+}
+
+func (h *ProductSyncHandler) UpdateSchedule(c *gin.Context) {
+	var req ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	schedule, err := h.service.UpdateSchedule(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidSchedule) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid sync schedule", err.Error())
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update sync schedule", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sync schedule updated successfully", schedule)
+}
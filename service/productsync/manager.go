@@ -0,0 +1,71 @@
+package productsync
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/wafi04/backendvazzz/pkg/config"
+	"github.com/wafi04/backendvazzz/pkg/lib"
+)
+
+// SyncManager controls the automatic product sync. The schedule and status live in the
+// database behind an advisory lock, so every API replica sees the same state.
+type SyncManager struct {
+	Digi *lib.DigiflazzService
+	Jobs *ProductSyncRepository
+	Db   *sql.DB
+}
+
+// Global sync manager instance with proper initialization
+var (
+	GlobalSyncManager *SyncManager
+	SyncManagerMutex  sync.Mutex
+)
+
+// NewSyncManager creates a new sync manager
+func NewSyncManager(db *sql.DB, digi *lib.DigiflazzService, syncCfg config.SyncConfig) *SyncManager {
+	return &SyncManager{
+		Digi: digi,
+		Jobs: NewProductSyncRepository(db, digi, syncCfg),
+		Db:   db,
+	}
+}
+
+// GetGlobalSyncManager returns the global sync manager instance
+func GetGlobalSyncManager() *SyncManager {
+	SyncManagerMutex.Lock()
+	defer SyncManagerMutex.Unlock()
+	return GlobalSyncManager
+}
+
+// SetGlobalSyncManager sets the global sync manager instance
+func SetGlobalSyncManager(sm *SyncManager) {
+	SyncManagerMutex.Lock()
+	defer SyncManagerMutex.Unlock()
+	GlobalSyncManager = sm
+}
+
+// Start enables the scheduled sync on every instance
+func (sm *SyncManager) Start(ctx context.Context) (*Schedule, error) {
+	return sm.Jobs.SetScheduleEnabled(ctx, true)
+}
+
+// Stop disables the scheduled sync on every instance; job yang sedang berjalan tetap diselesaikan
+func (sm *SyncManager) Stop(ctx context.Context) (*Schedule, error) {
+	return sm.Jobs.SetScheduleEnabled(ctx, false)
+}
+
+// Status returns the schedule and the sync running on any instance
+func (sm *SyncManager) Status(ctx context.Context) (*ClusterStatus, error) {
+	return sm.Jobs.Status(ctx)
+}
+
+// ManualSync starts a manual sync job in the background and returns its id
+func (sm *SyncManager) ManualSync(ctx context.Context) (int, error) {
+	job, err := sm.Jobs.Start(ctx, TriggerManual)
+	if err != nil {
+		return 0, err
+	}
+	return job.ID, nil
+}
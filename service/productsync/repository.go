@@ -0,0 +1,504 @@
+package productsync
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/wafi04/backendvazzz/pkg/config"
+	"github.com/wafi04/backendvazzz/pkg/lib"
+	"github.com/wafi04/backendvazzz/service/product"
+)
+
+// Pemicu sinkronisasi
+const (
+	TriggerManual    = "manual"
+	TriggerScheduled = "scheduled"
+)
+
+// Status sync_jobs
+const (
+	StatusRunning = "RUNNING"
+	StatusSuccess = "SUCCESS"
+	StatusFailed  = "FAILED"
+)
+
+var (
+	ErrSyncInProgress = errors.New("sync is already in progress")
+	ErrJobNotFound    = errors.New("sync job not found")
+)
+
+// batchSize adalah jumlah produk per upsert multi-row dan per transaksi
+const batchSize = 100
+
+const syncTimeout = 5 * time.Minute
+
+// SyncJob is one sync run; FetchMs, UpsertMs and DurationMs are timings in milliseconds
+type SyncJob struct {
+	ID          int             `json:"id"`
+	Trigger     string          `json:"trigger"`
+	Status      string          `json:"status"`
+	Fetched     int             `json:"fetched"`
+	Created     int             `json:"created"`
+	Updated     int             `json:"updated"`
+	Failed      int             `json:"failed"`
+	Deactivated int             `json:"deactivated"`
+	Reactivated int             `json:"reactivated"`
+	Held        int             `json:"held"`
+	BelowCost   int             `json:"belowCost"`
+	Instance    string          `json:"instance,omitempty"`
+	FetchMs     int64           `json:"fetchMs"`
+	UpsertMs    int64           `json:"upsertMs"`
+	DurationMs  int64           `json:"durationMs"`
+	Error       *string         `json:"error,omitempty"`
+	StartedAt   time.Time       `json:"startedAt"`
+	FinishedAt  *time.Time      `json:"finishedAt,omitempty"`
+	Errors      []SyncItemError `json:"errors,omitempty"`
+
+	// runStart memakai jam proses, bukan started_at dari database yang bisa beda zona waktu
+	runStart time.Time
+	lock     *sql.Conn
+	// batchFailed counts batches whose whole transaction failed, not single bad items
+	batchFailed int
+}
+
+type SyncItemError struct {
+	SKU         string    `json:"sku"`
+	ProductName string    `json:"productName"`
+	Message     string    `json:"message"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type ProductSyncRepository struct {
+	DB       *sql.DB
+	digi     *lib.DigiflazzService
+	products *product.ProductRepository
+	config   config.SyncConfig
+}
+
+func NewProductSyncRepository(db *sql.DB, digi *lib.DigiflazzService, cfg config.SyncConfig) *ProductSyncRepository {
+	return &ProductSyncRepository{
+		DB:       db,
+		digi:     digi,
+		products: product.NewProductRepository(db),
+		config:   cfg,
+	}
+}
+
+// Start records a new job and runs it in the background
+func (repo *ProductSyncRepository) Start(ctx context.Context, trigger string) (*SyncJob, error) {
+	job, err := repo.begin(ctx, trigger)
+	if err != nil {
+		return nil, err
+	}
+
+	go repo.run(job)
+	return job, nil
+}
+
+// begin takes the cluster-wide sync lock and records a new job. run releases the lock.
+func (repo *ProductSyncRepository) begin(ctx context.Context, trigger string) (*SyncJob, error) {
+	lock, err := repo.acquireLock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lock sudah dipegang, jadi job RUNNING yang tersisa milik instance yang mati di tengah sync
+	if _, err := repo.DB.ExecContext(ctx, `
+		UPDATE sync_jobs
+		SET status = $1, error = 'interrupted: instance stopped before the job finished', finished_at = NOW()
+		WHERE status = $2
+	`, StatusFailed, StatusRunning); err != nil {
+		repo.releaseLock(lock)
+		return nil, fmt.Errorf("failed to close interrupted sync jobs: %w", err)
+	}
+
+	job := &SyncJob{Trigger: trigger, Status: StatusRunning, Instance: instanceName(), runStart: time.Now(), lock: lock}
+	err = repo.DB.QueryRowContext(ctx, `
+		INSERT INTO sync_jobs (trigger, status, instance) VALUES ($1, $2, $3)
+		RETURNING id, started_at
+	`, trigger, StatusRunning, job.Instance).Scan(&job.ID, &job.StartedAt)
+	if err != nil {
+		repo.releaseLock(lock)
+		return nil, fmt.Errorf("failed to create sync job: %w", err)
+	}
+
+	return job, nil
+}
+
+func (repo *ProductSyncRepository) run(job *SyncJob) {
+	defer repo.releaseLock(job.lock)
+
+	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+	defer cancel()
+
+	log.Printf("Starting product sync job %d (%s)...", job.ID, job.Trigger)
+
+	fetchStart := time.Now()
+	products, err := repo.digi.CheckPrice()
+	job.FetchMs = time.Since(fetchStart).Milliseconds()
+	if err != nil {
+		repo.finish(job, fmt.Errorf("failed to fetch price list: %w", err))
+		return
+	}
+	job.Fetched = len(products)
+
+	session, err := repo.products.NewSyncSession(ctx, product.PriceGuard{
+		MaxChangePercent: repo.config.MaxPriceChangePercent,
+		DisableBelowCost: repo.config.DisableBelowCost,
+	})
+	if err != nil {
+		repo.finish(job, fmt.Errorf("failed to prepare sync: %w", err))
+		return
+	}
+
+	upsertStart := time.Now()
+	for i := 0; i < len(products); i += batchSize {
+		if ctx.Err() != nil {
+			job.UpsertMs = time.Since(upsertStart).Milliseconds()
+			repo.finish(job, fmt.Errorf("sync cancelled due to timeout"))
+			return
+		}
+
+		end := i + batchSize
+		if end > len(products) {
+			end = len(products)
+		}
+
+		itemErrors := repo.syncBatch(ctx, job, session, products[i:end])
+		if err := repo.saveProgress(ctx, job, itemErrors); err != nil {
+			log.Printf("Failed to save progress of sync job %d: %v", job.ID, err)
+		}
+	}
+	job.UpsertMs = time.Since(upsertStart).Milliseconds()
+
+	// Hanya dijalankan setelah seluruh price list berhasil diambil dan diproses
+	if err := repo.deactivateVanished(ctx, job, products); err != nil {
+		log.Printf("Failed to deactivate vanished products of sync job %d: %v", job.ID, err)
+	}
+
+	repo.finish(job, nil)
+}
+
+// syncBatch upserts one batch and updates the job counters; when the batch transaction
+// fails, every product in the batch is counted as failed
+func (repo *ProductSyncRepository) syncBatch(ctx context.Context, job *SyncJob, session *product.SyncSession, batch []*lib.ProductData) []SyncItemError {
+	result, err := repo.products.UpsertBatch(ctx, session, batch, job.ID)
+	if err != nil {
+		job.batchFailed++
+		itemErrors := make([]SyncItemError, 0, len(batch))
+		for _, p := range batch {
+			job.Failed++
+			if p == nil {
+				continue
+			}
+			itemErrors = append(itemErrors, SyncItemError{
+				SKU:         p.BuyerSkuCode,
+				ProductName: p.ProductName,
+				Message:     err.Error(),
+			})
+		}
+		return itemErrors
+	}
+
+	for _, change := range result.Changes {
+		if change.Old == nil {
+			job.Created++
+		} else {
+			job.Updated++
+		}
+		if change.Reactivated {
+			job.Reactivated++
+		}
+		if change.Held {
+			job.Held++
+		}
+		if change.BelowCost {
+			job.BelowCost++
+		}
+	}
+
+	itemErrors := make([]SyncItemError, 0, len(result.Errors))
+	for _, itemErr := range result.Errors {
+		job.Failed++
+		itemErrors = append(itemErrors, SyncItemError{
+			SKU:         itemErr.SKU,
+			ProductName: itemErr.ProductName,
+			Message:     itemErr.Err.Error(),
+		})
+	}
+	return itemErrors
+}
+
+// deactivateVanished records digiflazz products missing from the price list and marks
+// them inactive, unless an abnormally large share of active products vanished at once
+// or a whole batch of this job failed to sync
+func (repo *ProductSyncRepository) deactivateVanished(ctx context.Context, job *SyncJob, products []*lib.ProductData) error {
+	// Price list kosong lebih mungkin error supplier daripada semua produk hilang
+	if len(products) == 0 {
+		return nil
+	}
+	// Produk gagal tetap ada di price list sehingga tidak dianggap hilang; hanya batch yang
+	// gagal total (mis. database bermasalah) yang membuat hasil sync tidak bisa dipercaya
+	if job.batchFailed > 0 {
+		msg := fmt.Sprintf("deactivation skipped: %d batches failed to sync", job.batchFailed)
+		log.Printf("Sync job %d: %s", job.ID, msg)
+		if _, err := repo.DB.ExecContext(ctx, `
+			INSERT INTO sync_job_errors (job_id, message) VALUES ($1, $2)
+		`, job.ID, msg); err != nil {
+			return fmt.Errorf("failed to record sync error: %w", err)
+		}
+		return nil
+	}
+
+	skus := make([]string, 0, len(products))
+	for _, p := range products {
+		if p != nil {
+			skus = append(skus, p.BuyerSkuCode)
+		}
+	}
+
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var active, vanished int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE NOT (provider_id = ANY($1)))
+		FROM services
+		WHERE provider = 'digiflazz' AND status = 'active'
+	`, pq.Array(skus)).Scan(&active, &vanished)
+	if err != nil {
+		return fmt.Errorf("failed to count vanished products: %w", err)
+	}
+
+	if vanished > 0 && vanished*100 > active*repo.config.MaxVanishedPercent {
+		msg := fmt.Sprintf("deactivation aborted: %d of %d active products vanished, above the %d%% limit",
+			vanished, active, repo.config.MaxVanishedPercent)
+		log.Printf("Sync job %d: %s", job.ID, msg)
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO sync_job_errors (job_id, message) VALUES ($1, $2)
+		`, job.ID, msg); err != nil {
+			return fmt.Errorf("failed to record sync error: %w", err)
+		}
+		return tx.Commit()
+	}
+
+	// Laporan ditulis dari baris yang benar-benar dinonaktifkan, jadi produk yang sudah
+	// nonaktif (VANISHED, admin, BELOW_COST) tidak dilaporkan ulang
+	result, err := tx.ExecContext(ctx, `
+		WITH deactivated AS (
+			UPDATE services
+			SET status = 'inactive', inactive_reason = $3, deactivated_at = NOW(), updated_at = NOW()
+			WHERE provider = 'digiflazz'
+			  AND status = 'active'
+			  AND NOT (provider_id = ANY($2))
+			RETURNING provider_id, service_name
+		)
+		INSERT INTO sync_job_disappeared (job_id, provider_id, service_name)
+		SELECT $1, provider_id, service_name FROM deactivated
+	`, job.ID, pq.Array(skus), product.InactiveVanished)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate vanished products: %w", err)
+	}
+	deactivated, _ := result.RowsAffected()
+	job.Deactivated = int(deactivated)
+
+	return tx.Commit()
+}
+
+func (repo *ProductSyncRepository) saveProgress(ctx context.Context, job *SyncJob, itemErrors []SyncItemError) error {
+	if len(itemErrors) > 0 {
+		skus := make([]string, len(itemErrors))
+		names := make([]string, len(itemErrors))
+		messages := make([]string, len(itemErrors))
+		for i, itemErr := range itemErrors {
+			skus[i], names[i], messages[i] = itemErr.SKU, itemErr.ProductName, itemErr.Message
+		}
+
+		_, err := repo.DB.ExecContext(ctx, `
+			INSERT INTO sync_job_errors (job_id, sku, product_name, message)
+			SELECT $1, * FROM UNNEST($2::text[], $3::text[], $4::text[])
+		`, job.ID, pq.Array(skus), pq.Array(names), pq.Array(messages))
+		if err != nil {
+			return fmt.Errorf("failed to record sync errors: %w", err)
+		}
+	}
+
+	_, err := repo.DB.ExecContext(ctx, `
+		UPDATE sync_jobs SET fetched = $1, created = $2, updated = $3, failed = $4, held = $5, below_cost = $6
+		WHERE id = $7
+	`, job.Fetched, job.Created, job.Updated, job.Failed, job.Held, job.BelowCost, job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update sync job: %w", err)
+	}
+	return nil
+}
+
+// finish menyimpan hasil akhir job; context baru dipakai karena context sync bisa sudah habis
+func (repo *ProductSyncRepository) finish(job *SyncJob, runErr error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	job.DurationMs = time.Since(job.runStart).Milliseconds()
+	job.Status = StatusSuccess
+	var errMsg interface{}
+	if runErr != nil {
+		job.Status = StatusFailed
+		errMsg = runErr.Error()
+	}
+
+	_, err := repo.DB.ExecContext(ctx, `
+		UPDATE sync_jobs
+		SET status = $1, fetched = $2, created = $3, updated = $4, failed = $5, error = $6,
+			deactivated = $7, reactivated = $8, fetch_ms = $9, upsert_ms = $10, duration_ms = $11,
+			held = $12, below_cost = $13, finished_at = NOW()
+		WHERE id = $14
+	`, job.Status, job.Fetched, job.Created, job.Updated, job.Failed, errMsg, job.Deactivated, job.Reactivated,
+		job.FetchMs, job.UpsertMs, job.DurationMs, job.Held, job.BelowCost, job.ID)
+	if err != nil {
+		log.Printf("Failed to finish sync job %d: %v", job.ID, err)
+	}
+
+	log.Printf("Sync job %d %s - Fetched: %d, Created: %d, Updated: %d, Failed: %d, Deactivated: %d, Reactivated: %d, Held: %d, Below cost: %d, Fetch: %dms, Upsert: %dms, Total: %dms",
+		job.ID, job.Status, job.Fetched, job.Created, job.Updated, job.Failed, job.Deactivated, job.Reactivated,
+		job.Held, job.BelowCost, job.FetchMs, job.UpsertMs, job.DurationMs)
+}
+
+func (repo *ProductSyncRepository) List(ctx context.Context, limit int) ([]SyncJob, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT id, trigger, status, fetched, created, updated, failed, deactivated, reactivated, held, below_cost,
+			COALESCE(instance, ''), fetch_ms, upsert_ms, duration_ms, error, started_at, finished_at
+		FROM sync_jobs
+		ORDER BY started_at DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sync jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []SyncJob{}
+	for rows.Next() {
+		var job SyncJob
+		if err := rows.Scan(
+			&job.ID, &job.Trigger, &job.Status, &job.Fetched, &job.Created, &job.Updated,
+			&job.Failed, &job.Deactivated, &job.Reactivated, &job.Held, &job.BelowCost, &job.Instance,
+			&job.FetchMs, &job.UpsertMs, &job.DurationMs, &job.Error, &job.StartedAt, &job.FinishedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan sync job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// Get returns a job together with its per-item errors
+func (repo *ProductSyncRepository) Get(ctx context.Context, id int) (*SyncJob, error) {
+	var job SyncJob
+	err := repo.DB.QueryRowContext(ctx, `
+		SELECT id, trigger, status, fetched, created, updated, failed, deactivated, reactivated, held, below_cost,
+			COALESCE(instance, ''), fetch_ms, upsert_ms, duration_ms, error, started_at, finished_at
+		FROM sync_jobs
+		WHERE id = $1
+	`, id).Scan(
+		&job.ID, &job.Trigger, &job.Status, &job.Fetched, &job.Created, &job.Updated,
+		&job.Failed, &job.Deactivated, &job.Reactivated, &job.Held, &job.BelowCost, &job.Instance,
+		&job.FetchMs, &job.UpsertMs, &job.DurationMs, &job.Error, &job.StartedAt, &job.FinishedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrJobNotFound
+		}
+		return nil, fmt.Errorf("failed to load sync job: %w", err)
+	}
+
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT COALESCE(sku, ''), COALESCE(product_name, ''), message, created_at
+		FROM sync_job_errors
+		WHERE job_id = $1
+		ORDER BY id ASC
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sync job errors: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemErr SyncItemError
+		if err := rows.Scan(&itemErr.SKU, &itemErr.ProductName, &itemErr.Message, &itemErr.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan sync job error: %w", err)
+		}
+		job.Errors = append(job.Errors, itemErr)
+	}
+	return &job, rows.Err()
+}
+
+type DisappearedProduct struct {
+	ProviderID  string `json:"providerId"`
+	ServiceName string `json:"serviceName"`
+}
+
+// SyncReport is the price diff produced by one sync job
+type SyncReport struct {
+	JobID       int                   `json:"jobId"`
+	Increased   []product.PriceChange `json:"increased"`
+	Decreased   []product.PriceChange `json:"decreased"`
+	New         []product.PriceChange `json:"new"`
+	Disappeared []DisappearedProduct  `json:"disappeared"`
+}
+
+func (repo *ProductSyncRepository) Report(ctx context.Context, id int) (*SyncReport, error) {
+	if _, err := repo.Get(ctx, id); err != nil {
+		return nil, err
+	}
+
+	changes, err := repo.products.PriceChanges(ctx, `WHERE h.sync_job_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SyncReport{
+		JobID:       id,
+		Increased:   []product.PriceChange{},
+		Decreased:   []product.PriceChange{},
+		New:         []product.PriceChange{},
+		Disappeared: []DisappearedProduct{},
+	}
+	for _, change := range changes {
+		switch change.Type {
+		case product.PriceNew:
+			report.New = append(report.New, change)
+		case product.PriceDecreased:
+			report.Decreased = append(report.Decreased, change)
+		default:
+			report.Increased = append(report.Increased, change)
+		}
+	}
+
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT provider_id, COALESCE(service_name, '')
+		FROM sync_job_disappeared
+		WHERE job_id = $1
+		ORDER BY provider_id ASC
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query disappeared products: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p DisappearedProduct
+		if err := rows.Scan(&p.ProviderID, &p.ServiceName); err != nil {
+			return nil, fmt.Errorf("failed to scan disappeared product: %w", err)
+		}
+		report.Disappeared = append(report.Disappeared, p)
+	}
+	return report, rows.Err()
+}
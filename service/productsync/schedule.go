@@ -0,0 +1,263 @@
+package productsync
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/wafi04/backendvazzz/pkg/worker"
+)
+
+// syncLockKey adalah kunci pg advisory lock sync produk, sama untuk semua instance
+const syncLockKey int64 = 727001
+
+var ErrInvalidSchedule = errors.New("invalid sync schedule")
+
+// scheduleLocation dipakai untuk membaca jadwal cron, mengikuti jam operasional
+var scheduleLocation = loadScheduleLocation()
+
+func loadScheduleLocation() *time.Location {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		return time.FixedZone("WIB", 7*60*60)
+	}
+	return loc
+}
+
+// Schedule is the automatic sync schedule shared by every API instance
+type Schedule struct {
+	Cron      string     `json:"cron"`
+	Enabled   bool       `json:"enabled"`
+	NextRunAt *time.Time `json:"nextRunAt,omitempty"`
+	LastRunAt *time.Time `json:"lastRunAt,omitempty"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+type ScheduleRequest struct {
+	Cron    string `json:"cron" validate:"required"`
+	Enabled *bool  `json:"enabled,omitempty"`
+}
+
+// ClusterStatus is the sync state seen from any instance
+type ClusterStatus struct {
+	Schedule   *Schedule `json:"schedule"`
+	IsSyncing  bool      `json:"isSyncing"`
+	RunningJob *SyncJob  `json:"runningJob,omitempty"`
+}
+
+func instanceName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// acquireLock takes the advisory lock on a dedicated connection; Postgres releases it
+// automatically when the instance dies and its connection drops
+func (repo *ProductSyncRepository) acquireLock(ctx context.Context) (*sql.Conn, error) {
+	conn, err := repo.DB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection for sync lock: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, syncLockKey).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to take sync lock: %w", err)
+	}
+	if !locked {
+		conn.Close()
+		if id, err := repo.runningJobID(ctx); err == nil && id != 0 {
+			return nil, fmt.Errorf("%w: job %d", ErrSyncInProgress, id)
+		}
+		return nil, ErrSyncInProgress
+	}
+	return conn, nil
+}
+
+func (repo *ProductSyncRepository) releaseLock(conn *sql.Conn) {
+	if conn == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, syncLockKey); err != nil {
+		log.Printf("Failed to release sync lock: %v", err)
+		// Koneksi dibuang supaya lock tidak ikut kembali ke pool
+		conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	}
+	conn.Close()
+}
+
+// isLocked reports whether any instance holds the sync lock
+func (repo *ProductSyncRepository) isLocked(ctx context.Context) (bool, error) {
+	var locked bool
+	err := repo.DB.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM pg_locks
+			WHERE locktype = 'advisory' AND granted
+			  AND classid = $1 AND objid = $2 AND objsubid = 1
+		)
+	`, uint32(syncLockKey>>32), uint32(syncLockKey)).Scan(&locked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check sync lock: %w", err)
+	}
+	return locked, nil
+}
+
+func (repo *ProductSyncRepository) runningJobID(ctx context.Context) (int, error) {
+	var id int
+	err := repo.DB.QueryRowContext(ctx, `
+		SELECT id FROM sync_jobs WHERE status = $1 ORDER BY id DESC LIMIT 1
+	`, StatusRunning).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// Status returns the schedule and the job running on any instance
+func (repo *ProductSyncRepository) Status(ctx context.Context) (*ClusterStatus, error) {
+	schedule, err := repo.GetSchedule(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	locked, err := repo.isLocked(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ClusterStatus{Schedule: schedule, IsSyncing: locked}
+	if !locked {
+		return status, nil
+	}
+
+	id, err := repo.runningJobID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find running sync job: %w", err)
+	}
+	if id != 0 {
+		if status.RunningJob, err = repo.Get(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+func (repo *ProductSyncRepository) GetSchedule(ctx context.Context) (*Schedule, error) {
+	var schedule Schedule
+	err := repo.DB.QueryRowContext(ctx, `
+		SELECT cron, enabled, next_run_at, last_run_at, updated_at
+		FROM sync_schedule
+		WHERE id = 1
+	`).Scan(&schedule.Cron, &schedule.Enabled, &schedule.NextRunAt, &schedule.LastRunAt, &schedule.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sync schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+// parseSchedule validates the cron expression and returns its next run from now
+func parseSchedule(expr string) (*worker.Cron, time.Time, error) {
+	cron, err := worker.ParseCron(expr)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	next := cron.Next(time.Now().In(scheduleLocation))
+	if next.IsZero() {
+		return nil, time.Time{}, fmt.Errorf("%w: %q never runs", ErrInvalidSchedule, expr)
+	}
+	return cron, next, nil
+}
+
+// UpdateSchedule changes the cron expression and optionally enables or disables it
+func (repo *ProductSyncRepository) UpdateSchedule(ctx context.Context, req ScheduleRequest) (*Schedule, error) {
+	cron, next, err := parseSchedule(req.Cron)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = repo.DB.ExecContext(ctx, `
+		UPDATE sync_schedule
+		SET cron = $1, enabled = COALESCE($2, enabled), next_run_at = $3, updated_at = NOW()
+		WHERE id = 1
+	`, cron.String(), req.Enabled, next)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update sync schedule: %w", err)
+	}
+	return repo.GetSchedule(ctx)
+}
+
+// SetScheduleEnabled turns the automatic sync on or off for every instance.
+// Saat diaktifkan, sync pertama langsung dijalankan pada tick scheduler berikutnya.
+func (repo *ProductSyncRepository) SetScheduleEnabled(ctx context.Context, enabled bool) (*Schedule, error) {
+	_, err := repo.DB.ExecContext(ctx, `
+		UPDATE sync_schedule
+		SET enabled = $1,
+			next_run_at = CASE WHEN $1 AND NOT enabled THEN NOW() ELSE next_run_at END,
+			updated_at = NOW()
+		WHERE id = 1
+	`, enabled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update sync schedule: %w", err)
+	}
+	return repo.GetSchedule(ctx)
+}
+
+// RunDue starts a scheduled sync when the schedule is due. Every instance calls it, but only
+// one claims the schedule slot and the advisory lock keeps a single sync running.
+func (repo *ProductSyncRepository) RunDue(ctx context.Context) error {
+	schedule, err := repo.GetSchedule(ctx)
+	if err != nil {
+		return err
+	}
+	if !schedule.Enabled || schedule.NextRunAt == nil || time.Now().Before(*schedule.NextRunAt) {
+		return nil
+	}
+
+	_, next, err := parseSchedule(schedule.Cron)
+	if err != nil {
+		return err
+	}
+
+	result, err := repo.DB.ExecContext(ctx, `
+		UPDATE sync_schedule
+		SET next_run_at = $1, last_run_at = NOW()
+		WHERE id = 1 AND enabled AND next_run_at = $2
+	`, next, *schedule.NextRunAt)
+	if err != nil {
+		return fmt.Errorf("failed to claim scheduled sync: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		// Instance lain sudah mengambil slot ini
+		return nil
+	}
+
+	job, err := repo.Start(ctx, TriggerScheduled)
+	if errors.Is(err, ErrSyncInProgress) {
+		log.Printf("Scheduled sync skipped: %v", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Scheduled sync job %d started, next run at %s", job.ID, next.Format(time.RFC3339))
+	return nil
+}
+
+// Validate checks the cron expression of a schedule request
+func (req *ScheduleRequest) Validate() error {
+	req.Cron = strings.TrimSpace(req.Cron)
+	_, _, err := parseSchedule(req.Cron)
+	return err
+}
@@ -0,0 +1,50 @@
+package productsync
+
+import "context"
+
+const defaultJobLimit = 20
+
+type ProductSyncService struct {
+	repo *ProductSyncRepository
+}
+
+func NewProductSyncService(repo *ProductSyncRepository) *ProductSyncService {
+	return &ProductSyncService{
+		repo: repo,
+	}
+}
+
+// Start queues a manual sync and returns the job right away
+func (s *ProductSyncService) Start(ctx context.Context) (*SyncJob, error) {
+	return s.repo.Start(ctx, TriggerManual)
+}
+
+func (s *ProductSyncService) List(ctx context.Context, limit int) ([]SyncJob, error) {
+	if limit <= 0 || limit > 100 {
+		limit = defaultJobLimit
+	}
+	return s.repo.List(ctx, limit)
+}
+
+func (s *ProductSyncService) Get(ctx context.Context, id int) (*SyncJob, error) {
+	return s.repo.Get(ctx, id)
+}
+
+func (s *ProductSyncService) Report(ctx context.Context, id int) (*SyncReport, error) {
+	return s.repo.Report(ctx, id)
+}
+
+func (s *ProductSyncService) Status(ctx context.Context) (*ClusterStatus, error) {
+	return s.repo.Status(ctx)
+}
+
+func (s *ProductSyncService) GetSchedule(ctx context.Context) (*Schedule, error) {
+	return s.repo.GetSchedule(ctx)
+}
+
+func (s *ProductSyncService) UpdateSchedule(ctx context.Context, req ScheduleRequest) (*Schedule, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return s.repo.UpdateSchedule(ctx, req)
+}
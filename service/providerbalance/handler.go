@@ -0,0 +1,51 @@
+package providerbalance
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wafi04/backendvazzz/pkg/utils"
+)
+
+type ProviderBalanceHandler struct {
+	service *ProviderBalanceService
+}
+
+func NewProviderBalanceHandler(service *ProviderBalanceService) *ProviderBalanceHandler {
+	return &ProviderBalanceHandler{
+		service: service,
+	}
+}
+
+func (h *ProviderBalanceHandler) List(c *gin.Context) {
+	balances, err := h.service.List(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch provider balances", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Provider balances retrieved successfully", balances)
+}
+
+func (h *ProviderBalanceHandler) Refresh(c *gin.Context) {
+	balances, err := h.service.Refresh(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadGateway, "Failed to check provider balances", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Provider balances refreshed successfully", balances)
+}
+
+func (h *ProviderBalanceHandler) Alerts(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	alerts, err := h.service.Alerts(c.Request.Context(), limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch balance alerts", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Balance alerts retrieved successfully", alerts)
+}
@@ -0,0 +1,279 @@
+package providerbalance
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/wafi04/backendvazzz/pkg/config"
+	"github.com/wafi04/backendvazzz/pkg/supplier"
+)
+
+// Asal pencatatan saldo supplier
+const (
+	SourceOrder    = "order"
+	SourceCallback = "callback"
+	SourceStatus   = "status"
+	SourceCheck    = "check"
+)
+
+var ErrInsufficientProviderBalance = errors.New("insufficient provider balance")
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type ProviderBalance struct {
+	Supplier  string    `json:"supplier"`
+	Balance   int64     `json:"balance"`
+	Threshold int64     `json:"threshold"`
+	Low       bool      `json:"low"`
+	Source    string    `json:"source"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+type BalanceAlert struct {
+	ID        int       `json:"id"`
+	Supplier  string    `json:"supplier"`
+	Balance   int64     `json:"balance"`
+	Threshold int64     `json:"threshold"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type ProviderBalanceRepository struct {
+	DB         *sql.DB
+	suppliers  *supplier.Registry
+	httpClient *http.Client
+	config     config.ProviderBalanceConfig
+}
+
+func NewProviderBalanceRepository(db *sql.DB, suppliers *supplier.Registry, cfg config.ProviderBalanceConfig) *ProviderBalanceRepository {
+	return &ProviderBalanceRepository{
+		DB:        db,
+		suppliers: suppliers,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		config: cfg,
+	}
+}
+
+// GatingEnabled menolak order baru jika saldo supplier tidak cukup
+func (repo *ProviderBalanceRepository) GatingEnabled() bool {
+	return repo.config.Gating
+}
+
+// Observe records the balance a supplier reported alongside an order result.
+// Failures are only logged, they must never fail the order itself.
+func (repo *ProviderBalanceRepository) Observe(ctx context.Context, name string, result *supplier.OrderResult, source string) {
+	if result == nil || result.LastBalance == nil {
+		return
+	}
+	if err := repo.Record(ctx, name, int64(*result.LastBalance), source); err != nil {
+		log.Printf("Failed to record %s balance: %v", name, err)
+	}
+}
+
+// Record stores the latest balance of a supplier and raises an alert the first
+// time it drops below the threshold. The alert re-arms once the balance recovers.
+func (repo *ProviderBalanceRepository) Record(ctx context.Context, name string, balance int64, source string) error {
+	threshold := repo.config.AlertThreshold
+	low := balance < threshold
+
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && rErr != sql.ErrTxDone {
+			log.Printf("Error during transaction rollback: %v", rErr)
+		}
+	}()
+
+	var wasAlerting bool
+	err = tx.QueryRowContext(ctx, `SELECT alerting FROM provider_balances WHERE supplier = $1 FOR UPDATE`, name).Scan(&wasAlerting)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to load %s balance: %w", name, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO provider_balances (supplier, balance, source, alerting, checked_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (supplier) DO UPDATE
+		SET balance = EXCLUDED.balance,
+			source = EXCLUDED.source,
+			alerting = EXCLUDED.alerting,
+			checked_at = EXCLUDED.checked_at
+	`, name, balance, source, low)
+	if err != nil {
+		return fmt.Errorf("failed to save %s balance: %w", name, err)
+	}
+
+	raise := low && !wasAlerting
+	if raise {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO provider_balance_alerts (supplier, balance, threshold)
+			VALUES ($1, $2, $3)
+		`, name, balance, threshold)
+		if err != nil {
+			return fmt.Errorf("failed to record %s balance alert: %w", name, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if raise {
+		log.Printf("ALERT: saldo %s tinggal %d, di bawah batas %d", name, balance, threshold)
+		go repo.notify(BalanceAlert{
+			Supplier:  name,
+			Balance:   balance,
+			Threshold: threshold,
+			CreatedAt: time.Now(),
+		})
+	}
+	return nil
+}
+
+// notify posts the alert to PROVIDER_BALANCE_ALERT_WEBHOOK_URL when configured
+func (repo *ProviderBalanceRepository) notify(alert BalanceAlert) {
+	url := repo.config.AlertWebhookURL
+	if url == "" {
+		return
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"supplier":  alert.Supplier,
+		"balance":   alert.Balance,
+		"threshold": alert.Threshold,
+		"message":   fmt.Sprintf("Saldo %s tinggal %d, segera deposit", alert.Supplier, alert.Balance),
+	})
+
+	resp, err := repo.httpClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Failed to send balance alert for %s: %v", alert.Supplier, err)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		log.Printf("Balance alert webhook for %s returned status %d", alert.Supplier, resp.StatusCode)
+	}
+}
+
+// Refresh asks every registered supplier for its balance
+func (repo *ProviderBalanceRepository) Refresh(ctx context.Context) error {
+	var errs []error
+	for _, name := range repo.suppliers.Names() {
+		s, err := repo.suppliers.Get(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		balance, err := s.Balance(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to check %s balance: %w", s.Name(), err))
+			continue
+		}
+
+		if err := repo.Record(ctx, s.Name(), int64(balance), SourceCheck); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (repo *ProviderBalanceRepository) List(ctx context.Context) ([]ProviderBalance, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT supplier, balance, source, checked_at
+		FROM provider_balances
+		ORDER BY supplier ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query provider balances: %w", err)
+	}
+	defer rows.Close()
+
+	threshold := repo.config.AlertThreshold
+	balances := []ProviderBalance{}
+	for rows.Next() {
+		b := ProviderBalance{Threshold: threshold}
+		if err := rows.Scan(&b.Supplier, &b.Balance, &b.Source, &b.CheckedAt); err != nil {
+			return nil, err
+		}
+		b.Low = b.Balance < threshold
+		balances = append(balances, b)
+	}
+	return balances, rows.Err()
+}
+
+func (repo *ProviderBalanceRepository) Alerts(ctx context.Context, limit int) ([]BalanceAlert, error) {
+	rows, err := repo.DB.QueryContext(ctx, `
+		SELECT id, supplier, balance, threshold, created_at
+		FROM provider_balance_alerts
+		ORDER BY created_at DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query balance alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []BalanceAlert{}
+	for rows.Next() {
+		var a BalanceAlert
+		if err := rows.Scan(&a.ID, &a.Supplier, &a.Balance, &a.Threshold, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
+
+// CheckAvailable returns ErrInsufficientProviderBalance when the known balance
+// of a supplier, minus orders still waiting for payment, cannot cover amount.
+// Suppliers without a recorded balance are never blocked.
+func (repo *ProviderBalanceRepository) CheckAvailable(ctx context.Context, q queryRower, name string, amount int) error {
+	if !repo.GatingEnabled() {
+		return nil
+	}
+	if name == "" {
+		name = supplier.DefaultSupplier
+	}
+
+	var balance, reserved int64
+	err := q.QueryRowContext(ctx, `
+		SELECT
+			b.balance,
+			COALESCE((
+				SELECT SUM(s.price_purchase)
+				FROM transactions t
+				JOIN services s ON s.provider_id = t.provider_order_id
+				WHERE t.status = 'PENDING'
+				  AND t.transaction_type = 'TOPUP'
+				  AND LOWER(COALESCE(NULLIF(s.provider, ''), $2)) = LOWER(b.supplier)
+			), 0)
+		FROM provider_balances b
+		WHERE LOWER(b.supplier) = LOWER($1)
+	`, name, supplier.DefaultSupplier).Scan(&balance, &reserved)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("failed to check %s balance: %w", name, err)
+	}
+
+	if balance-reserved < int64(amount) {
+		return fmt.Errorf("%w: %s", ErrInsufficientProviderBalance, name)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package providerbalance
+
+import "context"
+
+const (
+	defaultAlertLimit = 50
+	maxAlertLimit     = 100
+)
+
+type ProviderBalanceService struct {
+	repo *ProviderBalanceRepository
+}
+
+func NewProviderBalanceService(repo *ProviderBalanceRepository) *ProviderBalanceService {
+	return &ProviderBalanceService{
+		repo: repo,
+	}
+}
+
+func (s *ProviderBalanceService) List(ctx context.Context) ([]ProviderBalance, error) {
+	return s.repo.List(ctx)
+}
+
+// Refresh checks every supplier now and returns the updated balances
+func (s *ProviderBalanceService) Refresh(ctx context.Context) ([]ProviderBalance, error) {
+	if err := s.repo.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx)
+}
+
+// Alerts returns the latest balance alerts; a missing, invalid or too large limit falls back to the default
+func (s *ProviderBalanceService) Alerts(ctx context.Context, limit int) ([]BalanceAlert, error) {
+	if limit <= 0 || limit > maxAlertLimit {
+		limit = defaultAlertLimit
+	}
+	return s.repo.Alerts(ctx, limit)
+}
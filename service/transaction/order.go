@@ -4,24 +4,44 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log"
 	"strings"
+	"time"
 
 	"github.com/wafi04/backendvazzz/pkg/config"
 	"github.com/wafi04/backendvazzz/pkg/lib"
+	"github.com/wafi04/backendvazzz/pkg/types"
 	"github.com/wafi04/backendvazzz/pkg/utils"
+	"github.com/wafi04/backendvazzz/service/expiry"
+	"github.com/wafi04/backendvazzz/service/flashsale"
+	"github.com/wafi04/backendvazzz/service/fulfillment"
+	"github.com/wafi04/backendvazzz/service/order"
+	"github.com/wafi04/backendvazzz/service/postpaid"
+	"github.com/wafi04/backendvazzz/service/product"
+	"github.com/wafi04/backendvazzz/service/providerbalance"
 )
 
 type TransactionRepository struct {
 	db            *sql.DB
 	duitkuService *lib.DuitkuService
+	fulfillment   *fulfillment.FulfillmentRepository
+	balances      *providerbalance.ProviderBalanceRepository
+	postpaid      *postpaid.PostpaidRepository
+	orderRepo     *order.OrderRepository
+	flashSales    *flashsale.FlashSaleRepository
+	payment       config.PaymentConfig
 }
 
-func NewTransactionRepository(db *sql.DB) *TransactionRepository {
-	duitkuService := lib.NewDuitkuService()
-
+func NewTransactionRepository(db *sql.DB, duitkuService *lib.DuitkuService, fulfillmentRepo *fulfillment.FulfillmentRepository, balances *providerbalance.ProviderBalanceRepository, postpaidRepo *postpaid.PostpaidRepository, payment config.PaymentConfig) *TransactionRepository {
 	return &TransactionRepository{
 		db:            db,
 		duitkuService: duitkuService,
+		fulfillment:   fulfillmentRepo,
+		balances:      balances,
+		postpaid:      postpaidRepo,
+		orderRepo:     order.NewOrderRepository(db),
+		flashSales:    flashsale.NewFlashSaleRepository(db),
+		payment:       payment,
 	}
 }
 
@@ -61,8 +81,27 @@ func (repo *TransactionRepository) Create(ctx context.Context, req CreateTransac
 		return nil, err
 	}
 
+	if err := repo.checkProviderBalance(ctx, tx, service.ProviderID); err != nil {
+		return nil, err
+	}
+
 	pricing := repo.calculatePricing(service, role)
 
+	// Harga flash sale dipakai jika lebih murah; kuota dikunci dan dipotong di transaksi yang sama
+	sale, err := repo.flashSales.Reserve(ctx, tx, flashsale.Claim{
+		ProviderID:  service.ProviderID,
+		OrderID:     orderID,
+		CustomerKey: flashSaleCustomerKey(req),
+		Price:       pricing.UserPrice,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if sale != nil {
+		pricing.UserPrice = sale.SalePrice
+		pricing.UserProfitAmount = sale.SalePrice - service.PricePurchase
+	}
+
 	discount := 0
 	if req.VoucherCode != nil && *req.VoucherCode != "" {
 		discount, err = repo.calculateVoucherDiscount(ctx, tx, *req.VoucherCode, pricing.UserPrice)
@@ -77,10 +116,13 @@ func (repo *TransactionRepository) Create(ctx context.Context, req CreateTransac
 	}
 
 	// Handle different payment methods
-	var response *CreateTransactionResponse
+	var (
+		response *CreateTransactionResponse
+		saldo    *CreatePaymentUsingSaldo
+	)
 
 	if req.MethodCode == "SALDO" {
-		response, err = repo.processSaldoPayment(ctx, tx, req, orderID, pricing, discount, service, req.GameId, req.Zone)
+		response, saldo, err = repo.processSaldoPayment(ctx, tx, req, orderID, pricing, discount, service, req.GameId, req.Zone)
 	} else {
 		response, err = repo.processExternalPayment(ctx, tx, req, orderID, pricing, discount, service)
 	}
@@ -100,24 +142,46 @@ func (repo *TransactionRepository) Create(ctx context.Context, req CreateTransac
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	// Order sudah dibayar dan tersimpan; kegagalan di sini ditangani poller status check
+	if saldo != nil {
+		if err := repo.fulfillSaldo(ctx, *saldo); err != nil {
+			log.Printf("Failed to fulfill order %s: %v", orderID, err)
+		}
+	}
+
+	response.FlashSale = sale != nil
 	return response, nil
 }
 
+// flashSaleCustomerKey identifies the buyer for the per customer limit by the game account
+// receiving the top-up, so one account cannot collect the sale price through several users.
+func flashSaleCustomerKey(req CreateTransaction) string {
+	key := "game:" + strings.ToLower(strings.TrimSpace(req.GameId))
+	if req.Zone != nil && strings.TrimSpace(*req.Zone) != "" {
+		key += "/" + strings.ToLower(strings.TrimSpace(*req.Zone))
+	}
+	return key
+}
+
 func (repo *TransactionRepository) getServiceByProviderID(ctx context.Context, tx *sql.Tx, providerID string) (*Service, error) {
 	query := `
         SELECT
             price, price_platinum, price_reseller, price_purchase,
             profit, profit_platinum, profit_reseller, provider_id,
-            is_profit_fixed, service_name
+            is_profit_fixed, service_name, COALESCE(provider, ''),
+            start_cut_off, end_cut_off, stock, unlimited_stock, buyer_product_status, status
         FROM services
         WHERE provider_id = $1
     `
 
 	service := &Service{}
+	var status string
 	err := tx.QueryRowContext(ctx, query, providerID).Scan(
 		&service.Price, &service.PricePlatinum, &service.PriceReseller, &service.PricePurchase,
 		&service.Profit, &service.ProfitPlatinum, &service.ProfitReseller, &service.ProviderID,
-		&service.IsProfitFixed, &service.ServiceName,
+		&service.IsProfitFixed, &service.ServiceName, &service.Provider,
+		&service.Stock.StartCutOff, &service.Stock.EndCutOff, &service.Stock.Stock,
+		&service.Stock.UnlimitedStock, &service.Stock.BuyerProductStatus, &status,
 	)
 
 	if err != nil {
@@ -127,6 +191,15 @@ func (repo *TransactionRepository) getServiceByProviderID(ctx context.Context, t
 		return nil, fmt.Errorf("failed to query service details: %w", err)
 	}
 
+	if status != "active" {
+		return nil, fmt.Errorf("%w: product code '%s' (%s)", product.ErrProductUnavailable, providerID, product.ReasonInactive)
+	}
+
+	// Produk dalam jam cut-off supplier atau stok habis tidak bisa dipesan
+	if err := service.Stock.Check(time.Now()).Err(providerID); err != nil {
+		return nil, err
+	}
+
 	return service, nil
 }
 
@@ -156,7 +229,7 @@ func (repo *TransactionRepository) calculatePricing(service *Service, role *stri
 }
 
 func (repo *TransactionRepository) processSaldoPayment(ctx context.Context, tx *sql.Tx, req CreateTransaction,
-	orderID string, pricing PricingResult, discount int, service *Service, userId string, zone *string) (*CreateTransactionResponse, error) {
+	orderID string, pricing PricingResult, discount int, service *Service, userId string, zone *string) (*CreateTransactionResponse, *CreatePaymentUsingSaldo, error) {
 
 	var NoTujuan string
 
@@ -168,29 +241,31 @@ func (repo *TransactionRepository) processSaldoPayment(ctx context.Context, tx *
 	total := pricing.UserPrice
 
 	// Insert transaction record
-	if err := repo.insertTransaction(ctx, tx, req, orderID, pricing.UserPrice, discount, 0, total, pricing.UserProfit, pricing.UserProfitAmount, service.ServiceName); err != nil {
-		return nil, fmt.Errorf("failed to create transaction record: %w", err)
+	if err := repo.insertTransaction(ctx, tx, req, orderID, pricing.UserPrice, discount, 0, total, pricing.UserProfit, pricing.UserProfitAmount, service.ServiceName, TransactionTypeTopUp); err != nil {
+		return nil, nil, fmt.Errorf("failed to create transaction record: %w", err)
 	}
 
-	// Process saldo payment
-	_, err := repo.PaymentUsingSaldo(ctx, CreatePaymentUsingSaldo{
+	// Saldo dipotong di transaksi checkout, order dikirim ke supplier setelah commit
+	saldo := CreatePaymentUsingSaldo{
 		Username: req.Username,
 		OrderID:  orderID,
 		Total:    total,
 		WhatsApp: req.WhatsApp,
 		NoTujuan: NoTujuan,
+		SKU:      service.ProviderID,
 		Price:    pricing.UserPrice,
 		Tx:       tx,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to process saldo payment: %w", err)
 	}
+	if _, err := repo.PaymentUsingSaldo(ctx, saldo); err != nil {
+		return nil, nil, fmt.Errorf("failed to process saldo payment: %w", err)
+	}
+	saldo.Tx = nil
 
 	return &CreateTransactionResponse{
 		OrderID: orderID,
 		Total:   total,
 		Fee:     0,
-	}, nil
+	}, &saldo, nil
 }
 
 func (repo *TransactionRepository) processExternalPayment(ctx context.Context, tx *sql.Tx, req CreateTransaction,
@@ -205,12 +280,13 @@ func (repo *TransactionRepository) processExternalPayment(ctx context.Context, t
 	total := pricing.UserPrice + fee
 
 	// Insert transaction record
-	if err := repo.insertTransaction(ctx, tx, req, orderID, pricing.UserPrice, discount, fee, total, pricing.UserProfit, pricing.UserProfitAmount, service.ServiceName); err != nil {
+	if err := repo.insertTransaction(ctx, tx, req, orderID, pricing.UserPrice, discount, fee, total, pricing.UserProfit, pricing.UserProfitAmount, service.ServiceName, TransactionTypeTopUp); err != nil {
 		return nil, fmt.Errorf("failed to create transaction record: %w", err)
 	}
 
 	// Insert payment record
-	if err := repo.insertPaymentRecord(ctx, tx, orderID, pricing.UserPrice, total, fee, req.WhatsApp, methodNameResult, req.MethodCode); err != nil {
+	expiryPeriod := expiry.GetPeriodForMethod(ctx, tx, req.MethodCode, repo.payment.ExpiryMinutes)
+	if err := repo.insertPaymentRecord(ctx, tx, orderID, pricing.UserPrice, total, fee, req.WhatsApp, methodNameResult, req.MethodCode, expiryPeriod); err != nil {
 		return nil, fmt.Errorf("failed to insert payment record: %w", err)
 	}
 
@@ -222,15 +298,15 @@ func (repo *TransactionRepository) processExternalPayment(ctx context.Context, t
 }
 
 func (repo *TransactionRepository) insertTransaction(ctx context.Context, tx *sql.Tx, req CreateTransaction,
-	orderID string, userPrice, discount, fee, total, userProfit, profitAmount int, serviceName string) error {
+	orderID string, userPrice, discount, fee, total, userProfit, profitAmount int, serviceName, transactionType string) error {
 
 	insertTransactionQuery := `
         INSERT INTO transactions (
             order_id, username,provider_order_id, purchase_price, discount, user_id, zone,
             service_name, price, profit, profit_amount, status, is_digi,
-            success_report_sent, transaction_type, created_at,message
+            success_report_sent, transaction_type, voucher_code, created_at,message
         ) VALUES (
-            $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,$15, NOW(),'Transaction Pending'
+            $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,$15, $16, NOW(),'Transaction Pending'
         )
     `
 
@@ -246,28 +322,33 @@ func (repo *TransactionRepository) insertTransaction(ctx context.Context, tx *sq
 		userPrice,
 		userProfit,
 		profitAmount,
-		"PENDING",
+		types.StatusPending,
 		"active",
 		"active",
-		"TOPUP",
+		transactionType,
+		req.VoucherCode,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert transaction record: %w", err)
 	}
 
-	return nil
+	return repo.orderRepo.RecordCreated(ctx, tx, order.Transition{
+		OrderID: orderID,
+		To:      types.StatusPending,
+		Actor:   actorName(req.Username),
+		Source:  order.SourceSystem,
+	})
 }
 
-func (repo *TransactionRepository) insertPaymentRecord(ctx context.Context, tx *sql.Tx, orderID string, price, total, fee int, whatsApp, methodName, methodCode string) error {
+func (repo *TransactionRepository) insertPaymentRecord(ctx context.Context, tx *sql.Tx, orderID string, price, total, fee int, whatsApp, methodName, methodCode string, expiryPeriod int) error {
 
 	duitku, err := repo.duitkuService.CreateTransaction(ctx, &lib.DuitkuCreateTransactionParams{
-		PaymentAmount:   price,
+		PaymentAmount:   total,
 		MerchantOrderId: orderID,
 		ProductDetails:  "",
 		PaymentCode:     methodCode,
 		Cust:            stringPtr(methodCode),
-		CallbackUrl:     stringPtr(config.GetEnv("DUITKU_CALLBACK_URL", "")),
-		ReturnUrl:       stringPtr(config.GetEnv("DUITKU_RETURN_URL", "")),
+		ExpiryPeriod:    intPtr(expiryPeriod),
 	})
 
 	if err != nil {
@@ -277,9 +358,9 @@ func (repo *TransactionRepository) insertPaymentRecord(ctx context.Context, tx *
 	insertPaymentQuery := `
         INSERT INTO payments (
             order_id, price, total_amount, buyer_number, fee,
-            fee_amount, status, method, payment_number, created_at
+            fee_amount, status, method, method_code, payment_number, created_at
         ) VALUES (
-            $1, $2, $3, $4, $5, $6, $7, $8, $9, NOW()
+            $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW()
         )
     `
 
@@ -301,6 +382,7 @@ func (repo *TransactionRepository) insertPaymentRecord(ctx context.Context, tx *
 		fee,
 		"PENDING",
 		methodName,
+		methodCode,
 		paymentNumber,
 	)
 
@@ -343,3 +425,14 @@ func (repo *TransactionRepository) rollbackOnError(tx *sql.Tx) {
 func stringPtr(s string) *string {
 	return &s
 }
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func actorName(username string) string {
+	if username == "" {
+		return "guest"
+	}
+	return username
+}
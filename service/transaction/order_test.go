@@ -0,0 +1,28 @@
+package transaction
+
+import "testing"
+
+func TestFlashSaleCustomerKey(t *testing.T) {
+	zone := " 2001 "
+	empty := "  "
+
+	tests := []struct {
+		name string
+		req  CreateTransaction
+		want string
+	}{
+		{name: "game id only", req: CreateTransaction{GameId: "12345"}, want: "game:12345"},
+		{name: "trims and lowercases", req: CreateTransaction{GameId: " AbC12 "}, want: "game:abc12"},
+		{name: "with zone", req: CreateTransaction{GameId: "12345", Zone: &zone}, want: "game:12345/2001"},
+		{name: "blank zone ignored", req: CreateTransaction{GameId: "12345", Zone: &empty}, want: "game:12345"},
+		{name: "username not used", req: CreateTransaction{GameId: "12345", Username: "other"}, want: "game:12345"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := flashSaleCustomerKey(tt.req); got != tt.want {
+				t.Errorf("flashSaleCustomerKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
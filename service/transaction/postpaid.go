@@ -0,0 +1,138 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/wafi04/backendvazzz/service/expiry"
+	"github.com/wafi04/backendvazzz/service/postpaid"
+)
+
+// CreatePostpaid creates the order paying a bill quote. The quote id becomes the
+// order id so the supplier sees the same ref_id for inquiry and payment.
+func (repo *TransactionRepository) CreatePostpaid(ctx context.Context, req CreatePostpaidTransaction) (*CreateTransactionResponse, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer repo.rollbackOnError(tx)
+
+	quote, err := repo.postpaid.UseQuote(ctx, tx, req.QuoteID)
+	if err != nil {
+		return nil, err
+	}
+	// Tagihan dari inquiry user login hanya bisa dibayar oleh user itu sendiri
+	if quote.Username != "" && quote.Username != req.Username {
+		return nil, postpaid.ErrQuoteNotFound
+	}
+
+	orderReq := CreateTransaction{
+		ProductCode: quote.ProductCode,
+		MethodCode:  req.MethodCode,
+		WhatsApp:    req.WhatsApp,
+		Username:    req.Username,
+		GameId:      quote.CustomerNo,
+	}
+
+	var response *CreateTransactionResponse
+	if req.MethodCode == "SALDO" {
+		response, err = repo.processPostpaidSaldo(ctx, tx, orderReq, quote)
+	} else {
+		response, err = repo.processPostpaidExternal(ctx, tx, orderReq, quote)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return response, nil
+}
+
+func (repo *TransactionRepository) processPostpaidSaldo(ctx context.Context, tx *sql.Tx, req CreateTransaction, quote *postpaid.BillQuote) (*CreateTransactionResponse, error) {
+	if req.Username == "" {
+		return nil, ErrUsernameRequired
+	}
+
+	var balance int
+	err := tx.QueryRowContext(ctx, `SELECT balance FROM users WHERE username = $1 FOR UPDATE`, req.Username).Scan(&balance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user balance: %w", err)
+	}
+	if balance < quote.BillAmount {
+		return nil, ErrInsufficientBalance
+	}
+
+	amount := quote.BillAmount
+	if err := repo.insertTransaction(ctx, tx, req, quote.QuoteID, amount, 0, 0, amount, 0, amount-quote.PurchasePrice, quote.ServiceName, TransactionTypePostpaid); err != nil {
+		return nil, fmt.Errorf("failed to create transaction record: %w", err)
+	}
+
+	outcome, err := repo.postpaid.Pay(ctx, tx, quote.QuoteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE transactions SET supplier = $1, ref_id = $2 WHERE order_id = $3`, outcome.Supplier.Name(), quote.QuoteID, quote.QuoteID); err != nil {
+		return nil, fmt.Errorf("failed to record supplier: %w", err)
+	}
+
+	saldo := CreatePaymentUsingSaldo{
+		Username: req.Username,
+		OrderID:  quote.QuoteID,
+		Total:    amount,
+		WhatsApp: req.WhatsApp,
+		NoTujuan: quote.CustomerNo,
+		SKU:      quote.ProductCode,
+		Price:    amount,
+		Tx:       tx,
+	}
+	if _, err := repo.PaymentUsingSaldo(ctx, saldo); err != nil {
+		return nil, fmt.Errorf("failed to process saldo payment: %w", err)
+	}
+	if err := repo.settleSaldoPayment(ctx, saldo, outcome.Supplier.Name(), outcome.Result); err != nil {
+		return nil, fmt.Errorf("failed to process saldo payment: %w", err)
+	}
+
+	return &CreateTransactionResponse{
+		OrderID: quote.QuoteID,
+		Total:   amount,
+		Fee:     0,
+	}, nil
+}
+
+func (repo *TransactionRepository) processPostpaidExternal(ctx context.Context, tx *sql.Tx, req CreateTransaction, quote *postpaid.BillQuote) (*CreateTransactionResponse, error) {
+	amount := quote.BillAmount
+
+	fee, methodNameResult, err := repo.calculatePaymentFee(ctx, tx, req.MethodCode, amount)
+	if err != nil {
+		return nil, fmt.Errorf("payment method error: %w", err)
+	}
+
+	total := amount + fee
+
+	if err := repo.insertTransaction(ctx, tx, req, quote.QuoteID, amount, 0, fee, total, 0, amount-quote.PurchasePrice, quote.ServiceName, TransactionTypePostpaid); err != nil {
+		return nil, fmt.Errorf("failed to create transaction record: %w", err)
+	}
+
+	// Pembayaran tidak boleh lebih lama dari masa berlaku tagihan
+	expiryPeriod := expiry.GetPeriodForMethod(ctx, tx, req.MethodCode, repo.payment.ExpiryMinutes)
+	if remaining := int(time.Until(quote.ExpiresAt).Minutes()); remaining < expiryPeriod {
+		expiryPeriod = max(remaining, 1)
+	}
+
+	if err := repo.insertPaymentRecord(ctx, tx, quote.QuoteID, amount, total, fee, req.WhatsApp, methodNameResult, req.MethodCode, expiryPeriod); err != nil {
+		return nil, fmt.Errorf("failed to insert payment record: %w", err)
+	}
+
+	return &CreateTransactionResponse{
+		OrderID: quote.QuoteID,
+		Total:   total,
+		Fee:     fee,
+	}, nil
+}
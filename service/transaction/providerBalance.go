@@ -0,0 +1,35 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/wafi04/backendvazzz/service/providerbalance"
+)
+
+// checkProviderBalance menolak order jika tidak ada rute supplier yang saldonya cukup,
+// supaya customer tidak membayar order yang tidak bisa diproses
+func (repo *TransactionRepository) checkProviderBalance(ctx context.Context, tx *sql.Tx, productCode string) error {
+	if !repo.balances.GatingEnabled() {
+		return nil
+	}
+
+	routes, err := repo.fulfillment.Routes(ctx, tx, productCode)
+	if err != nil {
+		return err
+	}
+
+	for _, route := range routes {
+		err := repo.balances.CheckAvailable(ctx, tx, route.Supplier, route.Price)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, providerbalance.ErrInsufficientProviderBalance) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("%w: product code '%s'", providerbalance.ErrInsufficientProviderBalance, productCode)
+}
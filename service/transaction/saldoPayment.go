@@ -3,10 +3,15 @@ package transaction
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"strings"
+	"log"
 
-	"github.com/wafi04/backendvazzz/pkg/lib"
+	"github.com/wafi04/backendvazzz/pkg/supplier"
+	"github.com/wafi04/backendvazzz/pkg/types"
+	"github.com/wafi04/backendvazzz/service/fulfillment"
+	"github.com/wafi04/backendvazzz/service/order"
 )
 
 type CreatePaymentUsingSaldo struct {
@@ -15,6 +20,7 @@ type CreatePaymentUsingSaldo struct {
 	Total    int
 	WhatsApp string
 	NoTujuan string
+	SKU      string
 	Price    int
 	Tx       *sql.Tx
 }
@@ -24,24 +30,11 @@ type ResponsePaymentSaldo struct {
 	OrderID string
 }
 
+// PaymentUsingSaldo charges the user balance and marks the order PAID inside the checkout
+// transaction. The order is sent to the supplier by fulfillSaldo once the checkout is committed.
 func (repo *TransactionRepository) PaymentUsingSaldo(c context.Context, req CreatePaymentUsingSaldo) (*ResponsePaymentSaldo, error) {
-	digiflazz := lib.NewDigiflazzService(lib.DigiConfig{
-		DigiKey:      "f99884cd-b12d-5f6e-abf2-90d60f297bda",
-		DigiUsername: "casoyeDa3zJg",
-	})
-	digi, err := digiflazz.TopUp(c, lib.CreateTransactionToDigiflazz{
-		BuyerSKUCode: "CHECKIDS",
-		CustomerNo:   req.NoTujuan,
-		RefID:        req.OrderID,
-	})
-
-	fmt.Printf("Digiflazz Response: %+v\n", digi)
-
-	if err != nil {
-		return &ResponsePaymentSaldo{
-			Success: false,
-			OrderID: "",
-		}, fmt.Errorf("failed to insert payment: %w", err)
+	if req.Username == "" {
+		return nil, ErrUsernameRequired
 	}
 
 	insertPaymentQuery := `
@@ -53,47 +46,40 @@ func (repo *TransactionRepository) PaymentUsingSaldo(c context.Context, req Crea
 		)
 	`
 
-	_, err = req.Tx.ExecContext(c, insertPaymentQuery,
+	_, err := req.Tx.ExecContext(c, insertPaymentQuery,
 		req.OrderID,
 		fmt.Sprintf("%d", req.Price),
 		req.Total,
 		req.WhatsApp,
 		0,
 		0,
-		"PENDING",
+		types.StatusPending,
 		"SALDO",
 	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert payment: %w", err)
+	}
 
+	result, err := req.Tx.ExecContext(c, `
+		UPDATE users
+		SET balance = balance - $1
+		WHERE username = $2 AND balance >= $1
+	`, req.Price, req.Username)
 	if err != nil {
-		return &ResponsePaymentSaldo{
-			Success: false,
-			OrderID: "",
-		}, fmt.Errorf("failed to insert payment: %w", err)
+		return nil, fmt.Errorf("failed to charge balance: %w", err)
+	}
+	if charged, _ := result.RowsAffected(); charged == 0 {
+		return nil, ErrInsufficientBalance
 	}
 
-	switch strings.ToUpper(digi.Data.Status) {
-	case "GAGAL":
-		return &ResponsePaymentSaldo{
-			Success: false,
-			OrderID: "",
-		}, nil
-	case "SUKSES", "PENDING":
-		queryUpdate := `
-			UPDATE users
-			SET balance = balance - $1
-			WHERE username = $2
-			`
-		_, err := repo.db.ExecContext(c, queryUpdate, req.Price, req.Username)
-		if err != nil {
-			return &ResponsePaymentSaldo{
-				Success: true,
-				OrderID: req.OrderID,
-			}, fmt.Errorf("failed to insert payment: %w", err)
-		}
-		return &ResponsePaymentSaldo{
-			Success: true,
-			OrderID: req.OrderID,
-		}, nil
+	_, err = repo.orderRepo.Transition(c, req.Tx, order.Transition{
+		OrderID: req.OrderID,
+		To:      types.StatusPaid,
+		Actor:   actorName(req.Username),
+		Source:  order.SourceSystem,
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &ResponsePaymentSaldo{
@@ -101,3 +87,91 @@ func (repo *TransactionRepository) PaymentUsingSaldo(c context.Context, req Crea
 		OrderID: req.OrderID,
 	}, nil
 }
+
+// fulfillSaldo sends a paid SALDO order to the supplier and settles the answer. It runs after
+// the checkout is committed so the order row is not locked during the supplier request.
+func (repo *TransactionRepository) fulfillSaldo(c context.Context, req CreatePaymentUsingSaldo) error {
+	var (
+		source string
+		result *supplier.OrderResult
+	)
+	outcome, err := repo.fulfillment.Fulfill(c, req.OrderID, req.SKU, req.NoTujuan)
+	switch {
+	case errors.Is(err, fulfillment.ErrNoRouteLeft):
+		// Tidak ada supplier yang bisa memproses, order digagalkan dan saldo dikembalikan
+		source = order.SourceSystem
+		result = &supplier.OrderResult{RefID: req.OrderID, Status: supplier.StatusFailed, Message: err.Error()}
+	case errors.Is(err, fulfillment.ErrAttemptInProgress), errors.Is(err, fulfillment.ErrNotFulfillable):
+		return nil
+	case err != nil:
+		return err
+	default:
+		source, result = outcome.Supplier.Name(), outcome.Result
+	}
+
+	if result == nil {
+		// Tidak ada jawaban dari supplier, order tetap PAID dan dicek ulang oleh poller
+		log.Printf("No answer from %s for order %s, left for status check: %v", source, req.OrderID, outcome.Err)
+		return nil
+	}
+
+	req.Tx, err = repo.db.BeginTx(c, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer req.Tx.Rollback()
+
+	if err := repo.settleSaldoPayment(c, req, source, result); err != nil {
+		return err
+	}
+	return req.Tx.Commit()
+}
+
+// settleSaldoPayment moves a paid SALDO order according to the supplier answer and returns
+// the balance when the supplier failed. result is nil when the supplier did not answer.
+func (repo *TransactionRepository) settleSaldoPayment(c context.Context, req CreatePaymentUsingSaldo, source string, result *supplier.OrderResult) error {
+	if result == nil {
+		return nil
+	}
+	next, ok := order.FromSupplierStatus(result.Status)
+	if !ok {
+		return nil
+	}
+
+	payload, _ := json.Marshal(result)
+	_, err := repo.orderRepo.Transition(c, req.Tx, order.Transition{
+		OrderID: req.OrderID,
+		To:      next,
+		Actor:   source,
+		Source:  source,
+		Payload: stringPtr(string(payload)),
+	})
+	if err != nil {
+		// Callback supplier bisa tiba lebih dulu dan sudah memindahkan order
+		if errors.Is(err, order.ErrAlreadyInStatus) || errors.Is(err, order.ErrInvalidTransition) {
+			log.Printf("Order %s already settled, skip %s answer: %v", req.OrderID, source, err)
+			return nil
+		}
+		return err
+	}
+
+	if next != types.StatusFailed {
+		return nil
+	}
+
+	if _, err := req.Tx.ExecContext(c, `
+		UPDATE users
+		SET balance = balance + $1
+		WHERE username = $2
+	`, req.Price, req.Username); err != nil {
+		return fmt.Errorf("failed to refund balance: %w", err)
+	}
+
+	_, err = repo.orderRepo.Transition(c, req.Tx, order.Transition{
+		OrderID: req.OrderID,
+		To:      types.StatusRefunded,
+		Actor:   order.SourceSystem,
+		Source:  order.SourceSystem,
+	})
+	return err
+}
@@ -1,6 +1,11 @@
 package transaction
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/wafi04/backendvazzz/service/postpaid"
+	"github.com/wafi04/backendvazzz/service/product"
+)
 
 // Domain errors
 var (
@@ -11,6 +16,12 @@ var (
 	ErrVoucherInvalid      = errors.New("voucher is invalid or expired")
 )
 
+// Jenis transaksi pada kolom transactions.transaction_type
+const (
+	TransactionTypeTopUp    = "TOPUP"
+	TransactionTypePostpaid = postpaid.TransactionType
+)
+
 // DTOs
 type CreateTransaction struct {
 	ProductCode string  `json:"productCode" validate:"required"`
@@ -23,10 +34,19 @@ type CreateTransaction struct {
 	Zone        *string `json:"zone,omitempty"`
 }
 
+// CreatePostpaidTransaction pays a bill quote returned by the postpaid inquiry
+type CreatePostpaidTransaction struct {
+	QuoteID    string `json:"quoteId" validate:"required"`
+	MethodCode string `json:"methodCode" validate:"required"`
+	WhatsApp   string `json:"whatsapp" validate:"required"`
+	Username   string `json:"username,omitempty"`
+}
+
 type CreateTransactionResponse struct {
-	OrderID string `json:"orderId"`
-	Total   int    `json:"total"`
-	Fee     int    `json:"fee"`
+	OrderID   string `json:"orderId"`
+	Total     int    `json:"total"`
+	Fee       int    `json:"fee"`
+	FlashSale bool   `json:"flashSale"`
 }
 
 // Domain models
@@ -41,6 +61,8 @@ type Service struct {
 	ProviderID     string `db:"provider_id"`
 	IsProfitFixed  string `db:"is_profit_fixed"`
 	ServiceName    string `db:"service_name"`
+	Provider       string `db:"provider"`
+	Stock          product.Stock
 }
 
 type PricingResult struct {
@@ -9,11 +9,16 @@ import (
 	"log"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/google/uuid"
-	"github.com/wafi04/backendvazzz/pkg/lib"
+	"github.com/wafi04/backendvazzz/pkg/supplier"
+	"github.com/wafi04/backendvazzz/pkg/types"
 	"github.com/wafi04/backendvazzz/pkg/utils"
+	"github.com/wafi04/backendvazzz/service/fulfillment"
+	"github.com/wafi04/backendvazzz/service/order"
+	"github.com/wafi04/backendvazzz/service/postpaid"
 )
 
 type CallbackDuitku struct {
@@ -25,6 +30,12 @@ type CallbackDuitku struct {
 	Signature       string `json:"signature"`
 }
 
+// Asal konfirmasi pembayaran
+const (
+	SettledByCallback = "callback"
+	SettledByPoll     = "poll"
+)
+
 var (
 	depositPattern = regexp.MustCompile(`^DEP\d+$`)
 	paymentPattern = regexp.MustCompile(`^VAZZ\d+$`)
@@ -71,12 +82,70 @@ func (repo *TransactionsRepository) CallbackTransactionFromDuitkuRaw(c context.C
 		Signature:       formData.Get("signature"),
 	}
 
+	if reason := repo.verifyDuitkuCallback(c, data); reason != "" {
+		repo.recordRejectedCallback(c, order.SourceDuitku, data.MerchantOrderId, reason, duitkuRawResponseBytes)
+		return fmt.Errorf("%w: %s", ErrCallbackRejected, reason)
+	}
+
+	return repo.ProcessDuitkuResult(c, data, SettledByCallback)
+}
+
+// verifyDuitkuCallback returns the rejection reason, or an empty string when the callback is genuine
+func (repo *TransactionsRepository) verifyDuitkuCallback(c context.Context, data CallbackDuitku) string {
+	if data.MerchantCode != repo.duitkuService.DuitkuMerchantCode {
+		return fmt.Sprintf("unknown merchant code %q", data.MerchantCode)
+	}
+
+	if !repo.duitkuService.VerifyCallbackSignature(data.MerchantCode, data.Amount, data.MerchantOrderId, data.Signature) {
+		return "invalid signature"
+	}
+
+	return repo.checkStoredAmount(c, data.MerchantOrderId, data.Amount)
+}
+
+// checkStoredAmount compares the amount reported by Duitku with the stored order or deposit.
+// Used by the callback and the poller; it returns the rejection reason or an empty string.
+func (repo *TransactionsRepository) checkStoredAmount(c context.Context, merchantOrderID, reported string) string {
+	amount, err := strconv.ParseFloat(reported, 64)
+	if err != nil {
+		return fmt.Sprintf("invalid amount %q", reported)
+	}
+
+	var query string
+	switch detectTransactionType(merchantOrderID) {
+	case TransactionDeposit:
+		query = `SELECT amount FROM deposits WHERE deposit_id = $1`
+	case TransactionPayment:
+		query = `SELECT total_amount FROM payments WHERE order_id = $1`
+	default:
+		return "unsupported order id"
+	}
+
+	var expected int
+	if err := repo.DB.QueryRowContext(c, query, merchantOrderID).Scan(&expected); err != nil {
+		if err == sql.ErrNoRows {
+			return "order not found"
+		}
+		log.Printf("Failed to load amount for order %s: %v", merchantOrderID, err)
+		return "failed to load stored amount"
+	}
+
+	if int(amount) != expected {
+		return fmt.Sprintf("amount mismatch: duitku %s, stored %d", reported, expected)
+	}
+
+	return ""
+}
+
+// ProcessDuitkuResult applies a Duitku payment result, whether it came from the
+// callback endpoint or from the reconciliation poller.
+func (repo *TransactionsRepository) ProcessDuitkuResult(c context.Context, data CallbackDuitku, settledBy string) error {
 	// Log the callback data
 	logJSONBytes, err := json.Marshal(data)
 	if err != nil {
 		return fmt.Errorf("failed to marshal Duitku log data for order %s: %w", data.MerchantOrderId, err)
 	}
-	log.Printf("Duitku callback data: %s", string(logJSONBytes))
+	log.Printf("Duitku %s data: %s", settledBy, string(logJSONBytes))
 
 	if data.ResultCode != "00" {
 		log.Printf("Payment not successful. Result code: %s for order: %s", data.ResultCode, data.MerchantOrderId)
@@ -85,19 +154,15 @@ func (repo *TransactionsRepository) CallbackTransactionFromDuitkuRaw(c context.C
 
 	switch detectTransactionType(data.MerchantOrderId) {
 	case TransactionDeposit:
-		return repo.processDeposit(c, data.MerchantOrderId)
+		return repo.processDeposit(c, data.MerchantOrderId, settledBy)
 	case TransactionPayment:
-		return repo.processPayment(c, data.MerchantOrderId)
+		return repo.processPayment(c, data.MerchantOrderId, string(logJSONBytes), settledBy)
 	default:
 		return fmt.Errorf("unsupported transaction type for order %s", data.MerchantOrderId)
 	}
 }
 
-func (repo *TransactionsRepository) processPayment(c context.Context, merchantOrderId string) error {
-	digiflazz := lib.NewDigiflazzService(lib.DigiConfig{
-		DigiKey:      "f99884cd-b12d-5f6e-abf2-90d60f297bda",
-		DigiUsername: "casoyeDa3zJg",
-	})
+func (repo *TransactionsRepository) processPayment(c context.Context, merchantOrderId string, payload string, settledBy string) error {
 
 	var (
 		TrxId             string
@@ -135,7 +200,7 @@ func (repo *TransactionsRepository) processPayment(c context.Context, merchantOr
 			p.order_id,      
 			p.method,
 			p.fee_amount,
-			t.username       
+			t.username
 		FROM transactions t
 		LEFT JOIN payments p ON t.order_id = p.order_id
 		WHERE t.order_id = $1
@@ -161,50 +226,101 @@ func (repo *TransactionsRepository) processPayment(c context.Context, merchantOr
 		return fmt.Errorf("failed to query transaction details for order %s: %w", merchantOrderId, err)
 	}
 
-	if TransactionType != "TOPUP" {
-		return fmt.Errorf("transaction type for order %s is %s, expected TOPUP", merchantOrderId, TransactionType)
+	if TransactionType != "TOPUP" && TransactionType != postpaid.TransactionType {
+		return fmt.Errorf("transaction type for order %s is %s, expected TOPUP or %s", merchantOrderId, TransactionType, postpaid.TransactionType)
 	}
 
-	if TransactionStatus == "PAID" {
-		return fmt.Errorf("transaction %s already paid", TrxId)
+	actor := order.SourceDuitku
+	if settledBy == SettledByPoll {
+		actor = "duitku-poller"
+	}
+
+	// Pembayaran terverifikasi untuk order yang sudah kedaluwarsa tidak boleh hilang:
+	// order ditahan di MANUAL_REVIEW supaya admin memproses atau merefund
+	if strings.EqualFold(TransactionStatus, types.StatusExpired) {
+		return repo.holdLatePayment(c, tx, TrxId, actor, payload, settledBy)
+	}
+
+	_, err = repo.orderRepo.Transition(c, tx, order.Transition{
+		OrderID: TrxId,
+		To:      types.StatusPaid,
+		Actor:   actor,
+		Source:  order.SourceDuitku,
+		Payload: &payload,
+	})
+	if err != nil {
+		return fmt.Errorf("transaction %s cannot be marked as paid: %w", TrxId, err)
 	}
 
 	queryUpdate := `
 		UPDATE transactions 
 		SET 
-			status = 'PAID',
 			message = 'Pesanan Sudah Berhasil Dibayar',
 			updated_at = NOW(),
 			log = $1
 		WHERE order_id = $2
 	`
-	result, err := tx.ExecContext(c, queryUpdate, "Pesanan dari duitku", TrxId)
-	if err != nil {
-		return fmt.Errorf("failed to update transaction status for order %s: %w", TrxId, err)
+	if _, err := tx.ExecContext(c, queryUpdate, "Pesanan dari duitku", TrxId); err != nil {
+		return fmt.Errorf("failed to update transaction message for order %s: %w", TrxId, err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to check rows affected for order %s: %w", TrxId, err)
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("no rows affected when updating transaction %s", TrxId)
+	if _, err := tx.ExecContext(c, `UPDATE payments SET status = $1, settled_by = $2, updated_at = NOW() WHERE order_id = $3`, types.StatusPaid, settledBy, TrxId); err != nil {
+		return fmt.Errorf("failed to update payment status for order %s: %w", TrxId, err)
 	}
+
 	var customerNo string
-	if Zone != nil || *Zone != "" {
+	if Zone != nil && *Zone != "" {
 		customerNo = fmt.Sprintf("%s%s", UserId, *Zone)
 	} else {
 		customerNo = UserId
 	}
-	digi, _ := digiflazz.TopUp(c, lib.CreateTransactionToDigiflazz{
-		BuyerSKUCode: ProductCode,
-		CustomerNo:   customerNo,
-		RefID:        merchantOrderId,
-	})
+	if TransactionType != postpaid.TransactionType {
+		// Order dikirim ke supplier setelah commit supaya baris order tidak terkunci selama request ke supplier
+		if err = tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction for order %s: %w", TrxId, err)
+		}
+		noRoute := supplier.OrderResult{RefID: TrxId, Status: supplier.StatusFailed, Message: fulfillment.ErrNoRouteLeft.Error()}
+		return repo.fulfill(c, TrxId, ProductCode, customerNo, noRoute, order.SourceSystem, order.SourceSystem)
+	}
+
+	// Tagihan dibayar dengan ref_id yang sama seperti saat inquiry
+	outcome, err := repo.postpaid.Pay(c, tx, TrxId)
+	if err != nil {
+		return fmt.Errorf("failed to pay bill for order %s: %w", TrxId, err)
+	}
+	if _, err := tx.ExecContext(c, `UPDATE transactions SET supplier = $1, ref_id = $2 WHERE order_id = $2`, outcome.Supplier.Name(), TrxId); err != nil {
+		return fmt.Errorf("failed to record supplier for order %s: %w", TrxId, err)
+	}
+	if outcome.Err != nil {
+		log.Printf("Failed to pay bill %s on %s: %v", TrxId, outcome.Supplier.Name(), outcome.Err)
+	}
+	s, result := outcome.Supplier, outcome.Result
+
+	if result == nil {
+		// Order tetap PAID, status akan diperbarui lewat callback atau status check
+		if err = tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction for order %s: %w", TrxId, err)
+		}
+		return nil
+	}
 
-	switch strings.ToUpper(digi.Data.Status) {
-	case "GAGAL":
+	resultPayload, _ := json.Marshal(result)
+	next, ok := order.FromSupplierStatus(result.Status)
+	if ok {
+		_, err = repo.orderRepo.Transition(c, tx, order.Transition{
+			OrderID: TrxId,
+			To:      next,
+			Actor:   s.Name(),
+			Source:  s.Name(),
+			Payload: stringPtr(string(resultPayload)),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update order %s from %s: %w", TrxId, s.Name(), err)
+		}
+	}
 
+	switch next {
+	case types.StatusFailed:
 		refund := Price - Fee
 
 		var messages string
@@ -221,40 +337,44 @@ func (repo *TransactionsRepository) processPayment(c context.Context, merchantOr
 			if err != nil {
 				return fmt.Errorf("failed to process refund: %w", err)
 			}
+
+			_, err = repo.orderRepo.Transition(c, tx, order.Transition{
+				OrderID: TrxId,
+				To:      types.StatusRefunded,
+				Actor:   order.SourceSystem,
+				Source:  order.SourceSystem,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to mark order %s as refunded: %w", TrxId, err)
+			}
 		} else {
 			messages = "Transaksi Gagal, Silahkan Hubungi Admin"
 		}
 
-		// Update transaction status
 		queryUpdateTransaction := `
 		UPDATE transactions
 		SET 
 			message = $1,
-			status = 'FAILED',
 			log = $2,
 			updated_at = NOW()
 		WHERE order_id = $3
 	`
-		_, err := tx.ExecContext(c, queryUpdateTransaction, messages, "", merchantOrderId)
+		_, err := tx.ExecContext(c, queryUpdateTransaction, messages, result.Message, merchantOrderId)
 		if err != nil {
 			return fmt.Errorf("failed to update transaction: %w", err)
 		}
-
-		return nil
-	case "SUKSES", "PENDING":
+	case types.StatusSuccess, types.StatusProcess:
 		queryUpdate := `
 			UPDATE transactions
 			SET 
-				puchase_price = $1,
-				status = 'PAID'
+				purchase_price = $1,
 				updated_at = NOW()
 			WHERE order_id = $2
 			`
-		_, err := tx.ExecContext(c, queryUpdate, digi.Data.Price, merchantOrderId)
+		_, err := tx.ExecContext(c, queryUpdate, result.Price, merchantOrderId)
 		if err != nil {
-			return nil
+			return fmt.Errorf("failed to update purchase price for order %s: %w", TrxId, err)
 		}
-		return nil
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -263,7 +383,43 @@ func (repo *TransactionsRepository) processPayment(c context.Context, merchantOr
 
 	return nil
 }
-func (repo *TransactionsRepository) processDeposit(ctx context.Context, merchantOrderId string) error {
+
+// holdLatePayment records a payment that arrived after the order expired and moves the
+// order to MANUAL_REVIEW instead of fulfilling it
+func (repo *TransactionsRepository) holdLatePayment(c context.Context, tx *sql.Tx, orderID, actor, payload, settledBy string) error {
+	_, err := repo.orderRepo.Transition(c, tx, order.Transition{
+		OrderID: orderID,
+		To:      types.StatusManualReview,
+		Actor:   actor,
+		Source:  order.SourceDuitku,
+		Payload: &payload,
+	})
+	if err != nil {
+		return fmt.Errorf("late payment for order %s cannot be held for review: %w", orderID, err)
+	}
+
+	_, err = tx.ExecContext(c, `
+		UPDATE transactions
+		SET message = 'Pembayaran diterima setelah pesanan kadaluarsa, menunggu review admin',
+			updated_at = NOW()
+		WHERE order_id = $1
+	`, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction message for order %s: %w", orderID, err)
+	}
+
+	if _, err := tx.ExecContext(c, `UPDATE payments SET status = $1, settled_by = $2, updated_at = NOW() WHERE order_id = $3`, types.StatusPaid, settledBy, orderID); err != nil {
+		return fmt.Errorf("failed to update payment status for order %s: %w", orderID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction for order %s: %w", orderID, err)
+	}
+	log.Printf("Order %s was paid after it expired, moved to %s", orderID, types.StatusManualReview)
+	return nil
+}
+
+func (repo *TransactionsRepository) processDeposit(ctx context.Context, merchantOrderId string, settledBy string) error {
 	tx, err := repo.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -283,10 +439,12 @@ func (repo *TransactionsRepository) processDeposit(ctx context.Context, merchant
 		Method   string
 	}
 
+	// Dikunci supaya callback dan poller yang datang bersamaan tidak mengkredit dua kali
 	err = tx.QueryRowContext(ctx, `
         SELECT id, username, amount, status, method 
         FROM deposits 
-        WHERE deposit_id = $1`, merchantOrderId).Scan(
+        WHERE deposit_id = $1
+        FOR UPDATE`, merchantOrderId).Scan(
 		&deposit.ID, &deposit.Username, &deposit.Amount, &deposit.Status, &deposit.Method)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -295,10 +453,15 @@ func (repo *TransactionsRepository) processDeposit(ctx context.Context, merchant
 		return fmt.Errorf("failed to get deposit: %w", err)
 	}
 
-	// 2. Validasi status deposit
-	if strings.ToUpper(deposit.Status) != "PENDING" {
+	// 2. Validasi status deposit. Deposit yang kedaluwarsa tetap dikredit jika pembayarannya
+	// terverifikasi, uang customer sudah diterima
+	status := strings.ToUpper(deposit.Status)
+	if status != types.StatusPending && status != types.StatusExpired {
 		return fmt.Errorf("deposit already processed: status %s", deposit.Status)
 	}
+	if status == types.StatusExpired {
+		log.Printf("Deposit %s was paid after it expired, crediting it anyway", merchantOrderId)
+	}
 
 	// 3. Hitung amount dengan fee
 	var amountWithFee int
@@ -311,20 +474,22 @@ func (repo *TransactionsRepository) processDeposit(ctx context.Context, merchant
 		amountWithFee = deposit.Amount
 	}
 
-	_, err = tx.ExecContext(ctx, `
+	// Saldo hanya bertambah jika deposit benar-benar berpindah dari PENDING atau EXPIRED
+	result, err := tx.ExecContext(ctx, `
         WITH updated_deposit AS (
             UPDATE deposits 
             SET status = 'SUCCESS', 
                 log = 'Deposit berhasil diproses', 
+                settled_by = $6,
                 updated_at = NOW()
-            WHERE deposit_id = $1
+            WHERE deposit_id = $1 AND status IN ('PENDING', 'EXPIRED')
             RETURNING id
         ),
         updated_user AS (
             UPDATE users 
             SET balance = COALESCE(balance, 0) + $2,
                 updated_at = NOW()
-            WHERE username = $3
+            WHERE username = $3 AND EXISTS (SELECT 1 FROM updated_deposit)
             RETURNING id, balance, balance - $2 AS old_balance
         )
         INSERT INTO balance_histories (
@@ -353,11 +518,15 @@ func (repo *TransactionsRepository) processDeposit(ctx context.Context, merchant
 		amountWithFee,       // $2
 		deposit.Username,    // $3
 		uuid.New().String(), // $4
-		deposit.Method)      // $5
+		deposit.Method,      // $5
+		settledBy)           // $6
 
 	if err != nil {
 		return fmt.Errorf("failed to process deposit transaction: %w", err)
 	}
+	if credited, _ := result.RowsAffected(); credited == 0 {
+		return fmt.Errorf("deposit %s was not credited: already processed or user %s not found", merchantOrderId, deposit.Username)
+	}
 
 	// 5. Commit transaksi
 	if err = tx.Commit(); err != nil {
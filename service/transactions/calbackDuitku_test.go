@@ -0,0 +1,123 @@
+package transactions
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wafi04/backendvazzz/pkg/testdb"
+)
+
+func TestDetectTransactionType(t *testing.T) {
+	tests := map[string]TransactionType{
+		"DEP1700000000":   TransactionDeposit,
+		" dep123 ":        TransactionDeposit,
+		"VAZZ1700000000":  TransactionPayment,
+		"VAZZ-1700000000": TransactionUnknown,
+		"INV123":          TransactionUnknown,
+		"":                TransactionUnknown,
+	}
+	for id, want := range tests {
+		if got := detectTransactionType(id); got != want {
+			t.Errorf("detectTransactionType(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+// createDeposit inserts a PENDING deposit the way the deposit service does
+func createDeposit(t *testing.T, db *sql.DB, username string, amount int) string {
+	t.Helper()
+	depositID := testdb.UniqueID("DEP")
+	_, err := db.Exec(`
+		INSERT INTO deposits (method, amount, username, deposit_id, payment_reference, status, created_at, updated_at, log)
+		VALUES ('BCA Virtual Account', $1, $2, $3, $3, 'PENDING', $4, $4, '')
+	`, amount, username, depositID, time.Now())
+	if err != nil {
+		t.Fatalf("failed to create deposit: %v", err)
+	}
+	return depositID
+}
+
+func TestProcessDepositCreditsOnceUnderConcurrentCallbacks(t *testing.T) {
+	db := testdb.Open(t)
+	repo := &TransactionsRepository{DB: db}
+
+	username := testdb.UniqueID("depositor")
+	testdb.CreateUser(t, db, username, 0)
+	depositID := createDeposit(t, db, username, 50000)
+
+	const callbacks = 10
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		credited int
+	)
+	for i := 0; i < callbacks; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			settledBy := "callback"
+			if i%2 == 1 {
+				settledBy = "reconcile"
+			}
+			if err := repo.processDeposit(context.Background(), depositID, settledBy); err == nil {
+				mu.Lock()
+				credited++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if credited != 1 {
+		t.Errorf("deposit credited %d times, want 1", credited)
+	}
+	if balance := testdb.UserBalance(t, db, username); balance != 50000 {
+		t.Errorf("balance = %d, want 50000", balance)
+	}
+	var histories int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM balance_histories WHERE username = $1`, username).Scan(&histories); err != nil {
+		t.Fatal(err)
+	}
+	if histories != 1 {
+		t.Errorf("balance histories = %d, want 1", histories)
+	}
+}
+
+func TestCheckStoredAmount(t *testing.T) {
+	db := testdb.Open(t)
+	repo := &TransactionsRepository{DB: db}
+
+	username := testdb.UniqueID("depositor")
+	testdb.CreateUser(t, db, username, 0)
+	depositID := createDeposit(t, db, username, 50000)
+
+	tests := []struct {
+		name     string
+		orderID  string
+		reported string
+		want     string
+	}{
+		{name: "matching amount", orderID: depositID, reported: "50000", want: ""},
+		{name: "matching decimal amount", orderID: depositID, reported: "50000.00", want: ""},
+		{name: "mismatch", orderID: depositID, reported: "10000", want: "amount mismatch"},
+		{name: "invalid amount", orderID: depositID, reported: "abc", want: "invalid amount"},
+		{name: "unknown deposit", orderID: testdb.UniqueID("DEP"), reported: "50000", want: "order not found"},
+		{name: "unsupported id", orderID: "INV1", reported: "50000", want: "unsupported order id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := repo.checkStoredAmount(context.Background(), tt.orderID, tt.reported)
+			if tt.want == "" && got != "" {
+				t.Fatalf("checkStoredAmount() = %q, want no mismatch", got)
+			}
+			if !strings.HasPrefix(got, tt.want) {
+				t.Fatalf("checkStoredAmount() = %q, want prefix %q", got, tt.want)
+			}
+		})
+	}
+}
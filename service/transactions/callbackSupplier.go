@@ -0,0 +1,435 @@
+package transactions
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/wafi04/backendvazzz/pkg/supplier"
+	"github.com/wafi04/backendvazzz/pkg/types"
+	"github.com/wafi04/backendvazzz/service/fulfillment"
+	"github.com/wafi04/backendvazzz/service/order"
+	"github.com/wafi04/backendvazzz/service/providerbalance"
+)
+
+// CallbackFromSupplier verifies a webhook pushed by a supplier and applies the result.
+// Unverified callbacks are recorded and rejected before any order is touched.
+func (cd *TransactionsRepository) CallbackFromSupplier(c context.Context, name string, header http.Header, body []byte) (*supplier.Callback, error) {
+	s, err := cd.suppliers.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	callback, err := s.ParseCallback(header, body)
+	if err != nil {
+		if errors.Is(err, supplier.ErrInvalidCallback) {
+			cd.recordRejectedCallback(c, s.Name(), "", err.Error(), body)
+			return nil, fmt.Errorf("%w: %v", ErrCallbackRejected, err)
+		}
+		return nil, err
+	}
+
+	log.Printf("Callback %s (%s) - RefID: %s, Status: %s", s.Name(), callback.Event, callback.Result.RefID, callback.Result.RawStatus)
+	cd.balances.Observe(c, s.Name(), &callback.Result, providerbalance.SourceCallback)
+
+	return callback, cd.applySupplierResult(c, s.Name(), callback.Result, s.Name())
+}
+
+// applySupplierResult moves the order according to a supplier result,
+// shared by the webhook, fulfillment and the status-check poller.
+func (cd *TransactionsRepository) applySupplierResult(c context.Context, source string, detail supplier.OrderResult, actor string) error {
+	if detail.RefID == "" {
+		return fmt.Errorf("ref_id tidak boleh kosong")
+	}
+
+	nextStatus, ok := order.FromSupplierStatus(detail.Status)
+	if !ok {
+		log.Printf("Status tidak dikenali - RefID: %s, Status: %s", detail.RefID, detail.RawStatus)
+		return fmt.Errorf("status %s tidak dikenali", detail.RawStatus)
+	}
+
+	// Mulai transaksi database
+	tx, err := cd.DB.BeginTx(c, nil)
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaksi: %w", err)
+	}
+	defer tx.Rollback()
+
+	// ref_id dari supplier bisa milik percobaan fallback (<order_id>-<n>)
+	orderID := detail.RefID
+	attempt, err := cd.fulfillment.Resolve(c, tx, detail.RefID)
+	if err != nil {
+		return err
+	}
+	if attempt != nil {
+		orderID = attempt.OrderID
+	}
+
+	var (
+		fulfilledBy sql.NullString
+		currentRef  sql.NullString
+		productCode string
+		userID      string
+		zone        *string
+	)
+	err = tx.QueryRowContext(c, `
+		SELECT supplier, ref_id, provider_order_id, user_id, zone
+		FROM transactions
+		WHERE order_id = $1
+	`, orderID).Scan(&fulfilledBy, &currentRef, &productCode, &userID, &zone)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("transaksi dengan order_id %s tidak ditemukan", orderID)
+		}
+		return fmt.Errorf("gagal mengambil supplier transaksi: %w", err)
+	}
+
+	// Hanya supplier yang memproses percobaan ini yang boleh mengubah statusnya
+	expected := fulfilledBy.String
+	if attempt != nil {
+		expected = attempt.Supplier
+	}
+	if expected != "" && !strings.EqualFold(expected, source) {
+		return fmt.Errorf("transaksi %s diproses oleh %s, bukan %s", detail.RefID, expected, source)
+	}
+
+	if attempt != nil {
+		if err := cd.fulfillment.MarkAttempt(c, tx, attempt.SupplierRef, detail.Status, detail.Message); err != nil {
+			return err
+		}
+
+		// Percobaan lama yang sudah digantikan fallback tidak mengubah order
+		if currentRef.Valid && currentRef.String != attempt.SupplierRef {
+			if detail.Status == supplier.StatusSuccess {
+				log.Printf("PERLU CEK MANUAL: percobaan lama %s untuk order %s sukses setelah fallback", detail.RefID, orderID)
+			}
+			return tx.Commit()
+		}
+
+		if nextStatus == types.StatusFailed {
+			customerNo := userID
+			if zone != nil && *zone != "" {
+				customerNo = fmt.Sprintf("%s%s", userID, *zone)
+			}
+
+			// Percobaan yang gagal disimpan dulu, fallback dikirim tanpa mengunci order
+			if err := tx.Commit(); err != nil {
+				return fmt.Errorf("gagal commit transaksi: %w", err)
+			}
+			return cd.fulfill(c, orderID, productCode, customerNo, detail, source, actor)
+		}
+	}
+
+	return cd.settle(c, tx, orderID, source, detail, actor)
+}
+
+// fulfill sends a paid order to the supplier routes not tried yet and settles the answer.
+// last is the result applied when no route is left. Must not be called while holding a
+// transaction on the order: the supplier request runs without any lock.
+func (cd *TransactionsRepository) fulfill(c context.Context, orderID, productCode, customerNo string, last supplier.OrderResult, source, actor string) error {
+	detail := last
+	outcome, err := cd.fulfillment.Fulfill(c, orderID, productCode, customerNo)
+	switch {
+	case errors.Is(err, fulfillment.ErrNoRouteLeft):
+		// Semua rute sudah dicoba, order benar-benar gagal
+	case errors.Is(err, fulfillment.ErrAttemptInProgress), errors.Is(err, fulfillment.ErrNotFulfillable):
+		log.Printf("Skip fulfillment order %s: %v", orderID, err)
+		return nil
+	case err != nil:
+		return fmt.Errorf("gagal fulfill order %s: %w", orderID, err)
+	case outcome.Result == nil:
+		// Belum ada jawaban dari supplier, tunggu callback atau status check
+		log.Printf("Order %s belum dijawab %s, menunggu status check: %v", orderID, outcome.Supplier.Name(), outcome.Err)
+		return nil
+	default:
+		detail = *outcome.Result
+		source = outcome.Supplier.Name()
+		actor = source
+	}
+
+	tx, err := cd.DB.BeginTx(c, nil)
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaksi: %w", err)
+	}
+	defer tx.Rollback()
+
+	return cd.settle(c, tx, orderID, source, detail, actor)
+}
+
+// settle moves the order to the status of a supplier result, refunds failed orders and
+// commits tx
+func (cd *TransactionsRepository) settle(c context.Context, tx *sql.Tx, orderID, source string, detail supplier.OrderResult, actor string) error {
+	nextStatus, ok := order.FromSupplierStatus(detail.Status)
+	if !ok {
+		return fmt.Errorf("status %s tidak dikenali", detail.RawStatus)
+	}
+
+	payload, _ := json.Marshal(detail)
+	_, err := cd.orderRepo.Transition(c, tx, order.Transition{
+		OrderID: orderID,
+		To:      nextStatus,
+		Actor:   actor,
+		Source:  source,
+		Payload: stringPtr(string(payload)),
+	})
+	if err != nil {
+		if errors.Is(err, order.ErrAlreadyInStatus) {
+			log.Printf("Callback duplikat - RefID: %s, Status: %s", detail.RefID, nextStatus)
+			// Percobaan fallback yang sudah tercatat tetap disimpan
+			return tx.Commit()
+		}
+		if errors.Is(err, order.ErrOrderNotFound) {
+			return fmt.Errorf("transaksi dengan order_id %s tidak ditemukan", orderID)
+		}
+		return fmt.Errorf("gagal update status transaksi: %w", err)
+	}
+
+	var updatedAt time.Time
+	var username *string
+	var currentStatus string
+	var price int
+
+	var message string
+	switch nextStatus {
+	case types.StatusSuccess:
+		message = "Transaksi Berhasil"
+	case types.StatusFailed:
+		message = "Transaksi Gagal"
+	default:
+		message = detail.Message
+	}
+
+	// Harga beli dari jawaban supplier dipakai jika ada
+	updateQuery := `
+		UPDATE transactions 
+		SET message = $1, 
+			serial_number = $2, 
+			updated_at = $3,
+			purchase_price = CASE WHEN $5 > 0 THEN $5 ELSE purchase_price END
+		WHERE order_id = $4
+		RETURNING username, status, updated_at, price`
+
+	err = tx.QueryRowContext(c, updateQuery,
+		message,
+		detail.SN,
+		time.Now(),
+		orderID,
+		detail.Price,
+	).Scan(
+		&username,
+		&currentStatus,
+		&updatedAt,
+		&price,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("transaksi dengan order_id %s tidak ditemukan", orderID)
+		}
+		return fmt.Errorf("gagal update transaksi: %w", err)
+	}
+
+	// Ambil payment method
+	var methodName string
+	paymentQuery := `
+		SELECT method FROM payments
+		WHERE order_id = $1`
+
+	err = tx.QueryRowContext(c, paymentQuery, orderID).Scan(&methodName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			log.Printf("Payment method tidak ditemukan untuk order_id: %s", orderID)
+			methodName = "UNKNOWN"
+		} else {
+			return fmt.Errorf("gagal mengambil payment method: %w", err)
+		}
+	}
+
+	// Process berdasarkan status
+	switch nextStatus {
+	case types.StatusSuccess:
+		log.Printf("Transaksi sukses - RefID: %s, CustomerNo: %s, SN: %s, Method: %s",
+			detail.RefID, detail.CustomerNo, detail.SN, methodName)
+
+	case types.StatusFailed:
+		log.Printf("Transaksi gagal - RefID: %s, Status: %s, Message: %s",
+			detail.RefID, detail.RawStatus, detail.Message)
+
+		err = cd.processFailedTransaction(c, tx, orderID, username, methodName, price)
+		if err != nil {
+			return fmt.Errorf("gagal proses transaksi gagal: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("gagal commit transaksi: %w", err)
+	}
+
+	return nil
+}
+
+func (cd *TransactionsRepository) processFailedTransaction(c context.Context, tx *sql.Tx, refID string, username *string, methodName string, price int) error {
+	if username == nil {
+		log.Printf("Username kosong untuk order_id: %s, skip refund", refID)
+		return nil
+	}
+
+	log.Printf("Processing failed transaction for user: %s", *username)
+
+	// Logic untuk transaksi gagal
+	if methodName == "QRIS (All Payment)" {
+		// QRIS dikenakan fee 0.7%
+		fee := math.Round(float64(price) * (0.7 / 100))
+		refundAmount := float64(price) - fee
+
+		log.Printf("QRIS Refund - Original: %d, Fee: %.2f, Refund: %.2f",
+			price, fee, refundAmount)
+
+		// Refund dengan potongan fee
+		err := cd.refundUserBalance(c, tx, *username, refundAmount, refID, "QRIS_REFUND")
+		if err != nil {
+			return fmt.Errorf("gagal refund balance QRIS: %w", err)
+		}
+	} else {
+		// Untuk method lain, refund full amount
+		log.Printf("Full Refund - Amount: %d", price)
+
+		err := cd.refundUserBalance(c, tx, *username, float64(price), refID, "FULL_REFUND")
+		if err != nil {
+			return fmt.Errorf("gagal refund balance: %w", err)
+		}
+	}
+
+	_, err := cd.orderRepo.Transition(c, tx, order.Transition{
+		OrderID: refID,
+		To:      types.StatusRefunded,
+		Actor:   order.SourceSystem,
+		Source:  order.SourceSystem,
+	})
+	if err != nil {
+		return fmt.Errorf("gagal update status refund: %w", err)
+	}
+
+	return nil
+}
+
+func (cd *TransactionsRepository) refundUserBalance(c context.Context, tx *sql.Tx, username string, amount float64, orderID string, refundType string) error {
+	// Ambil balance sebelumnya
+	var balanceBefore float64
+	getBalanceQuery := `SELECT balance FROM users WHERE username = $1`
+	err := tx.QueryRowContext(c, getBalanceQuery, username).Scan(&balanceBefore)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("user dengan username %s tidak ditemukan", username)
+		}
+		return fmt.Errorf("gagal mengambil balance user: %w", err)
+	}
+
+	// Update user balance
+	balanceQuery := `
+		UPDATE users 
+		SET balance = balance + $1, updated_at = $2
+		WHERE username = $3`
+
+	result, err := tx.ExecContext(c, balanceQuery, amount, time.Now(), username)
+	if err != nil {
+		return fmt.Errorf("gagal update balance: %w", err)
+	}
+
+	// Cek rows affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("gagal cek rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("tidak ada user yang diupdate untuk username: %s", username)
+	}
+
+	// Hitung balance setelah update
+	balanceAfter := balanceBefore + amount
+
+	// Buat description berdasarkan refund type
+	var description string
+	switch refundType {
+	case "QRIS_REFUND":
+		description = fmt.Sprintf("Refund QRIS (dengan fee) untuk transaksi gagal: %s", orderID)
+	case "FULL_REFUND":
+		description = fmt.Sprintf("Refund penuh untuk transaksi gagal: %s", orderID)
+	default:
+		description = fmt.Sprintf("Refund untuk transaksi gagal: %s", orderID)
+	}
+
+	// Insert balance history
+	historyQuery := `
+		INSERT INTO balance_histories (
+			username,
+			platform_id,
+			batch_id,
+			balance_before,
+			balance_after,
+			amount_changed,
+			change_type,
+			description,
+			created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err = tx.ExecContext(c, historyQuery,
+		username,
+		"DIGIFLAZZ",
+		orderID,
+		balanceBefore,
+		balanceAfter,
+		amount,
+		"REFUND",
+		description,
+		time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("gagal insert balance history: %w", err)
+	}
+
+	log.Printf("Refund berhasil untuk user %s sebesar %.2f (Balance: %.2f -> %.2f)",
+		username, amount, balanceBefore, balanceAfter)
+	return nil
+}
+
+func (cd *TransactionsRepository) GetTransactionByOrderID(c context.Context, orderID string) (*TransactionDetail, error) {
+	query := `
+		SELECT order_id, username, status, message, serial_number, price, created_at, updated_at
+		FROM transactions 
+		WHERE order_id = $1`
+
+	var t TransactionDetail
+	err := cd.DB.QueryRowContext(c, query, orderID).Scan(
+		&t.OrderID, &t.Username, &t.Status,
+		&t.Message, &t.SN, &t.Price,
+		&t.CreatedAt, &t.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("transaksi dengan order_id %s tidak ditemukan", orderID)
+		}
+		return nil, fmt.Errorf("gagal mengambil data transaksi: %w", err)
+	}
+
+	return &t, nil
+}
+
+type TransactionDetail struct {
+	OrderID   string    `json:"order_id"`
+	Username  string    `json:"username"`
+	Status    string    `json:"status"`
+	Message   string    `json:"message"`
+	SN        string    `json:"sn"`
+	Price     int       `json:"price"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -1,7 +1,7 @@
 package transactions
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math"
@@ -10,6 +10,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/wafi04/backendvazzz/pkg/model"
+	"github.com/wafi04/backendvazzz/pkg/supplier"
 	"github.com/wafi04/backendvazzz/pkg/utils"
 )
 
@@ -17,16 +18,6 @@ type TransactionHandler struct {
 	transactionRepo *TransactionsRepository
 }
 
-type RawCallbackRequest struct {
-	Data         interface{} `json:"data,omitempty"`
-	RefID        *string     `json:"ref_id,omitempty"`
-	BuyerSKUCode *string     `json:"buyer_sku_code,omitempty"`
-	CustomerNo   *string     `json:"customer_no,omitempty"`
-	Status       *string     `json:"status,omitempty"`
-	Message      *string     `json:"message,omitempty"`
-	SN           *string     `json:"sn,omitempty"`
-}
-
 func NewTransactionHandler(trepo *TransactionsRepository) *TransactionHandler {
 	return &TransactionHandler{
 		transactionRepo: trepo,
@@ -137,6 +128,10 @@ func (h *TransactionHandler) CallbackDuitku(c *gin.Context) {
 	// Process callback
 	err = h.transactionRepo.CallbackTransactionFromDuitkuRaw(c, rawBody)
 	if err != nil {
+		if errors.Is(err, ErrCallbackRejected) {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Callback rejected", err.Error())
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to process callback", err.Error())
 		return
 	}
@@ -144,86 +139,36 @@ func (h *TransactionHandler) CallbackDuitku(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Callback processed successfully", nil)
 }
 
-func (h *TransactionHandler) CallbackDigiflazz(c *gin.Context) {
-	// Set response content type
+func (h *TransactionHandler) CallbackSupplier(c *gin.Context) {
 	c.Header("Content-Type", "application/json")
 
-	// Get raw request body for logging
-	rawBody, exists := c.Get(gin.BodyBytesKey)
-	if exists {
-		log.Printf("Raw callback body: %s", string(rawBody.([]byte)))
-	}
-
-	var rawRequest RawCallbackRequest
-
-	// Bind JSON request
-	if err := c.ShouldBindJSON(&rawRequest); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "invalid json format", "Failed to unmarshal callback detail: %v")
-
-		return
-	}
-
-	// Validate request body is not empty
-	if rawRequest.Data == nil && rawRequest.RefID == nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Empty is required", "Failed to unmarshal callback detail: %v")
-
+	// Read raw request body, signature dihitung dari body asli
+	rawBody, err := c.GetRawData()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to read request body", err.Error())
 		return
 	}
 
-	// Transform to standard callback data structure
-	var callbackData CallbackData
-
-	if rawRequest.Data != nil {
-		dataBytes, err := json.Marshal(rawRequest.Data)
-		if err != nil {
-			utils.ErrorResponse(c, http.StatusBadRequest, "Failed to decode response from digiflazz", "Failed to unmarshal callback detail: %v")
-			return
-		}
-
-		var detail CallbackDetail
-		if err := json.Unmarshal(dataBytes, &detail); err != nil {
-			utils.ErrorResponse(c, http.StatusBadRequest, "Message is required", "Failed to unmarshal callback detail: %v")
-			return
-		}
+	name := c.Param("supplier")
+	log.Printf("Raw %s callback body: %s", name, string(rawBody))
 
-		callbackData = CallbackData{
-			Data: detail,
-		}
-	} else {
-		callbackData = CallbackData{
-			Data: CallbackDetail{
-				RefID:        getStringValue(rawRequest.RefID),
-				BuyerSKUCode: getStringValue(rawRequest.BuyerSKUCode),
-				CustomerNo:   getStringValue(rawRequest.CustomerNo),
-				Status:       getStringValue(rawRequest.Status),
-				Message:      getStringValue(rawRequest.Message),
-				SN:           getStringValue(rawRequest.SN),
-			},
+	callback, err := h.transactionRepo.CallbackFromSupplier(c.Request.Context(), name, c.Request.Header, rawBody)
+	if err != nil {
+		switch {
+		case errors.Is(err, supplier.ErrUnknownSupplier):
+			utils.ErrorResponse(c, http.StatusNotFound, "Unknown supplier", err.Error())
+		case errors.Is(err, ErrCallbackRejected):
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Callback rejected", err.Error())
+		case errors.Is(err, supplier.ErrMalformedCallback):
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid callback payload", err.Error())
+		default:
+			log.Printf("Callback processing failed: %v", err)
+			utils.ErrorResponse(c, http.StatusInternalServerError, fmt.Sprintf("Processing failed: %v", err), err.Error())
 		}
-	}
-
-	// Validate required fields
-	if callbackData.Data.RefID == "" {
-		utils.ErrorResponse(c, http.StatusBadRequest, "RefId is required", "RefId is required")
-		return
-	}
-
-	if callbackData.Data.Status == "" {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Message is required", "Message is required")
 		return
 	}
 
-	// Process callback
-	ctx := c.Request.Context()
-	if err := h.transactionRepo.Callback(ctx, callbackData); err != nil {
-		log.Printf("Callback processing failed: %v", err)
-
-		utils.ErrorResponse(c, http.StatusInternalServerError, fmt.Sprintf("Processing failed: %v", err), err.Error())
-		return
-	}
-
-	// Success response
-	utils.SuccessResponse(c, http.StatusCreated, "Callback processed successfully", callbackData)
+	utils.SuccessResponse(c, http.StatusCreated, "Callback processed successfully", callback.Result)
 }
 
 func (h *TransactionHandler) GetRepostTransaction(c *gin.Context) {
@@ -263,10 +208,3 @@ func (h *TransactionHandler) GetRepostTransaction(c *gin.Context) {
 
 	utils.SuccessResponse(c, http.StatusOK, "Report Trasactions retrieved successfully", response)
 }
-
-func getStringValue(s *string) string {
-	if s == nil {
-		return ""
-	}
-	return *s
-}
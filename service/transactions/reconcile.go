@@ -0,0 +1,90 @@
+package transactions
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/wafi04/backendvazzz/pkg/types"
+)
+
+// reconcileBatchSize membatasi jumlah order yang dicek ke Duitku setiap putaran
+const reconcileBatchSize = 50
+
+// ReconcilePendingPayments polls Duitku for PENDING orders and deposits whose callback
+// has not arrived yet, and settles the ones Duitku reports as paid.
+func (repo *TransactionsRepository) ReconcilePendingPayments(ctx context.Context) error {
+	query := `
+		SELECT p.order_id
+		FROM payments p
+		JOIN transactions t ON t.order_id = p.order_id
+		WHERE p.status = $1
+		  AND t.status = $1
+		  AND p.method <> 'SALDO'
+		  AND p.created_at < NOW() - make_interval(mins => $2)
+		UNION ALL
+		SELECT d.deposit_id
+		FROM deposits d
+		WHERE d.status = $1
+		  AND d.created_at < NOW() - make_interval(mins => $2)
+		LIMIT $3
+	`
+
+	rows, err := repo.DB.QueryContext(ctx, query, types.StatusPending, repo.payment.ReconcileAfterMinutes, reconcileBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to query pending payments: %w", err)
+	}
+
+	var orderIDs []string
+	for rows.Next() {
+		var orderID string
+		if err := rows.Scan(&orderID); err != nil {
+			rows.Close()
+			return err
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	recovered := 0
+	for _, orderID := range orderIDs {
+		status, err := repo.duitkuService.CheckTransaction(ctx, orderID)
+		if err != nil {
+			log.Printf("Reconcile: failed to check %s on duitku: %v", orderID, err)
+			continue
+		}
+
+		if status.StatusCode != "00" {
+			continue
+		}
+
+		// Nominal dari Duitku harus sama dengan yang tersimpan, sama seperti callback
+		if reason := repo.checkStoredAmount(ctx, orderID, status.Amount); reason != "" {
+			log.Printf("Reconcile: refusing to settle %s: %s", orderID, reason)
+			continue
+		}
+
+		err = repo.ProcessDuitkuResult(ctx, CallbackDuitku{
+			MerchantCode:    repo.duitkuService.DuitkuMerchantCode,
+			Amount:          status.Amount,
+			RefId:           status.Reference,
+			MerchantOrderId: orderID,
+			ResultCode:      status.StatusCode,
+		}, SettledByPoll)
+		if err != nil {
+			log.Printf("Reconcile: failed to settle %s: %v", orderID, err)
+			continue
+		}
+
+		log.Printf("Reconcile: order %s recovered by polling", orderID)
+		recovered++
+	}
+
+	if recovered > 0 {
+		log.Printf("Reconcile: %d of %d pending payments recovered", recovered, len(orderIDs))
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+// ErrCallbackRejected is returned when a provider callback fails verification
+var ErrCallbackRejected = errors.New("callback rejected")
+
+// recordRejectedCallback logs a security event and keeps the raw payload for investigation
+func (repo *TransactionsRepository) recordRejectedCallback(c context.Context, provider, orderID, reason string, payload []byte) {
+	log.Printf("SECURITY: rejected %s callback for order %q: %s", provider, orderID, reason)
+
+	query := `
+		INSERT INTO callback_rejections (provider, order_id, reason, payload, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+	`
+	if _, err := repo.DB.ExecContext(c, query, provider, orderID, reason, string(payload)); err != nil {
+		log.Printf("Failed to store rejected %s callback: %v", provider, err)
+	}
+}
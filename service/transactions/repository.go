@@ -7,17 +7,40 @@ import (
 	"strings"
 	"time"
 
+	"github.com/wafi04/backendvazzz/pkg/config"
+	"github.com/wafi04/backendvazzz/pkg/lib"
 	"github.com/wafi04/backendvazzz/pkg/model"
+	"github.com/wafi04/backendvazzz/pkg/supplier"
 	"github.com/wafi04/backendvazzz/pkg/types"
+	"github.com/wafi04/backendvazzz/service/fulfillment"
+	"github.com/wafi04/backendvazzz/service/order"
+	"github.com/wafi04/backendvazzz/service/postpaid"
+	"github.com/wafi04/backendvazzz/service/providerbalance"
 )
 
 type TransactionsRepository struct {
-	DB *sql.DB
+	DB            *sql.DB
+	orderRepo     *order.OrderRepository
+	duitkuService *lib.DuitkuService
+	suppliers     *supplier.Registry
+	fulfillment   *fulfillment.FulfillmentRepository
+	balances      *providerbalance.ProviderBalanceRepository
+	postpaid      *postpaid.PostpaidRepository
+	payment       config.PaymentConfig
+	statusCheck   config.StatusCheckConfig
 }
 
-func NewTransactionsRepository(DB *sql.DB) *TransactionsRepository {
+func NewTransactionsRepository(DB *sql.DB, duitkuService *lib.DuitkuService, suppliers *supplier.Registry, fulfillmentRepo *fulfillment.FulfillmentRepository, balances *providerbalance.ProviderBalanceRepository, postpaidRepo *postpaid.PostpaidRepository, payment config.PaymentConfig, statusCheck config.StatusCheckConfig) *TransactionsRepository {
 	return &TransactionsRepository{
-		DB: DB,
+		DB:            DB,
+		orderRepo:     order.NewOrderRepository(DB),
+		duitkuService: duitkuService,
+		suppliers:     suppliers,
+		fulfillment:   fulfillmentRepo,
+		balances:      balances,
+		postpaid:      postpaidRepo,
+		payment:       payment,
+		statusCheck:   statusCheck,
 	}
 }
 
@@ -243,20 +266,37 @@ func (repo *TransactionsRepository) GetByGameID(gameId string) ([]types.Transact
 }
 
 func (repo *TransactionsRepository) UpdateStatus(id int64, status string) error {
-	query := `
-		UPDATE transactions 
-		SET status = $1, updated_at = $2, completed_at = $3
-		WHERE id = $4`
-
-	var completedAt *time.Time
-	if status == types.StatusSuccess || status == types.StatusFailed || status == types.StatusCancelled {
-		now := time.Now()
-		completedAt = &now
+	ctx := context.Background()
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var orderID string
+	if err := tx.QueryRowContext(ctx, `SELECT order_id FROM transactions WHERE id = $1`, id).Scan(&orderID); err != nil {
+		return err
+	}
+
+	_, err = repo.orderRepo.Transition(ctx, tx, order.Transition{
+		OrderID: orderID,
+		To:      status,
+		Actor:   order.SourceAdmin,
+		Source:  order.SourceAdmin,
+	})
+	if err != nil {
+		return err
 	}
 
-	_, err := repo.DB.Exec(query, status, time.Now(), completedAt, id)
-	return err
+	if order.IsFinal(status) || status == types.StatusFailed || status == types.StatusExpired {
+		if _, err := tx.ExecContext(ctx, `UPDATE transactions SET completed_at = $1 WHERE id = $2`, time.Now(), id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
+
 func (repo *TransactionsRepository) GetAllWithPayment(c context.Context, req model.FilterTransaction) ([]model.TransactionWithPayment, int, error) {
 	whereConditions := []string{"1=1"}
 	args := []interface{}{}
@@ -415,3 +455,7 @@ func (repo *TransactionsRepository) GetAllWithPayment(c context.Context, req mod
 
 	return transactions, totalCount, nil
 }
+
+func stringPtr(s string) *string {
+	return &s
+}
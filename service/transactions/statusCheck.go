@@ -0,0 +1,203 @@
+package transactions
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/wafi04/backendvazzz/pkg/supplier"
+	"github.com/wafi04/backendvazzz/pkg/types"
+	"github.com/wafi04/backendvazzz/service/order"
+	"github.com/wafi04/backendvazzz/service/postpaid"
+	"github.com/wafi04/backendvazzz/service/providerbalance"
+)
+
+const (
+	statusCheckBatchSize  = 50
+	statusCheckBaseDelay  = time.Minute
+	statusCheckMaxDelay   = 2 * time.Hour
+	statusCheckPollerName = "supplier-poller"
+)
+
+type stuckOrder struct {
+	OrderID     string
+	RefID       string
+	ProductCode string
+	UserID      string
+	Zone        *string
+	Attempts    int
+	Supplier    string
+	Type        string
+	Status      string
+}
+
+// statusCheckBackoff returns the delay before the next check, doubling on every attempt
+func statusCheckBackoff(attempts int) time.Duration {
+	delay := statusCheckBaseDelay
+	for i := 0; i < attempts; i++ {
+		delay *= 2
+		if delay >= statusCheckMaxDelay {
+			return statusCheckMaxDelay
+		}
+	}
+	return delay
+}
+
+// CheckStuckTopUps re-checks orders that the supplier has not finished yet.
+// Orders still pending after too many attempts are moved to MANUAL_REVIEW.
+func (repo *TransactionsRepository) CheckStuckTopUps(ctx context.Context) error {
+	query := `
+		SELECT t.order_id, COALESCE(t.ref_id, t.order_id), COALESCE(fa.sku, t.provider_order_id),
+			t.user_id, t.zone, t.status_check_attempts, COALESCE(t.supplier, ''), t.transaction_type, t.status
+		FROM transactions t
+		LEFT JOIN fulfillment_attempts fa ON fa.supplier_ref = t.ref_id
+		WHERE t.status IN ($1, $2)
+		  AND t.transaction_type IN ('TOPUP', $5)
+		  AND t.updated_at < NOW() - make_interval(mins => $3)
+		  AND (t.next_status_check_at IS NULL OR t.next_status_check_at <= NOW())
+		ORDER BY t.updated_at ASC
+		LIMIT $4
+	`
+
+	rows, err := repo.DB.QueryContext(ctx, query, types.StatusPaid, types.StatusProcess, repo.statusCheck.AfterMinutes, statusCheckBatchSize, postpaid.TransactionType)
+	if err != nil {
+		return fmt.Errorf("failed to query stuck top-ups: %w", err)
+	}
+
+	var orders []stuckOrder
+	for rows.Next() {
+		var o stuckOrder
+		if err := rows.Scan(&o.OrderID, &o.RefID, &o.ProductCode, &o.UserID, &o.Zone, &o.Attempts, &o.Supplier, &o.Type, &o.Status); err != nil {
+			rows.Close()
+			return err
+		}
+		orders = append(orders, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	maxAttempts := repo.statusCheck.MaxAttempts
+
+	for _, o := range orders {
+		if err := repo.checkStuckTopUp(ctx, o, maxAttempts); err != nil {
+			log.Printf("Status check for order %s failed: %v", o.OrderID, err)
+		}
+	}
+
+	return nil
+}
+
+func (repo *TransactionsRepository) checkStuckTopUp(ctx context.Context, o stuckOrder, maxAttempts int) error {
+	attempts := o.Attempts + 1
+	nextCheck := time.Now().Add(statusCheckBackoff(attempts))
+
+	_, err := repo.DB.ExecContext(ctx, `
+		UPDATE transactions
+		SET status_check_attempts = $1, next_status_check_at = $2
+		WHERE order_id = $3
+	`, attempts, nextCheck, o.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to record status check attempt: %w", err)
+	}
+
+	customerNo := o.UserID
+	if o.Zone != nil && *o.Zone != "" {
+		customerNo = fmt.Sprintf("%s%s", o.UserID, *o.Zone)
+	}
+
+	// Supplier yang tidak bisa ditentukan tetap dihitung sebagai percobaan, supaya order
+	// akhirnya masuk MANUAL_REVIEW dan tidak dicek ulang selamanya
+	s, result, err := repo.checkSupplierStatus(ctx, o, customerNo)
+	switch {
+	case s == nil:
+		log.Printf("Status check for order %s: supplier %q not resolved: %v", o.OrderID, o.Supplier, err)
+	case err != nil:
+		log.Printf("Status check request for order %s failed: %v", o.OrderID, err)
+	default:
+		repo.balances.Observe(ctx, s.Name(), result, providerbalance.SourceStatus)
+		result.RefID = o.RefID
+		next, ok := order.FromSupplierStatus(result.Status)
+		if ok && next != types.StatusProcess {
+			return repo.applySupplierResult(ctx, s.Name(), *result, statusCheckPollerName)
+		}
+		// Supplier masih memproses: order PAID dipindah ke PROCESS supaya timeline sesuai
+		if ok && o.Status == types.StatusPaid {
+			if err := repo.applySupplierResult(ctx, s.Name(), *result, statusCheckPollerName); err != nil {
+				log.Printf("Status check for order %s: failed to mark as %s: %v", o.OrderID, types.StatusProcess, err)
+			}
+		}
+	}
+
+	if attempts < maxAttempts {
+		return nil
+	}
+
+	return repo.markNeedsManualReview(ctx, o.OrderID, attempts)
+}
+
+// checkSupplierStatus asks the supplier for the order status; s is nil when the
+// supplier itself cannot be resolved
+func (repo *TransactionsRepository) checkSupplierStatus(ctx context.Context, o stuckOrder, customerNo string) (supplier.Supplier, *supplier.OrderResult, error) {
+	if o.Type == postpaid.TransactionType {
+		outcome, err := repo.postpaid.PayStatus(ctx, repo.DB, o.OrderID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return outcome.Supplier, outcome.Result, outcome.Err
+	}
+
+	s, err := repo.suppliers.Get(o.Supplier)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := s.Status(ctx, supplier.OrderRequest{
+		RefID:      o.RefID,
+		SKU:        o.ProductCode,
+		CustomerNo: customerNo,
+	})
+	return s, result, err
+}
+
+func (repo *TransactionsRepository) markNeedsManualReview(ctx context.Context, orderID string, attempts int) error {
+	tx, err := repo.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rErr := tx.Rollback(); rErr != nil && rErr != sql.ErrTxDone {
+			log.Printf("Error during transaction rollback: %v", rErr)
+		}
+	}()
+
+	_, err = repo.orderRepo.Transition(ctx, tx, order.Transition{
+		OrderID: orderID,
+		To:      types.StatusManualReview,
+		Actor:   statusCheckPollerName,
+		Source:  order.SourceSystem,
+		Payload: stringPtr(fmt.Sprintf(`{"attempts":%d}`, attempts)),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE transactions
+		SET message = 'Transaksi Sedang Dicek Manual', next_status_check_at = NULL
+		WHERE order_id = $1
+	`, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction message: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Printf("Order %s moved to manual review after %d status checks", orderID, attempts)
+	return nil
+}